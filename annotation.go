@@ -0,0 +1,198 @@
+package terminal
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Annotation is an error, warning or notice from a GitHub Actions workflow
+// command, e.g.
+//
+//	::error file=app.js,line=1,col=5,title=Syntax error::Missing semicolon
+//
+// https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#setting-an-error-message
+type Annotation struct {
+	// Level is "error", "warning" or "notice".
+	Level   string `json:"level"`
+	Title   string `json:"title,omitempty"`
+	Message string `json:"message"`
+
+	// The location the annotation refers to, if any.
+	File      string `json:"file,omitempty"`
+	Line      int    `json:"line,omitempty"`
+	EndLine   int    `json:"endLine,omitempty"`
+	Column    int    `json:"column,omitempty"`
+	EndColumn int    `json:"endColumn,omitempty"`
+
+	// ScreenLine is the index of the screen line the annotation was on.
+	ScreenLine int `json:"screenLine"`
+}
+
+// Annotations returns the GitHub Actions annotations on the screen, in order.
+func (s *Screen) Annotations() []Annotation {
+	var annotations []Annotation
+	for i, line := range s.screen {
+		if a, ok := parseAnnotation(line); ok {
+			a.ScreenLine = i
+			annotations = append(annotations, a)
+		}
+	}
+	return annotations
+}
+
+// Workflow commands as they are printed (::error ...::), and as they appear in
+// downloaded logs (##[error]).
+var annotationRegexp = regexp.MustCompile(`^(?:::(error|warning|notice)(?: ([^:]*))?::|##\[(error|warning|notice)\])`)
+
+// parseAnnotation parses a line containing an annotation workflow command.
+func parseAnnotation(line screenLine) (Annotation, bool) {
+	a, _, ok := parseAnnotationMessage(line)
+	return a, ok
+}
+
+// parseAnnotationMessage parses a line as parseAnnotation does, and also
+// returns the message as a line of its own. It keeps the nodes of the
+// original line, so the message keeps its styles and links.
+func parseAnnotationMessage(line screenLine) (Annotation, screenLine, bool) {
+	if len(line.nodes) < 2 || (line.nodes[0].blob != ':' && line.nodes[0].blob != '#') {
+		return Annotation{}, screenLine{}, false
+	}
+	text := lineString(line, len(line.nodes))
+	m := annotationRegexp.FindStringSubmatchIndex(text)
+	if m == nil {
+		return Annotation{}, screenLine{}, false
+	}
+
+	var a Annotation
+	if m[2] >= 0 {
+		a.Level = text[m[2]:m[3]]
+	} else {
+		a.Level = text[m[6]:m[7]]
+	}
+	data := strings.TrimRight(text[m[1]:], " \t")
+	a.Message = unescapeWorkflowCommandData(data)
+
+	// Each rune of the text is a node
+	start := utf8.RuneCountInString(text[:m[1]])
+	end := start + utf8.RuneCountInString(data)
+	message := screenLine{
+		nodes:    unescapeWorkflowCommandNodes(line.nodes[start:end]),
+		metadata: line.metadata,
+		apcs:     line.apcs,
+		label:    line.label,
+	}
+
+	if m[4] >= 0 {
+		for _, property := range strings.Split(text[m[4]:m[5]], ",") {
+			parts := strings.SplitN(property, "=", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			value := unescapeWorkflowCommandProperty(parts[1])
+			switch strings.TrimSpace(parts[0]) {
+			case "title":
+				a.Title = value
+			case "file":
+				a.File = value
+			case "line":
+				a.Line, _ = strconv.Atoi(value)
+			case "endLine":
+				a.EndLine, _ = strconv.Atoi(value)
+			case "col":
+				a.Column, _ = strconv.Atoi(value)
+			case "endColumn":
+				a.EndColumn, _ = strconv.Atoi(value)
+			}
+		}
+	}
+
+	return a, message, true
+}
+
+var (
+	workflowCommandDataUnescaper     = strings.NewReplacer("%25", "%", "%0D", "\r", "%0A", "\n")
+	workflowCommandPropertyUnescaper = strings.NewReplacer("%25", "%", "%0D", "\r", "%0A", "\n", "%3A", ":", "%2C", ",")
+)
+
+func unescapeWorkflowCommandData(s string) string {
+	return workflowCommandDataUnescaper.Replace(s)
+}
+
+// unescapeWorkflowCommandNodes unescapes nodes as unescapeWorkflowCommandData
+// does, each escape taking the style of its %.
+func unescapeWorkflowCommandNodes(nodes []node) []node {
+	unescaped := make([]node, 0, len(nodes))
+	for i := 0; i < len(nodes); i++ {
+		n := nodes[i]
+		if n.blob == '%' && n.elem == nil && i+2 < len(nodes) && nodes[i+1].elem == nil && nodes[i+2].elem == nil {
+			switch string([]rune{nodes[i+1].blob, nodes[i+2].blob}) {
+			case "25":
+				i += 2
+			case "0D":
+				n.blob = '\r'
+				i += 2
+			case "0A":
+				n.blob = '\n'
+				i += 2
+			}
+		}
+		unescaped = append(unescaped, n)
+	}
+	return unescaped
+}
+
+func unescapeWorkflowCommandProperty(s string) string {
+	return workflowCommandPropertyUnescaper.Replace(s)
+}
+
+// location formats the file, line and column the annotation refers to, e.g.
+// app.js:1:5, or returns "" if it doesn't refer to a file.
+func (a *Annotation) location() string {
+	if a.File == "" {
+		return ""
+	}
+	location := a.File
+	if a.Line > 0 {
+		location += ":" + strconv.Itoa(a.Line)
+		if a.Column > 0 {
+			location += ":" + strconv.Itoa(a.Column)
+		}
+	}
+	return location
+}
+
+// decoration wraps an annotation's message (which is numNodes long) in an
+// element classed by level, carrying its location as data attributes and
+// preceded by its title and location.
+func (a *Annotation) decoration(numNodes int) decoration {
+	h := html.EscapeString
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<span class="term-annotation term-annotation-%s"`, h(a.Level))
+	if a.File != "" {
+		fmt.Fprintf(&b, ` data-file="%s"`, h(a.File))
+	}
+	for _, attr := range []struct {
+		name  string
+		value int
+	}{{"line", a.Line}, {"end-line", a.EndLine}, {"column", a.Column}, {"end-column", a.EndColumn}} {
+		if attr.value > 0 {
+			fmt.Fprintf(&b, ` data-%s="%d"`, attr.name, attr.value)
+		}
+	}
+
+	title := a.Title
+	if title == "" {
+		title = strings.ToUpper(a.Level[:1]) + a.Level[1:]
+	}
+	fmt.Fprintf(&b, `><span class="term-annotation-title">%s</span> `, h(title))
+	if location := a.location(); location != "" {
+		fmt.Fprintf(&b, `<span class="term-annotation-location">%s</span> `, h(location))
+	}
+
+	return decoration{start: 0, end: numNodes, open: b.String(), close: "</span>"}
+}
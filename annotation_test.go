@@ -0,0 +1,26 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestScreenAnnotations(t *testing.T) {
+	s := NewScreen(Options{})
+	s.Parse([]byte("one\n" +
+		"::error file=src/app.js,line=10,endLine=12,col=3,endColumn=4,title=Oh no::Something%0Abroke\n" +
+		"two\n" +
+		"\x1b[31m##[warning]Careful\x1b[0m\n" +
+		"::debug::not an annotation\n" +
+		"::notice file=a%2Cb.txt::Hi\n"))
+
+	want := []Annotation{
+		{Level: "error", Title: "Oh no", Message: "Something\nbroke", File: "src/app.js", Line: 10, EndLine: 12, Column: 3, EndColumn: 4, ScreenLine: 1},
+		{Level: "warning", Message: "Careful", ScreenLine: 3},
+		{Level: "notice", Message: "Hi", File: "a,b.txt", ScreenLine: 5},
+	}
+	if diff := cmp.Diff(s.Annotations(), want); diff != "" {
+		t.Errorf("Annotations() diff (-got +want):\n%s", diff)
+	}
+}
@@ -0,0 +1,84 @@
+package terminal
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+)
+
+// appendOnlyChunk is roughly how much input renderAppendOnly parses at a
+// time, before rendering the lines that are finished.
+const appendOnlyChunk = 16 * 1024
+
+// appendOnly reports whether input never moves the cursor up or clears the
+// screen, as in most CI logs, so that lines above the cursor are final as
+// soon as it leaves them. It errs on the side of false, e.g. for sequences
+// that the parser would treat as text.
+//
+// Input with an OSC or APC sequence spanning lines isn't append only either,
+// as renderAppendOnly parses up to a newline at a time.
+func appendOnly(input []byte) bool {
+	for {
+		i := bytes.IndexByte(input, '\x1b')
+		if i < 0 || i+1 >= len(input) {
+			return true
+		}
+		input = input[i+1:]
+
+		switch input[0] {
+		case 'M', '8':
+			// Reverse index, restore cursor
+			return false
+		case ']', '_':
+			end := bytes.IndexByte(input, '\a')
+			if end < 0 {
+				end = len(input)
+			}
+			if bytes.IndexByte(input[:end], '\n') >= 0 {
+				return false
+			}
+			input = input[end:]
+		case '[':
+			// Skip the parameters to find the final character, which the
+			// parser upper cases
+			params := bytes.IndexFunc(input[1:], func(r rune) bool {
+				return r != '?' && r != ';' && (r < '0' || r > '9')
+			})
+			if params < 0 {
+				return true
+			}
+			final, _ := utf8.DecodeRune(input[1+params:])
+			if final := unicode.ToUpper(final); final == 'A' || final == 'J' {
+				// Cursor up, erase in display
+				return false
+			}
+		}
+	}
+}
+
+// renderAppendOnly renders input that's appendOnly the same as
+// RenderWithOptions, but a chunk at a time with a Streamer, rendering lines
+// as soon as the cursor leaves them, rather than keeping the whole screen in
+// memory.
+func renderAppendOnly(input []byte, opts Options) []byte {
+	var buf bytes.Buffer
+	// Escape sequences that change the style take more bytes as spans than
+	// they do in the input, though many only repeat the current style, so on
+	// the fixtures this comes to about 8 bytes more for each sequence.
+	buf.Grow(len(input) + bytes.Count(input, []byte{'\x1b'})*8)
+	s := NewStreamer(&buf, opts)
+	for len(input) > 0 {
+		end := len(input)
+		if end > appendOnlyChunk {
+			if i := bytes.IndexByte(input[appendOnlyChunk:], '\n'); i >= 0 {
+				end = appendOnlyChunk + i + 1
+			}
+		}
+		s.screen.Parse(input[:end])
+		input = input[end:]
+		// Writing to a bytes.Buffer can't fail
+		_ = s.flush(s.screen.y)
+	}
+	_ = s.Close()
+	return buf.Bytes()
+}
@@ -0,0 +1,68 @@
+package terminal
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+)
+
+func TestAppendOnly(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  bool
+	}{
+		{"plain\nlines\n", true},
+		{"\x1b[31mred\x1b[0m\r\x1b[2Kprogress\x1b[1B\x1b[5C", true},
+		{"\x1b]1339;url=http://example.com\a\n\x1b_bk;t=123\a", true},
+		{"unterminated \x1b[", true},
+		{"one\ntwo\x1b[1Aup", false},
+		{"one\ntwo\x1b[aup", false},
+		{"\x1b[2J", false},
+		{"\x1b[J", false},
+		{"one\x1bMtwo", false},
+		{"\x1b7one\ntwo\x1b8", false},
+		{"\x1b]1339;url=http://example.com;content=two\nlines\a", false},
+	}
+	for _, tc := range testCases {
+		if got := appendOnly([]byte(tc.input)); got != tc.want {
+			t.Errorf("appendOnly(%q) = %t, want %t", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestRenderAppendOnlyMatchesScreen(t *testing.T) {
+	optionSets := map[string]Options{
+		"default":      {},
+		"line numbers": {LineNumbers: LineNumberDataAttribute, ElapsedTime: true},
+		"max lines":    {MaxLines: 5},
+		"breaks":       {BreakElements: true},
+		"progress":     {ProgressFrames: 2, Search: regexp.MustCompile("info")},
+	}
+
+	for _, base := range TestFiles {
+		raw, err := os.ReadFile(fmt.Sprintf("fixtures/%s.raw", base))
+		if err != nil {
+			t.Fatalf("could not read fixture: %v", err)
+		}
+		if !appendOnly(raw) {
+			continue
+		}
+		inputs := [][]byte{raw}
+		if len(raw) < appendOnlyChunk {
+			// Long enough to be rendered in more than one chunk
+			inputs = append(inputs, bytes.Repeat(raw, appendOnlyChunk/len(raw)+2))
+		}
+
+		for name, opts := range optionSets {
+			for _, input := range inputs {
+				screen := NewScreen(opts)
+				screen.Parse(input)
+				if got, want := renderAppendOnly(input, opts), screen.AsHTML(); !bytes.Equal(got, want) {
+					t.Errorf("%s with %s options, %d bytes: renderAppendOnly() differs from Screen.AsHTML()\ngot:\n%s\nwant:\n%s", base, name, len(input), got, want)
+				}
+			}
+		}
+	}
+}
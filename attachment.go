@@ -0,0 +1,111 @@
+package terminal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// An Attachment is a file sent with iTerm2's 1337;File sequence that isn't
+// shown as an image, such as a JUnit XML report or a coverage file, kept for
+// Options.Attachments.
+type Attachment struct {
+	// Name is the file's name, and ContentType its type as guessed from the
+	// name's extension, or "" if it has none.
+	Name        string `json:"name"`
+	ContentType string `json:"contentType,omitempty"`
+
+	// Size is the size of the file in bytes.
+	Size int `json:"size"`
+
+	// Content is the file's contents, base64-encoded as they were sent, or
+	// "" if they didn't fit within MaxInlineImageBytes or
+	// MaxTotalInlineImageBytes, or CSPSafe is set.
+	Content string `json:"content,omitempty"`
+
+	// Line is the number of the line the file was sent on, counting from 1.
+	Line int `json:"line"`
+}
+
+// Attachments returns the files sent on the screen that aren't shown as
+// images, in order, if Options.Attachments keeps them.
+func (s *Screen) Attachments() []Attachment {
+	return s.attachments
+}
+
+// parseAttachmentSequence parses an OSC sequence for a file, e.g.
+// 1337;File=name=anVuaXQueG1s:BASE64, returning it as an attachment if it
+// isn't an image to show inline: it doesn't have inline=1, or its type isn't
+// an image. fits reports whether the file's content can be kept.
+func parseAttachmentSequence(sequence []byte, fits func(size int) bool) (Attachment, bool) {
+	args, elementType, content, err := splitAndVerifyElementSequence(sequence)
+	if err != nil || elementType != ELEMENT_ITERM_IMAGE {
+		return Attachment{}, false
+	}
+	tokens, err := tokenizeString(args, ';', '\\')
+	if err != nil {
+		return Attachment{}, false
+	}
+
+	var a Attachment
+	inline := false
+	for _, token := range tokens {
+		key, val, ok := strings.Cut(token, "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "name":
+			name, err := base64.StdEncoding.DecodeString(val)
+			if err != nil {
+				return Attachment{}, false
+			}
+			a.Name = string(name)
+			a.ContentType = contentTypeForFile(a.Name)
+		case "inline":
+			inline = val == "1"
+		}
+	}
+	if inline && strings.HasPrefix(a.ContentType, "image/") {
+		return Attachment{}, false
+	}
+
+	a.Size = inlineImageSize(content)
+	if fits == nil || fits(a.Size) {
+		a.Content = string(content)
+	}
+	return a, true
+}
+
+// attachmentsHTML lists the attachments as links to download them, in a
+// collapsible <details> element, or returns "" if there are none.
+func attachmentsHTML(attachments []Attachment) string {
+	if len(attachments) == 0 {
+		return ""
+	}
+	h := html.EscapeString
+	var b bytes.Buffer
+	b.WriteString(`<details class="term-attachments"><summary>Attachments</summary><ul>`)
+	for _, a := range attachments {
+		name := a.Name
+		if name == "" {
+			name = "unnamed file"
+		}
+		b.WriteString("<li>")
+		if a.Content != "" {
+			// Without the space of e.g. "text/xml; charset=utf-8"
+			contentType := strings.ReplaceAll(a.ContentType, " ", "")
+			if contentType == "" {
+				contentType = "application/octet-stream"
+			}
+			fmt.Fprintf(&b, `<a href="data:%s;base64,%s" download="%s">%s</a>`, h(contentType), h(a.Content), h(name), h(name))
+		} else {
+			b.WriteString(h(name))
+		}
+		fmt.Fprintf(&b, ` <span class="term-attachment-details">line %d, %d bytes</span></li>`, a.Line, a.Size)
+	}
+	b.WriteString("</ul></details>")
+	return b.String()
+}
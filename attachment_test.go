@@ -0,0 +1,95 @@
+package terminal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fileSequence returns the 1337;File sequence sending a file, with any extra
+// arguments, e.g. "inline=1".
+func fileSequence(name, content string, args ...string) string {
+	args = append([]string{"name=" + base64.StdEncoding.EncodeToString([]byte(name))}, args...)
+	return "\x1b]1337;File=" + strings.Join(args, ";") + ":" + base64.StdEncoding.EncodeToString([]byte(content)) + "\a"
+}
+
+func TestAttachments(t *testing.T) {
+	input := "tests\n" + fileSequence("junit.xml", "<testsuite/>") + "coverage" + fileSequence("coverage.out", "mode: set", "inline=1") +
+		"\n" + fileSequence("shot.png", "png", "inline=1") + "done"
+
+	screen := NewScreen(Options{Attachments: AttachmentsCollected})
+	screen.Parse([]byte(input))
+	want := []Attachment{
+		{Name: "junit.xml", ContentType: "text/xml; charset=utf-8", Size: 12, Content: "PHRlc3RzdWl0ZS8+", Line: 2},
+		{Name: "coverage.out", Size: 9, Content: "bW9kZTogc2V0", Line: 2},
+	}
+	if diff := cmp.Diff(screen.Attachments(), want); diff != "" {
+		t.Errorf("Attachments() diff (-got +want):\n%s", diff)
+	}
+	if got, want := string(screen.AsHTML()), "tests\ncoverage\n"+`<img alt="shot.png" src="data:image/png;base64,cG5n" loading="lazy" decoding="async">`+"\ndone"; got != want {
+		t.Errorf("AsHTML() = %q, want %q", got, want)
+	}
+	if got := string(SerializeMetadata(screen)); !strings.Contains(got, `"attachments":[{"name":"junit.xml"`) {
+		t.Errorf("SerializeMetadata() = %s, want the attachments", got)
+	}
+
+	// Files over the limit are kept without their content
+	screen = NewScreen(Options{Attachments: AttachmentsCollected, MaxInlineImageBytes: 10})
+	screen.Parse([]byte(input))
+	if got := screen.Attachments(); len(got) != 2 || got[0].Content != "" || got[0].Size != 12 || got[1].Content == "" {
+		t.Errorf("with MaxInlineImageBytes, Attachments() = %+v, want the first without content", got)
+	}
+
+	// By default they're dropped, and inline ones rendered as images
+	screen = NewScreen(Options{})
+	screen.Parse([]byte(input))
+	if got := screen.Attachments(); got != nil {
+		t.Errorf("by default, Attachments() = %+v, want none", got)
+	}
+}
+
+func TestAttachmentsListed(t *testing.T) {
+	input := "report" + fileSequence("junit.xml", "<testsuite/>") + "\n" + fileSequence("big & <odd>.bin", "01234567890123456789")
+	got := string(RenderWithOptions([]byte(input), Options{Attachments: AttachmentsListed, MaxInlineImageBytes: 12}))
+	want := "report" + `<details class="term-attachments"><summary>Attachments</summary><ul>` +
+		`<li><a href="data:text/xml;charset=utf-8;base64,PHRlc3RzdWl0ZS8+" download="junit.xml">junit.xml</a> <span class="term-attachment-details">line 1, 12 bytes</span></li>` +
+		`<li>big &amp; &lt;odd&gt;.bin <span class="term-attachment-details">line 2, 20 bytes</span></li>` +
+		`</ul></details>`
+	if got != want {
+		t.Errorf("RenderWithOptions(%q) = %q, want %q", input, got, want)
+	}
+
+	if got := string(RenderWithOptions([]byte("no files"), Options{Attachments: AttachmentsListed})); got != "no files" {
+		t.Errorf("without attachments, RenderWithOptions() = %q, want no list", got)
+	}
+}
+
+func TestAttachmentsListedStreamed(t *testing.T) {
+	input := "report\n" + fileSequence("junit.xml", "<testsuite/>") + "done\n"
+	want := string(RenderWithOptions([]byte(input), Options{Attachments: AttachmentsListed}))
+	var buf bytes.Buffer
+	s := NewStreamer(&buf, Options{Attachments: AttachmentsListed})
+	s.Write([]byte(input))
+	s.Close()
+	if got := buf.String(); got != want {
+		t.Errorf("streamed = %q, want %q", got, want)
+	}
+}
+
+func TestAttachmentsListedMerged(t *testing.T) {
+	streams := []Stream{
+		{Input: []byte("\x1b_bk;t=1000\x07a1\n\x1b_bk;t=3000\x07a2" + fileSequence("a.xml", "a"))},
+		{Input: []byte("\x1b_bk;t=2000\x07b1" + fileSequence("b.xml", "b"))},
+	}
+	got := string(RenderMerged(streams, Options{Attachments: AttachmentsListed, OmitProcessingInstructions: true}))
+	want := "a1\nb1\na2" + `<details class="term-attachments"><summary>Attachments</summary><ul>` +
+		`<li><a href="data:text/xml;charset=utf-8;base64,Yg==" download="b.xml">b.xml</a> <span class="term-attachment-details">line 2, 1 bytes</span></li>` +
+		`<li><a href="data:text/xml;charset=utf-8;base64,YQ==" download="a.xml">a.xml</a> <span class="term-attachment-details">line 3, 1 bytes</span></li>` +
+		`</ul></details>`
+	if got != want {
+		t.Errorf("RenderMerged() = %q, want %q", got, want)
+	}
+}
@@ -2,19 +2,22 @@ package terminal
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 const bkNamespace = "bk"
 
-// Parse an Application Program Command sequence, which may or may not be a
-// Buildkite APC, e.g. bk;t=123123234234234;llamas=blah
-func parseApcBk(sequence string) (map[string]string, error) {
-	if !strings.HasPrefix(sequence, bkNamespace+";") {
+// Parse an Application Program Command sequence, which may or may not be in
+// the given namespace, e.g. bk;t=123123234234234;llamas=blah
+func parseApcNamespace(namespace string, sequence []byte) (map[string]string, error) {
+	if len(sequence) <= len(namespace) || string(sequence[:len(namespace)]) != namespace || sequence[len(namespace)] != ';' {
 		return nil, nil
 	}
 
-	tokens, err := tokenizeString(sequence[3:], ';', '\\')
+	tokens, err := tokenizeString(string(sequence[len(namespace)+1:]), ';', '\\')
 	if err != nil {
 		return nil, err
 	}
@@ -31,3 +34,52 @@ func parseApcBk(sequence string) (map[string]string, error) {
 
 	return data, nil
 }
+
+// escapeComment percent-encodes the characters of an APC payload that could
+// end or break an HTML comment: percent signs, hyphens, angle brackets,
+// control characters and invalid UTF-8.
+func escapeComment(payload string) string {
+	var b strings.Builder
+	for i := 0; i < len(payload); {
+		r, n := utf8.DecodeRuneInString(payload[i:])
+		if (r == utf8.RuneError && n == 1) || r < 0x20 || r == 0x7f || strings.ContainsRune("%-<>", r) {
+			fmt.Fprintf(&b, "%%%02X", payload[i])
+		} else {
+			b.WriteString(payload[i : i+n])
+		}
+		i += n
+	}
+	return b.String()
+}
+
+// splitTimestamp separates the timestamp (t) from the rest of a line's bk
+// metadata.
+func splitTimestamp(data map[string]string) (timestamp string, rest map[string]string) {
+	timestamp, ok := data["t"]
+	if !ok {
+		return "", data
+	}
+	rest = make(map[string]string, len(data)-1)
+	for k, v := range data {
+		if k != "t" {
+			rest[k] = v
+		}
+	}
+	return timestamp, rest
+}
+
+// timestampAsDatetime converts a timestamp in milliseconds since the Unix
+// epoch into a valid datetime for a <time> element.
+func timestampAsDatetime(timestamp string) (string, bool) {
+	ms, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", false
+	}
+	return time.UnixMilli(ms).UTC().Format("2006-01-02T15:04:05.000Z07:00"), true
+}
+
+// Parse an Application Program Command sequence, which may or may not be a
+// Buildkite APC, e.g. bk;t=123123234234234;llamas=blah
+func parseApcBk(sequence []byte) (map[string]string, error) {
+	return parseApcNamespace(bkNamespace, sequence)
+}
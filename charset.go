@@ -0,0 +1,231 @@
+package terminal
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// LookupInputEncoding returns the encoding with the given name or label, as
+// used for HTML, e.g. "windows-1252", "latin1" or "utf-16le", for
+// Options.InputEncoding.
+func LookupInputEncoding(name string) (encoding.Encoding, error) {
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown encoding %q", name)
+	}
+	return enc, nil
+}
+
+const (
+	// detectSample is how much of the input detectEncoding looks at.
+	detectSample = 4096
+
+	// minUTF16Sample is how much input with zero bytes detectEncoding
+	// waits for, to tell if it's UTF-16.
+	minUTF16Sample = 16
+)
+
+var (
+	utf8BOM    = []byte("\xef\xbb\xbf")
+	utf16LEBOM = []byte("\xff\xfe")
+	utf16BEBOM = []byte("\xfe\xff")
+)
+
+// detectEncoding guesses the encoding of input from its start: UTF-8 or
+// UTF-16 with a byte order mark, UTF-16 from the zero high bytes of mostly
+// ASCII text, or Windows-1252 (which covers ISO 8859-1) if it isn't valid
+// UTF-8. It returns nil for UTF-8, which needs no transcoding, and false if
+// it can't tell yet before atEOF, as input is too short, or plain ASCII
+// apart from a character cut off at the end.
+func detectEncoding(input []byte, atEOF bool) (encoding.Encoding, bool) {
+	switch {
+	case bytes.HasPrefix(input, utf8BOM):
+		return unicode.UTF8BOM, true
+	case bytes.HasPrefix(input, utf16LEBOM):
+		return unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM), true
+	case bytes.HasPrefix(input, utf16BEBOM):
+		return unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM), true
+	}
+	if !atEOF {
+		// Wait for the rest of a byte order mark, or enough to count zeros
+		for _, bom := range [][]byte{utf8BOM, utf16LEBOM, utf16BEBOM} {
+			if len(input) < len(bom) && bytes.HasPrefix(bom, input) {
+				return nil, false
+			}
+		}
+		if len(input) < minUTF16Sample && bytes.IndexByte(input, 0) >= 0 {
+			return nil, false
+		}
+	}
+
+	sample := input[:min(len(input), detectSample)]
+	var zeros [2]int
+	for i, c := range sample {
+		if c == 0 {
+			zeros[i%2]++
+		}
+	}
+	// Both bytes of a character are rarely zero, so a zero in one position
+	// and not the other is UTF-16, and in which position gives the order
+	if pairs := len(sample) / 2; pairs > 0 {
+		switch {
+		case zeros[1] > pairs/4 && zeros[0] <= zeros[1]/8:
+			return unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), true
+		case zeros[0] > pairs/4 && zeros[1] <= zeros[0]/8:
+			return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), true
+		}
+	}
+
+	if !atEOF || len(sample) < len(input) {
+		// A character can be cut off by the end of the sample
+		for i := len(sample) - 1; i >= max(len(sample)-utf8.UTFMax, 0); i-- {
+			if utf8.RuneStart(sample[i]) {
+				if !utf8.FullRune(sample[i:]) {
+					sample = sample[:i]
+				}
+				break
+			}
+		}
+	}
+	if plainASCII(sample) {
+		return nil, atEOF || len(input) >= detectSample
+	}
+	if utf8.Valid(sample) {
+		return nil, true
+	}
+	return charmap.Windows1252, true
+}
+
+// inputDecoder transcodes input to UTF-8 for opts.InputEncoding or
+// opts.DetectInputEncoding, keeping the bytes of any character cut off at
+// the end of the input for the next call.
+type inputDecoder struct {
+	started     bool
+	transformer transform.Transformer
+	pending     []byte
+
+	// Set at the end of the input, to decode a character cut off by it as
+	// U+FFFD
+	atEOF bool
+}
+
+// decode returns input transcoded to UTF-8. The encoding is detected, if
+// needed, from the first input that isn't plain ASCII, which reads the same
+// in UTF-8 and Windows-1252.
+func (d *inputDecoder) decode(input []byte, opts *Options) []byte {
+	if !d.started {
+		enc := opts.InputEncoding
+		if enc == nil && opts.DetectInputEncoding {
+			sample := input
+			if len(d.pending) > 0 {
+				sample = append(d.pending, input...)
+			}
+			if plainASCII(sample) {
+				return input
+			}
+			var ok bool
+			if enc, ok = detectEncoding(sample, d.atEOF); !ok {
+				// Copied, as the caller can reuse input
+				d.pending = append([]byte(nil), sample...)
+				return nil
+			}
+		}
+		d.started = true
+		if enc != nil {
+			d.transformer = enc.NewDecoder()
+		}
+	}
+
+	src := input
+	if len(d.pending) > 0 {
+		src = append(d.pending, input...)
+		d.pending = nil
+	}
+	if d.transformer == nil {
+		return src
+	}
+	// Each byte of a single byte encoding can take up to 3 bytes in UTF-8
+	dst := make([]byte, len(src)*3+utf8.UTFMax)
+	n := 0
+	for {
+		nDst, nSrc, err := d.transformer.Transform(dst[n:], src, d.atEOF)
+		n += nDst
+		src = src[nSrc:]
+		if err == transform.ErrShortDst {
+			dst = append(dst, make([]byte, len(src)*3+utf8.UTFMax)...)
+			continue
+		}
+		// Otherwise src is empty, or ends part way through a character
+		break
+	}
+	if len(src) > 0 {
+		d.pending = append([]byte(nil), src...)
+	}
+	return dst[:n]
+}
+
+// plainASCII reports whether input is all ASCII, without the NUL bytes of
+// UTF-16.
+func plainASCII(input []byte) bool {
+	for _, c := range input {
+		if c == 0 || c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeInput transcodes all of input to UTF-8 for opts.InputEncoding or
+// opts.DetectInputEncoding.
+func decodeInput(input []byte, opts Options) []byte {
+	d := inputDecoder{atEOF: true}
+	return d.decode(input, &opts)
+}
+
+// Transcode returns a reader of r's content transcoded to UTF-8 from the
+// encoding it's detected to be in, as for Options.DetectInputEncoding, so
+// that logs from a mix of machines can be read without saying which
+// encoding each is in. UTF-8 input is read as it is.
+func Transcode(r io.Reader) io.Reader {
+	return &transcoder{r: r, opts: Options{DetectInputEncoding: true}}
+}
+
+// transcoder is the reader returned by Transcode.
+type transcoder struct {
+	r       io.Reader
+	opts    Options
+	decoder inputDecoder
+	buf     []byte
+	decoded []byte
+	err     error
+}
+
+func (t *transcoder) Read(p []byte) (int, error) {
+	for len(t.decoded) == 0 && t.err == nil {
+		if t.buf == nil {
+			t.buf = make([]byte, 32*1024)
+		}
+		n, err := t.r.Read(t.buf)
+		if err == io.EOF {
+			t.decoder.atEOF = true
+		}
+		if n > 0 || t.decoder.atEOF {
+			t.decoded = t.decoder.decode(t.buf[:n], &t.opts)
+		}
+		t.err = err
+	}
+	n := copy(p, t.decoded)
+	t.decoded = t.decoded[n:]
+	if len(t.decoded) > 0 {
+		return n, nil
+	}
+	return n, t.err
+}
@@ -0,0 +1,156 @@
+package terminal
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// utf16 encodes s as UTF-16, with the bytes of each character in the given
+// order.
+func utf16(s string, bigEndian bool) []byte {
+	var b []byte
+	for _, r := range s {
+		if bigEndian {
+			b = append(b, byte(r>>8), byte(r))
+		} else {
+			b = append(b, byte(r), byte(r>>8))
+		}
+	}
+	return b
+}
+
+func TestInputEncoding(t *testing.T) {
+	want := RenderWithOptions([]byte("\x1b[31mcafé €5\x1b[0m\nnaïve"), Options{})
+	testCases := []struct {
+		name  string
+		input []byte
+		opts  Options
+	}{
+		{"declared windows-1252", []byte("\x1b[31mcaf\xe9 \x805\x1b[0m\nna\xefve"), Options{InputEncoding: charmap.Windows1252}},
+		{"detected windows-1252", []byte("\x1b[31mcaf\xe9 \x805\x1b[0m\nna\xefve"), Options{DetectInputEncoding: true}},
+		{"detected utf-8", []byte("\x1b[31mcafé €5\x1b[0m\nnaïve"), Options{DetectInputEncoding: true}},
+		{"detected utf-8 bom", []byte("\xef\xbb\xbf\x1b[31mcafé €5\x1b[0m\nnaïve"), Options{DetectInputEncoding: true}},
+		{"detected utf-16le bom", append([]byte("\xff\xfe"), utf16("\x1b[31mcafé €5\x1b[0m\nnaïve", false)...), Options{DetectInputEncoding: true}},
+		{"detected utf-16be bom", append([]byte("\xfe\xff"), utf16("\x1b[31mcafé €5\x1b[0m\nnaïve", true)...), Options{DetectInputEncoding: true}},
+		{"detected utf-16le", utf16("\x1b[31mcafé €5\x1b[0m\nnaïve", false), Options{DetectInputEncoding: true}},
+		{"detected utf-16be", utf16("\x1b[31mcafé €5\x1b[0m\nnaïve", true), Options{DetectInputEncoding: true}},
+	}
+	for _, tc := range testCases {
+		if got := RenderWithOptions(tc.input, tc.opts); !bytes.Equal(got, want) {
+			t.Errorf("%s: RenderWithOptions = %q, want %q", tc.name, got, want)
+		}
+
+		// Decoded a byte at a time after the first line, to cut characters
+		// in two
+		var d inputDecoder
+		first := bytes.IndexByte(tc.input, 'c')
+		decoded := bytes.Clone(d.decode(tc.input[:first], &tc.opts))
+		for i := first; i < len(tc.input); i++ {
+			decoded = append(decoded, d.decode(tc.input[i:i+1], &tc.opts)...)
+		}
+		if got, want := string(decoded), "\x1b[31mcafé €5\x1b[0m\nnaïve"; got != want {
+			t.Errorf("%s: decoded a byte at a time = %q, want %q", tc.name, got, want)
+		}
+	}
+}
+
+func TestScreenCloseInputEncoding(t *testing.T) {
+	testCases := []struct {
+		input string
+		opts  Options
+		want  string
+	}{
+		// Too short to tell the encoding of until the end
+		{"caf\xe9", Options{DetectInputEncoding: true}, "café"},
+		{"a\x00b\x00", Options{DetectInputEncoding: true}, "ab"},
+		// Ending part way through a character
+		{"a\x00b", Options{InputEncoding: unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)}, "a\ufffd"},
+	}
+	for _, tc := range testCases {
+		s := NewScreen(tc.opts)
+		s.Parse([]byte(tc.input))
+		s.Close()
+		if got := string(s.AsHTML()); got != tc.want {
+			t.Errorf("Parse(%q) and Close() = %q, want %q", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestDetectEncodingLongInput(t *testing.T) {
+	// The sample ends part way through a character
+	input := []byte(strings.Repeat("a", detectSample-1) + "é")
+	if enc, _ := detectEncoding(input, false); enc != nil {
+		t.Errorf("detectEncoding(valid UTF-8 cut off by the sample) = %v, want nil", enc)
+	}
+	// Only the start of the input is looked at
+	input = []byte(strings.Repeat("a", detectSample) + "\xe9")
+	if enc, _ := detectEncoding(input, false); enc != nil {
+		t.Errorf("detectEncoding(windows-1252 after the sample) = %v, want nil", enc)
+	}
+}
+
+func TestLookupInputEncoding(t *testing.T) {
+	for _, name := range []string{"windows-1252", "latin1", "ISO-8859-1", "utf-16le", "cp1251", "shift_jis"} {
+		if _, err := LookupInputEncoding(name); err != nil {
+			t.Errorf("LookupInputEncoding(%q) error = %v", name, err)
+		}
+	}
+	if _, err := LookupInputEncoding("ebcdic"); err == nil {
+		t.Error(`LookupInputEncoding("ebcdic") error = nil, want an error`)
+	}
+}
+
+func TestStreamerInputEncoding(t *testing.T) {
+	input := utf16(strings.Repeat("\x1b[32mgrün\x1b[0m\n", 3)+"ok", false)
+	opts := Options{DetectInputEncoding: true}
+
+	var buf bytes.Buffer
+	s := NewStreamer(&buf, opts)
+	for i := 0; i < len(input); i += 3 {
+		if _, err := s.Write(input[i:min(i+3, len(input))]); err != nil {
+			t.Fatalf("s.Write() error = %v", err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("s.Close() error = %v", err)
+	}
+	if got, want := buf.String(), string(RenderWithOptions(input, opts)); got != want {
+		t.Errorf("streamed output = %q, want %q", got, want)
+	}
+}
+
+func TestTranscode(t *testing.T) {
+	want := strings.Repeat("naïve café €5\n", 1000)
+	testCases := []struct {
+		name  string
+		input []byte
+	}{
+		{"utf-8", []byte(want)},
+		{"windows-1252", bytes.Repeat([]byte("na\xefve caf\xe9 \x805\n"), 1000)},
+		{"utf-16le", append([]byte("\xff\xfe"), utf16(want, false)...)},
+		{"utf-16be", utf16(want, true)},
+	}
+	for _, tc := range testCases {
+		// Read a byte at a time, to cut characters in two
+		got, err := io.ReadAll(Transcode(iotest.OneByteReader(bytes.NewReader(tc.input))))
+		if err != nil {
+			t.Errorf("%s: reading Transcode() error = %v", tc.name, err)
+			continue
+		}
+		if string(got) != want {
+			t.Errorf("%s: Transcode() read %q..., want %q...", tc.name, got[:min(len(got), 40)], want[:40])
+		}
+	}
+
+	// A character cut off by the end of the input is replaced
+	got, err := io.ReadAll(Transcode(bytes.NewReader([]byte("\xff\xfeo\x00k\x00!"))))
+	if err != nil || string(got) != "ok\ufffd" {
+		t.Errorf("Transcode(cut off UTF-16) read %q, %v, want %q", got, err, "ok\ufffd")
+	}
+}
@@ -0,0 +1,55 @@
+//go:build cgo
+
+// libterminal-to-html is terminal-to-html as a C shared library, so services in
+// other languages can link the renderer rather than running the binary. Build
+// it with make c-shared, which also writes the header, libterminal-to-html.h:
+//
+//	char *terminal_to_html(char *input, size_t len, char *options, size_t *out_len);
+//	void terminal_to_html_free(char *html);
+//
+// options is NULL or a JSON object with camelCased Options fields, e.g.
+// {"lineNumbers": "gutter", "buildkiteGroups": true}. terminal_to_html returns
+// the HTML, to be freed with terminal_to_html_free, and sets *out_len, unless
+// out_len is NULL, to its length. As the HTML can contain NUL bytes from the
+// input, *out_len is its length rather than the first NUL, though one follows
+// it too. NULL is returned if the options are invalid.
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"math"
+	"unsafe"
+
+	"github.com/buildkite/terminal-to-html/v3"
+	"github.com/buildkite/terminal-to-html/v3/internal/jsonopts"
+)
+
+//export terminal_to_html
+func terminal_to_html(input *C.char, length C.size_t, options *C.char, outLen *C.size_t) *C.char {
+	if uint64(length) > math.MaxInt {
+		return nil
+	}
+	var opts terminal.Options
+	if options != nil {
+		var err error
+		if opts, err = jsonopts.Parse([]byte(C.GoString(options))); err != nil {
+			return nil
+		}
+	}
+	html := terminal.RenderWithOptions(unsafe.Slice((*byte)(unsafe.Pointer(input)), int(length)), opts)
+	if outLen != nil {
+		*outLen = C.size_t(len(html))
+	}
+	return (*C.char)(C.CBytes(append(html, 0)))
+}
+
+//export terminal_to_html_free
+func terminal_to_html_free(html *C.char) {
+	C.free(unsafe.Pointer(html))
+}
+
+func main() {}
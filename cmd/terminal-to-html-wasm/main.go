@@ -0,0 +1,87 @@
+//go:build js && wasm
+
+// terminal-to-html-wasm renders terminal output in the browser, with the same
+// Go code as the server. Loaded with wasm_exec.js from the Go distribution, it
+// defines a global terminalToHTML object:
+//
+//	terminalToHTML.render(input, options) // HTML of input, a string or Uint8Array
+//	terminalToHTML.css(theme)             // the stylesheet, optionally in a theme
+//	terminalToHTML.version                // the version of terminal-to-html
+//
+// options is an optional object with camelCased Options fields, e.g.
+// {lineNumbers: "gutter", buildkiteGroups: true, search: "error"}. Options
+// that are Go functions aren't available, except Redact as redactStrings, an
+// array of the strings to redact. Invalid options make render return an
+// Error.
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/buildkite/terminal-to-html/v3"
+	"github.com/buildkite/terminal-to-html/v3/internal/assets"
+	"github.com/buildkite/terminal-to-html/v3/internal/jsonopts"
+)
+
+func main() {
+	js.Global().Set("terminalToHTML", js.ValueOf(map[string]any{
+		"render":  js.FuncOf(render),
+		"css":     js.FuncOf(css),
+		"version": terminal.Version(),
+	}))
+
+	// Keep the functions available
+	select {}
+}
+
+func render(this js.Value, args []js.Value) any {
+	if len(args) == 0 {
+		return jsError(fmt.Errorf("render needs input"))
+	}
+	var input []byte
+	switch {
+	case args[0].Type() == js.TypeString:
+		input = []byte(args[0].String())
+	case args[0].InstanceOf(js.Global().Get("Uint8Array")):
+		input = make([]byte, args[0].Length())
+		js.CopyBytesToGo(input, args[0])
+	default:
+		return jsError(fmt.Errorf("render needs a string or Uint8Array"))
+	}
+
+	var opts terminal.Options
+	if len(args) > 1 && args[1].Type() == js.TypeObject {
+		var err error
+		if opts, err = parseOptions(args[1]); err != nil {
+			return jsError(err)
+		}
+	}
+	return string(terminal.RenderWithOptions(input, opts))
+}
+
+func css(this js.Value, args []js.Value) any {
+	theme := assets.Themes[0]
+	if len(args) > 0 && args[0].Type() == js.TypeString {
+		var ok bool
+		if theme, ok = assets.FindTheme(args[0].String()); !ok {
+			return jsError(fmt.Errorf("unknown theme %q", args[0].String()))
+		}
+	}
+	styleSheet, err := assets.ThemeCSS(theme)
+	if err != nil {
+		return jsError(err)
+	}
+	return string(styleSheet)
+}
+
+// parseOptions converts a JavaScript options object to Options, by way of
+// JSON, so that it accepts the same options as the C library.
+func parseOptions(o js.Value) (terminal.Options, error) {
+	json := js.Global().Get("JSON").Call("stringify", o)
+	return jsonopts.Parse([]byte(json.String()))
+}
+
+func jsError(err error) js.Value {
+	return js.Global().Get("Error").New(err.Error())
+}
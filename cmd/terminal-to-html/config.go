@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"github.com/urfave/cli/v2/altsrc"
+)
+
+// envVars returns the environment variable that can set a flag, e.g.
+// TERMINAL_TO_HTML_MAX_LINES for --max-lines.
+func envVars(flag string) []string {
+	return []string{"TERMINAL_TO_HTML_" + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))}
+}
+
+// configSource loads the file given by --config, as YAML or TOML depending on
+// its extension. Its keys are flag names, e.g. max-lines. Flags given on the
+// command line or in the environment take precedence.
+func configSource(c *cli.Context) (altsrc.InputSourceContext, error) {
+	path := c.String("config")
+	switch strings.ToLower(filepath.Ext(path)) {
+	case "":
+		if path == "" {
+			return altsrc.NewMapInputSource("", map[any]any{}), nil
+		}
+	case ".yaml", ".yml":
+		return altsrc.NewYamlSourceFromFile(path)
+	case ".toml":
+		return altsrc.NewTomlSourceFromFile(path)
+	}
+	return nil, fmt.Errorf("unknown config file type %q, expected .yaml, .yml or .toml", path)
+}
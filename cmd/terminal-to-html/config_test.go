@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestConfig(t *testing.T) {
+	yaml := writeFile(t, "server.yaml", "width: 3\n")
+	toml := writeFile(t, "server.toml", "width = 3\n")
+
+	testCases := []struct {
+		name string
+		env  string
+		args []string
+		want string
+	}{
+		{"yaml", "", []string{"--config", yaml}, "abc\ndef"},
+		{"toml", "", []string{"--config", toml}, "abc\ndef"},
+		{"environment", "2", nil, "ab\ncd\nef"},
+		{"environment over config", "2", []string{"--config", yaml}, "ab\ncd\nef"},
+		{"command line over environment", "2", []string{"--width", "6"}, "abcdef"},
+	}
+	for _, tc := range testCases {
+		// Set, to be restored afterwards, but unset if empty, as an empty
+		// variable still counts as setting the flag
+		t.Setenv("TERMINAL_TO_HTML_WIDTH", tc.env)
+		if tc.env == "" {
+			os.Unsetenv("TERMINAL_TO_HTML_WIDTH")
+		}
+		if stdout, stderr, code := run(t, "abcdef", tc.args...); code != 0 || stdout != tc.want {
+			t.Errorf("%s: %q = %q, %q, exit %d, want %q", tc.name, tc.args, stdout, stderr, code, tc.want)
+		}
+	}
+
+	ini := writeFile(t, "server.ini", "width=3\n")
+	if _, stderr, code := run(t, "", "--config", ini); code != 1 || stderr != `could not read config: unknown config file type "`+ini+`", expected .yaml, .yml or .toml`+"\n" {
+		t.Errorf("--config %s = %q, exit %d, want an error", ini, stderr, code)
+	}
+}
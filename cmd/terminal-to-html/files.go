@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// extensions are the file extensions of the output formats, for --output-dir.
+var extensions = map[string]string{
+	"html":  ".html",
+	"plain": ".txt",
+	"json":  ".json",
+	"ansi":  ".ansi",
+	"lines": ".json",
+}
+
+var nonAnchorRegexp = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// fileName returns the base name of the file or URL, without any
+// compression extension.
+func fileName(file string) string {
+	name := file
+	if file == "-" {
+		name = "stdin"
+	} else if u, err := url.Parse(file); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		name = path.Base(u.Path)
+	} else {
+		name = filepath.Base(file)
+	}
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ".zst")
+	if name == "" || name == "." || name == "/" {
+		name = "output"
+	}
+	return name
+}
+
+// uniqueNames returns a name for each file, based on fileName, and numbered
+// if there would be duplicates.
+func uniqueNames(files []string, transform func(string) string) []string {
+	names := make([]string, len(files))
+	seen := map[string]bool{}
+	for i, file := range files {
+		base := transform(fileName(file))
+		name := base
+		for n := 2; seen[name]; n++ {
+			name = fmt.Sprintf("%s-%d", base, n)
+		}
+		seen[name] = true
+		names[i] = name
+	}
+	return names
+}
+
+// separate renders each file on its own, one after the other, under a
+// header: a heading with an anchor in HTML, a JSON field for json and lines,
+// or ==> file <== otherwise, like head and tail.
+func separate(files []string, format string) error {
+	anchors := uniqueNames(files, func(name string) string {
+		return "file-" + strings.Trim(nonAnchorRegexp.ReplaceAllString(name, "-"), "-")
+	})
+
+	var buf bytes.Buffer
+	for i, file := range files {
+		input, err := readFile(file)
+		if err != nil {
+			return fmt.Errorf("could not read input: %w", err)
+		}
+		output := render(input, format, func(offset int) (string, int) { return file, offset })
+
+		switch format {
+		case "html":
+			// The header is a block, so separates the files without newlines
+			fmt.Fprintf(&buf, `<a class="term-file" id="%s" href="#%s">%s</a>`, anchors[i], anchors[i], html.EscapeString(file))
+			buf.Write(output)
+		case "json":
+			// One object per line, with the file added to the metadata
+			name, _ := json.Marshal(file)
+			fmt.Fprintf(&buf, `{"file":%s,`, name)
+			buf.Write(output[1:])
+			buf.WriteString("\n")
+		case "lines":
+			// One object per line, with the file and its array of lines
+			name, _ := json.Marshal(file)
+			fmt.Fprintf(&buf, `{"file":%s,"lines":`, name)
+			buf.Write(output)
+			buf.WriteString("}\n")
+		default:
+			if i > 0 {
+				buf.WriteString("\n\n")
+			}
+			fmt.Fprintf(&buf, "==> %s <==\n", file)
+			buf.Write(output)
+		}
+	}
+
+	output := buf.Bytes()
+	if format == "html" {
+		var err error
+		if output, err = wrapPreview(output); err != nil {
+			return fmt.Errorf("could not wrap preview: %w", err)
+		}
+	}
+	if _, err := os.Stdout.Write(output); err != nil {
+		return fmt.Errorf("could not write output: %w", err)
+	}
+	return nil
+}
+
+// outputDir renders each file into a file of the same name in dir, with the
+// extension of the format.
+func outputDir(files []string, format, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("could not create output directory: %w", err)
+	}
+	names := uniqueNames(files, func(name string) string {
+		return strings.TrimSuffix(name, filepath.Ext(name)) + extensions[format]
+	})
+	for i, file := range files {
+		input, err := readFile(file)
+		if err != nil {
+			return fmt.Errorf("could not read input: %w", err)
+		}
+		output := render(input, format, func(offset int) (string, int) { return file, offset })
+		if format == "html" {
+			if output, err = wrapPreview(output); err != nil {
+				return fmt.Errorf("could not wrap preview: %w", err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(dir, names[i]), output, 0o644); err != nil {
+			return fmt.Errorf("could not write output: %w", err)
+		}
+	}
+	return nil
+}
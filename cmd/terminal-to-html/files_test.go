@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSeparate(t *testing.T) {
+	one := writeFile(t, "one.log", "\x1b[31mfirst\x1b[0m")
+	two := writeFile(t, "two.log", "second")
+
+	stdout, stderr, code := run(t, "", "--separate", "--format", "plain", one, two)
+	if want := "==> " + one + " <==\nfirst\n\n==> " + two + " <==\nsecond"; code != 0 || stdout != want {
+		t.Errorf("--separate --format plain = %q, %q, exit %d, want %q", stdout, stderr, code, want)
+	}
+
+	// The same name twice gets numbered anchors
+	stdout, stderr, code = run(t, "", "--separate", one, one)
+	want := `<a class="term-file" id="file-one-log" href="#file-one-log">` + one + `</a><span class="term-fg31">first</span>` +
+		`<a class="term-file" id="file-one-log-2" href="#file-one-log-2">` + one + `</a><span class="term-fg31">first</span>`
+	if code != 0 || stdout != want {
+		t.Errorf("--separate = %q, %q, exit %d, want %q", stdout, stderr, code, want)
+	}
+
+	// Each file's lines are a JSON object of their own
+	stdout, stderr, code = run(t, "", "--separate", "--format", "lines", one, two)
+	dec := json.NewDecoder(strings.NewReader(stdout))
+	for _, want := range []string{one, two} {
+		var got struct {
+			File  string            `json:"file"`
+			Lines []json.RawMessage `json:"lines"`
+		}
+		if err := dec.Decode(&got); err != nil || got.File != want || len(got.Lines) != 1 {
+			t.Errorf("--separate --format lines = %q, %q, exit %d, want an object with the line of %s: %v", stdout, stderr, code, want, err)
+			break
+		}
+	}
+}
+
+func TestOutputDir(t *testing.T) {
+	one := writeFile(t, "one.log", "\x1b[31mfirst\x1b[0m")
+	// Compression extensions are left out of the names of the output
+	two := writeFile(t, "two.log.gz", "")
+	dir := filepath.Join(t.TempDir(), "out")
+
+	if stdout, stderr, code := run(t, "", "--output-dir", dir, "--format", "plain", one, two); code != 0 || stdout != "" {
+		t.Fatalf("--output-dir = %q, %q, exit %d, want nothing written to stdout", stdout, stderr, code)
+	}
+	for name, want := range map[string]string{"one.txt": "first", "two.txt": ""} {
+		if got, err := os.ReadFile(filepath.Join(dir, name)); err != nil || string(got) != want {
+			t.Errorf("--output-dir wrote %s = %q, %v, want %q", name, got, err, want)
+		}
+	}
+
+	if _, stderr, code := run(t, "", "--output-dir", dir, "--format", "lines", one); code != 0 {
+		t.Fatalf("--output-dir --format lines = %q, exit %d", stderr, code)
+	}
+	if got, err := os.ReadFile(filepath.Join(dir, "one.json")); err != nil || !json.Valid(got) {
+		t.Errorf("--output-dir --format lines wrote one.json = %q, %v, want JSON", got, err)
+	}
+}
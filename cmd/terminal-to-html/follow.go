@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/buildkite/terminal-to-html/v3"
+)
+
+// followInterval is how often a followed file is checked for more input.
+const followInterval = 250 * time.Millisecond
+
+// follow renders the file to stdout, and then anything appended to it, like
+// tail -f, until interrupted. The output is the same as rendering the whole
+// file at the end, except that the last lines are only written once they're
+// far enough above the cursor that they can no longer change, or on exit.
+func follow(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var head, tail []byte
+	if PreviewMode {
+		if head, tail, err = previewParts(); err != nil {
+			return err
+		}
+	}
+	if _, err := os.Stdout.Write(head); err != nil {
+		return err
+	}
+
+	// The file is read to its end repeatedly, so its encoding is detected
+	// as it's parsed rather than with terminal.Transcode
+	opts := RenderOptions
+	opts.DetectInputEncoding = DetectEncoding
+	streamer := terminal.NewStreamer(os.Stdout, opts)
+	for {
+		if _, err := io.Copy(streamer, f); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			if err := streamer.Close(); err != nil {
+				return err
+			}
+			_, err := os.Stdout.Write(tail)
+			return err
+		case <-time.After(followInterval):
+		}
+
+		// Start again from the beginning if the file has been truncated
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		offset, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		if info.Size() < offset {
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+		}
+	}
+}
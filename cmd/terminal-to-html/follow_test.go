@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestFollow(t *testing.T) {
+	file := writeFile(t, "build.log", "\x1b[31mstarted\x1b[0m\n")
+	cmd := exec.Command(os.Args[0], "--follow", file)
+	cmd.Env = append(os.Environ(), "RUN_TERMINAL_TO_HTML=1")
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Appended once the file has been read to its end
+	time.Sleep(2 * followInterval)
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("finished\n")
+	f.Close()
+	time.Sleep(2 * followInterval)
+
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("--follow exited with %v", err)
+	}
+	// The same as converting the whole file at the end
+	want, _, _ := run(t, "", file)
+	if got := stdout.String(); got != want || !bytes.Contains(stdout.Bytes(), []byte("finished")) {
+		t.Errorf("--follow = %q, want %q", got, want)
+	}
+
+	if _, stderr, code := run(t, "", "--follow"); code != 1 || stderr != "--follow needs exactly one file\n" {
+		t.Errorf("--follow without a file = %q, exit %d, want an error", stderr, code)
+	}
+}
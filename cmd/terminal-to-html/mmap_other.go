@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// mapFile reports false, as files are only mapped into memory on Unix.
+func mapFile(f *os.File) ([]byte, bool) {
+	return nil, false
+}
@@ -0,0 +1,27 @@
+//go:build unix
+
+package main
+
+import (
+	"math"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// mapFile maps the contents of f into memory, read only, so that a large
+// file can be rendered without first reading all of it onto the heap. It
+// reports false if f can't be mapped, e.g. if it's a pipe or empty. The
+// mapping is left for the rest of the process, as the CLI exits once it has
+// rendered its input.
+func mapFile(f *os.File) ([]byte, bool) {
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() || info.Size() == 0 || info.Size() > math.MaxInt {
+		return nil, false
+	}
+	data, err := unix.Mmap(int(f.Fd()), 0, int(info.Size()), unix.PROT_READ, unix.MAP_SHARED)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// startProfiling starts any CPU profile and execution trace, written to the
+// named files if not empty, and returns a function that stops them and
+// writes any heap profile. The function is to be called even if starting
+// fails part way, to stop what was started.
+func startProfiling(cpuProfile, memProfile, traceFile string) (stop func() error, err error) {
+	var stops []func() error
+	stop = func() error {
+		var errs []error
+		for i := len(stops) - 1; i >= 0; i-- {
+			errs = append(errs, stops[i]())
+		}
+		return errors.Join(errs...)
+	}
+
+	if cpuProfile != "" {
+		f, err := os.Create(cpuProfile)
+		if err != nil {
+			return stop, err
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return stop, err
+		}
+		stops = append(stops, func() error {
+			pprof.StopCPUProfile()
+			if err := f.Close(); err != nil {
+				return fmt.Errorf("could not write CPU profile: %w", err)
+			}
+			return nil
+		})
+	}
+
+	if traceFile != "" {
+		f, err := os.Create(traceFile)
+		if err != nil {
+			return stop, err
+		}
+		if err := trace.Start(f); err != nil {
+			f.Close()
+			return stop, err
+		}
+		stops = append(stops, func() error {
+			trace.Stop()
+			if err := f.Close(); err != nil {
+				return fmt.Errorf("could not write trace: %w", err)
+			}
+			return nil
+		})
+	}
+
+	if memProfile != "" {
+		// Create the file now, so that a bad path is reported before doing
+		// any work
+		f, err := os.Create(memProfile)
+		if err != nil {
+			return stop, err
+		}
+		stops = append(stops, func() error {
+			// Get up to date statistics
+			runtime.GC()
+			if err := errors.Join(pprof.WriteHeapProfile(f), f.Close()); err != nil {
+				return fmt.Errorf("could not write memory profile: %w", err)
+			}
+			return nil
+		})
+	}
+
+	return stop, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProfiling(t *testing.T) {
+	dir := t.TempDir()
+	cpu, mem, trace := filepath.Join(dir, "cpu.pprof"), filepath.Join(dir, "mem.pprof"), filepath.Join(dir, "trace.out")
+	stdout, stderr, code := run(t, "\x1b[31mred", "--cpuprofile", cpu, "--memprofile", mem, "--trace", trace)
+	if code != 0 || stdout != `<span class="term-fg31">red</span>` {
+		t.Fatalf("profiling = %q, %q, exit %d, want the input converted", stdout, stderr, code)
+	}
+	// Profiles are gzipped, and traces start with the Go version
+	for file, prefix := range map[string]string{cpu: "\x1f\x8b", mem: "\x1f\x8b", trace: "go 1."} {
+		data, err := os.ReadFile(file)
+		if err != nil || !bytes.Contains(data[:min(len(data), 16)], []byte(prefix)) {
+			t.Errorf("%s = %q, %v, want it to start with %q", filepath.Base(file), data[:min(len(data), 16)], err, prefix)
+		}
+	}
+
+	// The profiles are written even if the conversion fails
+	cpu, trace = filepath.Join(dir, "failed-cpu.pprof"), filepath.Join(dir, "failed-trace.out")
+	if _, stderr, code := run(t, "", "--cpuprofile", cpu, "--trace", trace, filepath.Join(dir, "missing.log")); code != 1 || !strings.Contains(stderr, "could not read input") {
+		t.Errorf("profiling a missing file = %q, exit %d, want an error", stderr, code)
+	}
+	for file, prefix := range map[string]string{cpu: "\x1f\x8b", trace: "go 1."} {
+		data, err := os.ReadFile(file)
+		if err != nil || !bytes.Contains(data[:min(len(data), 16)], []byte(prefix)) {
+			t.Errorf("after failing, %s = %q, %v, want it to start with %q", filepath.Base(file), data[:min(len(data), 16)], err, prefix)
+		}
+	}
+
+	if _, stderr, code := run(t, "", "--cpuprofile", filepath.Join(dir, "missing", "cpu.pprof")); code != 1 || !strings.Contains(stderr, "could not start profiling") {
+		t.Errorf("--cpuprofile in a missing directory = %q, exit %d, want an error", stderr, code)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/buildkite/terminal-to-html/v3"
+)
+
+// Stats collects statistics about the conversion for --stats, or is nil.
+var Stats *renderStats
+
+type renderStats struct {
+	start      time.Time
+	inputBytes int
+	lines      int
+	sequences  terminal.SequenceStats
+}
+
+func newRenderStats() *renderStats {
+	return &renderStats{
+		start: time.Now(),
+		sequences: terminal.SequenceStats{
+			CSI: map[string]int{},
+			ESC: map[string]int{},
+			OSC: map[string]int{},
+			APC: map[string]int{},
+		},
+	}
+}
+
+// record is a Serializer that adds the screen's statistics to the totals,
+// rather than rendering anything.
+func (r *renderStats) record(s *terminal.Screen) []byte {
+	r.lines += s.LineCount()
+	sequences := s.SequenceStats()
+	for _, counts := range []struct{ total, add map[string]int }{
+		{r.sequences.CSI, sequences.CSI},
+		{r.sequences.ESC, sequences.ESC},
+		{r.sequences.OSC, sequences.OSC},
+		{r.sequences.APC, sequences.APC},
+	} {
+		for key, n := range counts.add {
+			counts.total[key] += n
+		}
+	}
+	r.sequences.Unknown += sequences.Unknown
+	return nil
+}
+
+// write writes the statistics, e.g.
+//
+//	input:     20480 bytes
+//	lines:     312
+//	CSI:       1024 (m: 1000, K: 24)
+//	...
+func (r *renderStats) write(w io.Writer) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	fmt.Fprintf(w, "input:     %d bytes\n", r.inputBytes)
+	fmt.Fprintf(w, "lines:     %d\n", r.lines)
+	fmt.Fprintf(w, "CSI:       %s\n", formatCounts(r.sequences.CSI))
+	fmt.Fprintf(w, "ESC:       %s\n", formatCounts(r.sequences.ESC))
+	fmt.Fprintf(w, "OSC:       %s\n", formatCounts(r.sequences.OSC))
+	fmt.Fprintf(w, "APC:       %s\n", formatCounts(r.sequences.APC))
+	fmt.Fprintf(w, "unknown:   %d sequences\n", r.sequences.Unknown)
+	fmt.Fprintf(w, "images:    %d\n", r.sequences.OSC["1337"]+r.sequences.OSC["1338"])
+	fmt.Fprintf(w, "elapsed:   %s\n", time.Since(r.start).Round(time.Microsecond))
+	// Memory obtained from the OS includes the runtime's own, and what the
+	// garbage collector has yet to reuse, so it's more than the live heap
+	fmt.Fprintf(w, "memory:    %.1f MiB obtained from the OS, %.1f MiB allocated in total\n", float64(mem.Sys)/(1<<20), float64(mem.TotalAlloc)/(1<<20))
+}
+
+// formatCounts formats the counts as the total, followed by the count of
+// each key, most common first.
+func formatCounts(counts map[string]int) string {
+	keys := make([]string, 0, len(counts))
+	total := 0
+	for key, n := range counts {
+		keys = append(keys, key)
+		total += n
+	}
+	if total == 0 {
+		return "0"
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%s: %d", key, counts[key])
+	}
+	return fmt.Sprintf("%d (%s)", total, strings.Join(parts, ", "))
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	one := writeFile(t, "one.log", "\x1b[31mred\x1b[0m\n\x1b[2K\x1b]0;title\a")
+	two := writeFile(t, "two.log", "\x1b[1mbold\x1b[5x")
+
+	// Totalled across the files, whether concatenated or separate
+	for _, args := range [][]string{{one, two}, {"--separate", one, two}} {
+		stdout, stderr, code := run(t, "", append([]string{"--stats"}, args...)...)
+		if code != 0 || stdout == "" {
+			t.Errorf("--stats %q = exit %d, want the output converted", args, code)
+		}
+		for _, want := range []string{
+			"input:     39 bytes\n",
+			"CSI:       4 (m: 3, K: 1)\n",
+			"OSC:       1 (0: 1)\n",
+			"unknown:   1 sequences\n",
+			"elapsed:   ",
+		} {
+			if !strings.Contains(stderr, want) {
+				t.Errorf("--stats %q = %q, want %q in it", args, stderr, want)
+			}
+		}
+	}
+
+	if _, stderr, code := run(t, "", "--stats", "--follow", one); code != 1 || stderr != "--stats is only supported when converting files or stdin\n" {
+		t.Errorf("--stats --follow = %q, exit %d, want an error", stderr, code)
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/buildkite/terminal-to-html/v3"
+)
+
+// TailViewer is the page served at /tail/{name}, which shows the log as it's
+// appended to, using the events from /tail/{name}/events.
+var TailViewer = `<div id="lines"></div><div id="tail"></div>
+<script>
+	const lines = document.getElementById("lines");
+	const tail = document.getElementById("tail");
+	const events = new EventSource(location.pathname.replace(/\/$/, "") + "/events");
+	events.addEventListener("lines", (e) => {
+		const atBottom = window.innerHeight + window.scrollY >= document.body.scrollHeight - 1;
+		lines.insertAdjacentHTML("beforeend", e.data);
+		if (atBottom) window.scrollTo(0, document.body.scrollHeight);
+	});
+	events.addEventListener("tail", (e) => {
+		const atBottom = window.innerHeight + window.scrollY >= document.body.scrollHeight - 1;
+		tail.innerHTML = e.data;
+		if (atBottom) window.scrollTo(0, document.body.scrollHeight);
+	});
+</script>`
+
+// tailHistory is the most lines of each log kept for browsers that start
+// watching it later, so that a long upload can't use up the memory.
+var tailHistory = 10000
+
+// tailLogs are the logs uploaded to /tail/{name}, each kept while it's being
+// uploaded or watched, so that uploads to one can't be seen by those
+// watching another.
+type tailLogs struct {
+	mu   sync.Mutex
+	logs map[string]*tailLog
+}
+
+func newTailLogs() *tailLogs {
+	return &tailLogs{logs: map[string]*tailLog{}}
+}
+
+// serveHTTP serves /tail/{name} with the log's serveHTTP, and
+// /tail/{name}/events with its serveEvents.
+func (t *tailLogs) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	name, events := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/tail/"), "/events")
+	name = strings.TrimSuffix(name, "/")
+	if name == "" || strings.Contains(name, "/") {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "Name the log to upload or watch, e.g. /tail/build-123.")
+		return
+	}
+	l := t.get(name)
+	defer t.release(name, l)
+	if events {
+		l.serveEvents(w, r)
+	} else {
+		l.serveHTTP(w, r)
+	}
+}
+
+// get returns the named log, starting it if there isn't one.
+func (t *tailLogs) get(name string) *tailLog {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	l, ok := t.logs[name]
+	if !ok {
+		l = newTailLog()
+		t.logs[name] = l
+	}
+	l.mu.Lock()
+	l.users++
+	l.mu.Unlock()
+	return l
+}
+
+// release drops the named log once nothing is uploading or watching it.
+func (t *tailLogs) release(name string, l *tailLog) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.users--
+	if l.users == 0 && t.logs[name] == l {
+		delete(t.logs, name)
+	}
+}
+
+// A tailLog is a log that's appended to by an upload to /tail/{name}, and
+// rendered live to browsers watching /tail/{name}/events. Lines are sent as
+// "lines" events once they can no longer change, and the rest of the log as a
+// "tail" event, which replaces the last one, whenever it changes.
+type tailLog struct {
+	mu       sync.Mutex
+	streamer *terminal.Streamer
+
+	// HTML of the lines that can no longer change, in the chunks the
+	// streamer wrote it in, of which the first dropped have been dropped to
+	// keep no more than tailHistory lines. lines counts the lines kept.
+	chunks  [][]byte
+	dropped int
+	lines   int
+
+	// HTML of the rest of the log
+	tail []byte
+
+	// Whether the log is being uploaded, so that only one upload writes to
+	// it at a time
+	uploading bool
+
+	// The number of requests uploading or watching the log
+	users int
+
+	// Subscribers are notified when the log changes, and read the HTML
+	// they haven't sent yet themselves
+	subscribers map[chan struct{}]struct{}
+}
+
+func newTailLog() *tailLog {
+	l := &tailLog{subscribers: map[chan struct{}]struct{}{}}
+	l.streamer = terminal.NewStreamer(tailWriter{l}, RenderOptions)
+	return l
+}
+
+// tailWriter keeps the HTML written by a tailLog's streamer, which is only
+// written to with the log's lock held.
+type tailWriter struct {
+	l *tailLog
+}
+
+func (w tailWriter) Write(p []byte) (int, error) {
+	l := w.l
+	l.chunks = append(l.chunks, bytes.Clone(p))
+	l.lines += bytes.Count(p, []byte("\n")) + 1
+	for l.lines > tailHistory && len(l.chunks) > 1 {
+		l.lines -= bytes.Count(l.chunks[0], []byte("\n")) + 1
+		l.chunks[0] = nil
+		l.chunks = l.chunks[1:]
+		l.dropped++
+	}
+	return len(p), nil
+}
+
+// Write appends to the log, and notifies the subscribers.
+func (l *tailLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n, err := l.streamer.Write(p)
+	l.tail = l.streamer.Tail()
+	for ch := range l.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+			// Already notified
+		}
+	}
+	return n, err
+}
+
+// serveHTTP appends an upload to the log, and serves the viewer page
+// otherwise.
+func (l *tailLog) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost, http.MethodPut:
+		l.mu.Lock()
+		busy := l.uploading
+		l.uploading = true
+		l.mu.Unlock()
+		if busy {
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprint(w, "This log is already being uploaded.")
+			return
+		}
+		defer func() {
+			l.mu.Lock()
+			l.uploading = false
+			l.mu.Unlock()
+		}()
+
+		body, err := terminal.Decompress(r.Body)
+		if err != nil {
+			log.Printf("could not read from HTTP stream: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "Error reading request.")
+			return
+		}
+		defer body.Close()
+		var input io.Reader = body
+		if DetectEncoding {
+			input = terminal.Transcode(body)
+		}
+		if err := copyInput(l, input, r.URL.Path); err != nil {
+			log.Printf("could not read from HTTP stream: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, "Error reading request.")
+		}
+	case http.MethodGet, http.MethodHead:
+		head, tail, err := previewParts()
+		if err != nil {
+			log.Printf("error wrapping preview: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, "Error creating preview.")
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(head)
+		io.WriteString(w, TailViewer)
+		w.Write(tail)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST, PUT")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// serveEvents sends the log as Server-Sent Events, starting with the lines
+// kept so far, until the client goes away.
+func (l *tailLog) serveEvents(w http.ResponseWriter, r *http.Request) {
+	ch := make(chan struct{}, 1)
+	l.mu.Lock()
+	l.subscribers[ch] = struct{}{}
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		delete(l.subscribers, ch)
+		l.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	rc := http.NewResponseController(w)
+
+	// The number of chunks sent, counting those dropped before they could be
+	sent := 0
+	var sentTail []byte
+	for {
+		l.mu.Lock()
+		sent = max(sent, l.dropped)
+		lines := bytes.Join(l.chunks[sent-l.dropped:], nil)
+		sent = l.dropped + len(l.chunks)
+		tail := l.tail
+		l.mu.Unlock()
+
+		var buf bytes.Buffer
+		if len(lines) > 0 {
+			writeEvent(&buf, "lines", lines)
+		}
+		if !bytes.Equal(tail, sentTail) || buf.Len() > 0 {
+			writeEvent(&buf, "tail", tail)
+			sentTail = tail
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		select {
+		case <-ch:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeEvent writes a Server-Sent Event, with each line of the data in a
+// data field.
+func writeEvent(w io.Writer, event string, data []byte) {
+	fmt.Fprintf(w, "event: %s\n", event)
+	for _, line := range strings.Split(string(data), "\n") {
+		fmt.Fprintf(w, "data: %s\n", line)
+	}
+	io.WriteString(w, "\n")
+}
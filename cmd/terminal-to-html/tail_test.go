@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// readEvent reads the next Server-Sent Event, returning its name and data.
+func readEvent(t *testing.T, r *bufio.Reader) (string, string) {
+	t.Helper()
+	var event string
+	var data []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			return event, strings.Join(data, "\n")
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = append(data, strings.TrimPrefix(line, "data: "))
+		}
+	}
+}
+
+func TestTailLogs(t *testing.T) {
+	defer func(maxBytes int64) { MaxBytes = maxBytes }(MaxBytes)
+	MaxBytes = 9
+
+	srv := httptest.NewServer(http.HandlerFunc(newTailLogs().serveHTTP))
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/tail/a/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	for _, upload := range []struct{ name, body string }{{"b", "secret\n"}, {"a", "hello\nthere\n"}} {
+		resp, err := http.Post(srv.URL+"/tail/"+upload.name, "text/plain", strings.NewReader(upload.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	// Only the upload to a, up to --max-bytes, is seen by those watching a
+	if event, data := readEvent(t, bufio.NewReader(resp.Body)); event != "tail" || data != "hello" {
+		t.Errorf("event = %q, %q, want the tail of a", event, data)
+	}
+
+	if resp, err := http.Get(srv.URL + "/tail/"); err != nil || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /tail/ = %v, %v, want 404", resp, err)
+	}
+}
+
+func TestTailLogHistory(t *testing.T) {
+	defer func(history int) { tailHistory = history }(tailHistory)
+	tailHistory = 10
+
+	l := newTailLog()
+	for i := 0; i < 300; i++ {
+		fmt.Fprintf(l, "line %d\n", i)
+	}
+	if l.lines > tailHistory || l.dropped == 0 {
+		t.Errorf("kept %d lines, dropping %d chunks, want no more than %d", l.lines, l.dropped, tailHistory)
+	}
+	if last := string(l.chunks[len(l.chunks)-1]); !strings.Contains(last, "line 199") {
+		t.Errorf("last chunk = %q, want the last line that can't change", last)
+	}
+}
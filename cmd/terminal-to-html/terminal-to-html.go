@@ -1,27 +1,49 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
-	"flag"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/buildkite/terminal-to-html/v3"
 	"github.com/buildkite/terminal-to-html/v3/internal/assets"
 	"github.com/urfave/cli/v2"
+	"github.com/urfave/cli/v2/altsrc"
 )
 
 var AppHelpTemplate = `{{.Name}} - {{.Usage}}
 
 STDIN/STDOUT USAGE:
   cat input.raw | {{.Name}} [arguments...] > out.html
+  {{.Name}} [arguments...] input.raw [more.raw...] > out.html
+  {{.Name}} --follow build.log > out.html
+  {{.Name}} --format plain input.raw > out.txt
+  {{.Name}} https://example.com/input.raw > out.html
+  {{.Name}} --separate one.raw two.raw > out.html
+  {{.Name}} --output-dir out/ one.raw two.raw
+
+STYLESHEET USAGE:
+  {{.Name}} --css > terminal.css
+  {{.Name}} --css --theme light > terminal.css
 
 WEBSERVICE USAGE:
   {{.Name}} --http :6060 &
   curl --data-binary "@input.raw" http://localhost:6060/terminal > out.html
+  TERMINAL_TO_HTML_HTTP=:6060 {{.Name}} --config server.yaml
+
+LIVE TAIL USAGE:
+  {{.Name}} --http :6060 &
+  tail -f build.log | curl -T - http://localhost:6060/tail/build-123
+  open http://localhost:6060/tail/build-123
 
 OPTIONS:
   {{range .Flags}}{{.}}
@@ -30,18 +52,26 @@ OPTIONS:
 
 var PreviewMode = false
 
-var PreviewTemplate = `
-	<!DOCTYPE html>
-	<html>
-		<head>
-			<meta charset="UTF-8">
-			<title>terminal-to-html Preview</title>
-			<style>STYLESHEET</style>
-		</head>
-		<body>
-			<div class="term-container">CONTENT</div>
-		</body>
-	</html>
+// Theme is the theme of the stylesheet, chosen with --theme.
+var Theme = assets.Themes[0]
+
+var PreviewTemplate = `<!DOCTYPE html>
+<html>
+	<head>
+		<meta charset="UTF-8">
+		<meta name="viewport" content="width=device-width, initial-scale=1">
+		<meta name="color-scheme" content="COLOR_SCHEME">
+		<title>terminal-to-html Preview</title>
+		<style>STYLESHEET</style>
+		<style>
+			html, body { margin: 0; min-height: 100%; background: BACKGROUND; }
+			body > .term-container { border-radius: 0; min-height: 100vh; box-sizing: border-box; }
+		</style>
+	</head>
+	<body>
+		<div class="term-container">CONTENT</div>
+	</body>
+</html>
 `
 
 func check(m string, e error) {
@@ -50,59 +80,361 @@ func check(m string, e error) {
 	}
 }
 
+// wrapPreview wraps the HTML in a complete page, styled with the stylesheet,
+// if in preview mode.
 func wrapPreview(s []byte) ([]byte, error) {
+	if !PreviewMode {
+		return s, nil
+	}
+	head, tail, err := previewParts()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.Write(head)
+	buf.Write(s)
+	buf.Write(tail)
+	return buf.Bytes(), nil
+}
+
+// previewParts returns the parts of the preview page that go before and after
+// the HTML.
+func previewParts() (head, tail []byte, err error) {
+	styleSheet, err := assets.ThemeCSS(Theme)
+	if err != nil {
+		return nil, nil, err
+	}
+	template := strings.NewReplacer("COLOR_SCHEME", Theme.ColorScheme, "BACKGROUND", Theme.Background).Replace(PreviewTemplate)
+
+	// Split the template rather than replacing in turn, in case the content
+	// or stylesheet contains a placeholder
+	before, rest, _ := strings.Cut(template, "STYLESHEET")
+	middle, after, _ := strings.Cut(rest, "CONTENT")
+
+	head = append([]byte(before), styleSheet...)
+	head = append(head, middle...)
+	return head, []byte(after), nil
+}
+
+// flushWriter flushes each write to the client, so that HTML is sent as soon
+// as it's rendered.
+type flushWriter struct {
+	w  io.Writer
+	rc *http.ResponseController
+}
+
+func (f flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, f.rc.Flush()
+}
+
+func webservice(listen string) error {
+	http.HandleFunc("/terminal", serveTerminal)
+	http.HandleFunc("/tail/", newTailLogs().serveHTTP)
+
+	log.Printf("Listening on %s", listen)
+	return http.ListenAndServe(listen, nil)
+}
+
+// serveTerminal renders the body of a request to /terminal.
+func serveTerminal(w http.ResponseWriter, r *http.Request) {
+	var head, tail []byte
+	var err error
 	if PreviewMode {
-		s = bytes.Replace([]byte(PreviewTemplate), []byte("CONTENT"), s, 1)
-		styleSheet, err := assets.TerminalCSS()
-		if err != nil {
-			return nil, err
-		}
-		s = bytes.Replace(s, []byte("STYLESHEET"), styleSheet, 1)
+		head, tail, err = previewParts()
+	}
+	if err != nil {
+		log.Printf("error wrapping preview: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, "Error creating preview.")
+		return
+	}
+
+	// Render the body as it's read, so that large uploads start producing
+	// output straight away without being held in memory. Once output has
+	// started, errors can only be logged.
+	rc := http.NewResponseController(w)
+	if err := rc.EnableFullDuplex(); err != nil {
+		log.Printf("could not stream response: %v", err)
+	}
+	out := flushWriter{w: w, rc: rc}
+	streamer := terminal.NewStreamer(out, RenderOptions)
+	if _, err := out.Write(head); err != nil {
+		log.Printf("error writing response: %v", err)
+		return
+	}
+	body, err := terminal.Decompress(r.Body)
+	if err != nil {
+		log.Printf("could not read from HTTP stream: %v", err)
+		return
+	}
+	defer body.Close()
+	var input io.Reader = body
+	if DetectEncoding {
+		input = terminal.Transcode(body)
+	}
+	if err := copyInput(streamer, input, r.URL.Path); err != nil {
+		log.Printf("could not read from HTTP stream: %v", err)
+		return
+	}
+	if err := streamer.Close(); err != nil {
+		log.Printf("error writing response: %v", err)
+		return
+	}
+	if _, err := out.Write(tail); err != nil {
+		log.Printf("error writing response: %v", err)
 	}
-	return s, nil
 }
 
-func webservice(listen string) {
-	http.HandleFunc("/terminal", func(w http.ResponseWriter, r *http.Request) {
-		input, err := io.ReadAll(r.Body)
+// readInput reads the named files one after the other, like cat, or stdin if
+// there are none. "-" also reads stdin. Compressed files are decompressed.
+func readInput(files []string) ([]byte, []inputFile, error) {
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	var input []byte
+	parts := make([]inputFile, len(files))
+	for i, file := range files {
+		data, err := readFile(file)
 		if err != nil {
-			log.Printf("could not read from HTTP stream: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			fmt.Fprint(w, "Error reading request.")
-			return
+			return nil, nil, err
+		}
+		parts[i] = inputFile{name: file, start: len(input)}
+		if i == 0 {
+			// Not copied, as it may be a large file mapped into memory.
+			// Appending to it copies it if there are more files.
+			input = data
+		} else {
+			input = append(input, data...)
 		}
+	}
+	return input, parts, nil
+}
+
+// An inputFile is one of the files concatenated into the input, starting
+// start bytes into it.
+type inputFile struct {
+	name  string
+	start int
+}
+
+// locateIn returns a function that finds which of the files a byte offset
+// into the input they were concatenated into is in, and the offset into it.
+func locateIn(parts []inputFile) func(offset int) (string, int) {
+	return func(offset int) (string, int) {
+		i := sort.Search(len(parts), func(i int) bool { return parts[i].start > offset }) - 1
+		return parts[i].name, offset - parts[i].start
+	}
+}
 
-		respBody, err := wrapPreview(terminal.Render(input))
+// readFile reads the named file, stdin if it's "-", or the content at an
+// http(s) URL, decompressing it if it's gzip or zstd compressed, and
+// transcoding it if DetectEncoding is set.
+func readFile(file string) ([]byte, error) {
+	var f io.Reader = os.Stdin
+	if strings.HasPrefix(file, "https://") || strings.HasPrefix(file, "http://") {
+		ctx, cancel := context.WithTimeout(context.Background(), FetchTimeout)
+		defer cancel()
+		body, err := fetch(ctx, file)
 		if err != nil {
-			log.Printf("error wrapping preview: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			fmt.Fprint(w, "Error creating preview.")
-			return
+			return nil, err
 		}
-
-		_, err = w.Write(respBody)
+		defer body.Close()
+		f = body
+	} else if file != "-" {
+		file, err := os.Open(file)
 		if err != nil {
-			log.Printf("error writing response: %v", err)
+			return nil, err
+		}
+		defer file.Close()
+		if data, ok := mapFile(file); ok && !terminal.IsCompressed(data) && !DetectEncoding {
+			return limitBytes(file.Name(), data), nil
 		}
-	})
+		f = file
+	}
+	r, err := terminal.Decompress(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	defer r.Close()
+	var input io.Reader = r
+	if DetectEncoding {
+		input = terminal.Transcode(r)
+	}
+	data, err := io.ReadAll(limitInput(input))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return limitBytes(file, data), nil
+}
 
-	log.Printf("Listening on %s", listen)
-	log.Fatal(http.ListenAndServe(listen, nil))
+// limitBytes truncates the data read from file to MaxBytes.
+func limitBytes(file string, data []byte) []byte {
+	if MaxBytes > 0 && int64(len(data)) > MaxBytes {
+		log.Printf("%s: ignoring input after the first %d bytes (--max-bytes)", file, MaxBytes)
+		data = data[:MaxBytes]
+	}
+	return data
 }
 
-func stdin() {
-	var input []byte
-	var err error
-	if len(flag.Arg(0)) > 0 {
-		input, err = os.ReadFile(flag.Arg(0))
-		check(fmt.Sprintf("could not read %s", flag.Arg(0)), err)
-	} else {
-		input, err = io.ReadAll(os.Stdin)
-		check("could not read stdin", err)
+// limitInput limits r to MaxBytes, plus one to tell if there was more.
+func limitInput(r io.Reader) io.Reader {
+	if MaxBytes <= 0 {
+		return r
+	}
+	return io.LimitReader(r, MaxBytes+1)
+}
+
+// copyInput copies an upload to w, ignoring what's after the first MaxBytes
+// bytes, as limitBytes does for files.
+func copyInput(w io.Writer, r io.Reader, name string) error {
+	if MaxBytes <= 0 {
+		_, err := io.Copy(w, r)
+		return err
+	}
+	if _, err := io.Copy(w, io.LimitReader(r, MaxBytes)); err != nil {
+		return err
 	}
-	output, err := wrapPreview(terminal.Render(input))
-	check("could not wrap preview", err)
-	fmt.Printf("%s", output)
+	if n, _ := io.ReadFull(r, make([]byte, 1)); n > 0 {
+		log.Printf("%s: ignoring input after the first %d bytes (--max-bytes)", name, MaxBytes)
+	}
+	return nil
+}
+
+// formats are the output formats that can be chosen with --format.
+var formats = map[string]terminal.Serializer{
+	"html":  terminal.SerializeHTML,
+	"plain": terminal.SerializePlainText,
+	"json":  terminal.SerializeMetadata,
+	"ansi":  terminal.SerializeANSI,
+	"lines": terminal.SerializeLines,
+}
+
+// RenderOptions are the options used to render the input, which include the
+// limits set by --max-lines and --max-image-bytes.
+var RenderOptions terminal.Options
+
+// MaxBytes limits how much (decompressed) input is read from each file, or
+// each request to the web service. Zero means no limit.
+var MaxBytes int64
+
+// FetchTimeout and FetchMaxBytes limit how long fetching input from a URL can
+// take, and how much of it there can be.
+var (
+	FetchTimeout        = time.Minute
+	FetchMaxBytes int64 = 1 << 30
+)
+
+var errFetchTooLarge = errors.New("response is larger than --fetch-max-bytes")
+
+// fetch gets the content at the URL, which can be no more than FetchMaxBytes
+// long.
+func fetch(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	if resp.ContentLength > FetchMaxBytes {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %w", url, errFetchTooLarge)
+	}
+	return limitedReadCloser{
+		LimitedReader: &io.LimitedReader{R: resp.Body, N: FetchMaxBytes + 1},
+		Closer:        resp.Body,
+	}, nil
+}
+
+// limitedReadCloser reads up to a limit, returning an error if there's more.
+type limitedReadCloser struct {
+	*io.LimitedReader
+	io.Closer
+}
+
+func (l limitedReadCloser) Read(p []byte) (int, error) {
+	n, err := l.LimitedReader.Read(p)
+	if l.N <= 0 {
+		return n, errFetchTooLarge
+	}
+	return n, err
+}
+
+// Strict is set by --strict, and StrictFailed once an unknown or malformed
+// escape sequence has been reported.
+var Strict, StrictFailed bool
+
+// DetectEncoding is set by --input-encoding auto, to transcode each file, or
+// each request to the web service, from the encoding it's detected to be in.
+var DetectEncoding bool
+
+// ShowEscapes is set by --show-escapes, to render the input with its escape
+// sequences visible rather than converting it.
+var ShowEscapes bool
+
+// render renders the input in the format, recording its statistics if
+// --stats is set, and reporting unknown escape sequences if --strict is set.
+// locate finds the file and offset into it of an offset into the input.
+func render(input []byte, format string, locate func(offset int) (string, int)) []byte {
+	if ShowEscapes {
+		return terminal.RenderEscapes(input)
+	}
+	serializers := []terminal.Serializer{formats[format]}
+	if Stats != nil {
+		Stats.inputBytes += len(input)
+		serializers = append(serializers, Stats.record)
+	}
+	if Strict {
+		serializers = append(serializers, func(s *terminal.Screen) []byte {
+			for _, sequence := range s.UnknownSequences() {
+				if RenderOptions.InputEncoding != nil || RenderOptions.TmuxCapture {
+					// The offsets are into the input as decoded, not the files
+					log.Printf("unknown or malformed escape sequence at byte %d of the decoded input: %q", sequence.Offset, sequence.Text)
+				} else {
+					file, fileOffset := locate(sequence.Offset)
+					log.Printf("%s: unknown or malformed escape sequence at byte %d: %q", file, fileOffset, sequence.Text)
+				}
+				StrictFailed = true
+			}
+			return nil
+		})
+	}
+	return terminal.RenderAll(input, RenderOptions, serializers...)[0]
+}
+
+func stdin(files []string, format string) error {
+	input, parts, err := readInput(files)
+	if err != nil {
+		return fmt.Errorf("could not read input: %w", err)
+	}
+	output := render(input, format, locateIn(parts))
+	if format == "html" {
+		if output, err = wrapPreview(output); err != nil {
+			return fmt.Errorf("could not wrap preview: %w", err)
+		}
+	}
+	if _, err := os.Stdout.Write(output); err != nil {
+		return fmt.Errorf("could not write output: %w", err)
+	}
+	return nil
+}
+
+// themeNames lists the names of the built-in themes.
+func themeNames() string {
+	names := make([]string, len(assets.Themes))
+	for i, theme := range assets.Themes {
+		names[i] = theme.Name
+	}
+	return strings.Join(names, ", ")
 }
 
 func main() {
@@ -113,23 +445,337 @@ func main() {
 	app.Name = "terminal-to-html"
 	app.Version = terminal.Version()
 	app.Usage = "turn ANSI in to HTML"
+	app.ArgsUsage = "[file or URL...]"
 	app.Flags = []cli.Flag{
+		&cli.PathFlag{
+			Name:    "config",
+			Usage:   "read flags from this YAML or TOML file, keyed by flag name, e.g. max-lines: 1000",
+			EnvVars: envVars("config"),
+		},
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "http",
+			Value:   "",
+			Usage:   "HTTP service mode (eg --http :6060), endpoints are /terminal and /tail/{name}",
+			EnvVars: envVars("http"),
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    "preview",
+			Usage:   "wrap output in HTML & CSS so it can be easily viewed directly in a browser",
+			EnvVars: envVars("preview"),
+		}),
+		&cli.BoolFlag{
+			Name:  "css",
+			Usage: "print the stylesheet for the HTML output, instead of converting any input",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Value: "html",
+			Usage: "output format: html, plain (text without styles), json (metadata such as annotations), ansi (text and styles, with cursor movement etc. resolved) or lines (a JSON array of the HTML of each line)",
+		},
+		&cli.DurationFlag{
+			Name:  "fetch-timeout",
+			Value: FetchTimeout,
+			Usage: "how long fetching an http(s):// URL given as input can take",
+		},
+		&cli.Int64Flag{
+			Name:  "fetch-max-bytes",
+			Value: FetchMaxBytes,
+			Usage: "the largest response to accept when fetching an http(s):// URL given as input",
+		},
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "theme",
+			Value:   assets.Themes[0].Name,
+			Usage:   "colors of the stylesheet for --preview and --css: " + themeNames(),
+			EnvVars: envVars("theme"),
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:    "max-lines",
+			Value:   1000000,
+			Usage:   "ignore input that moves the cursor past this many lines (0 for no limit)",
+			EnvVars: envVars("max-lines"),
+		}),
+		altsrc.NewInt64Flag(&cli.Int64Flag{
+			Name:    "max-bytes",
+			Value:   1 << 30,
+			Usage:   "ignore input after this many bytes of each file or request, after decompression (0 for no limit)",
+			EnvVars: envVars("max-bytes"),
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:    "max-image-bytes",
+			Value:   10 << 20,
+			Usage:   "render inline images larger than this as placeholders (0 for no limit)",
+			EnvVars: envVars("max-image-bytes"),
+		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:    "allowed-url-schemes",
+			Usage:   "only allow links and images with these URL schemes, e.g. https (relative URLs are always allowed)",
+			EnvVars: envVars("allowed-url-schemes"),
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:    "width",
+			Usage:   "wrap lines at this many columns, like the terminal the input was recorded in (0 to never wrap)",
+			EnvVars: envVars("width"),
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:    "truncate-columns",
+			Usage:   "cut lines longer than this many columns short, ending with an ellipsis, with the full line in a title attribute (0 to never truncate)",
+			EnvVars: envVars("truncate-columns"),
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:    "height",
+			Usage:   "treat the last this many lines as the terminal's display, so that erasing the display clears only those, and erasing the scrollback the lines above (0 for no scrollback)",
+			EnvVars: envVars("height"),
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    "tmux-capture",
+			Usage:   "render logs captured with tmux capture-pane -e (and -C) as tmux writes them",
+			EnvVars: envVars("tmux-capture"),
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    "conpty",
+			Usage:   "render output from Windows' pseudo console (ConPTY), which repaints lines by moving the cursor to them; set --width and --height to its size",
+			EnvVars: envVars("conpty"),
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    "eager-images",
+			Usage:   "load and decode images along with the page, rather than lazily when they're scrolled to",
+			EnvVars: envVars("eager-images"),
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    "title-headers",
+			Usage:   "show each change of the terminal title, as tools announcing their progress in it set it, as a header",
+			EnvVars: envVars("title-headers"),
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "input-encoding",
+			Usage:   "the encoding of the input, e.g. windows-1252 or utf-16le, or auto to detect it, rather than UTF-8",
+			EnvVars: envVars("input-encoding"),
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "timestamp-format",
+			Value:   "processing-instruction",
+			Usage:   "how to render Buildkite timestamps: processing-instruction (<?bk t=\"...\"?>), time-element (<time datetime=\"...\">) or data-attribute (data-timestamp on a span wrapping the line), for sanitizers that strip processing instructions",
+			EnvVars: envVars("timestamp-format"),
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    "elapsed-time",
+			Usage:   "add data-elapsed-ms and data-delta-ms attributes to a span wrapping each line with a Buildkite timestamp, holding the time since the first and the previous timestamped line",
+			EnvVars: envVars("elapsed-time"),
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "timestamp-normalization",
+			Usage:   "correct Buildkite timestamps that go backwards, e.g. from clock skew: clamp (to the timestamp before) or offset (shifting it and every later timestamp forward)",
+			EnvVars: envVars("timestamp-normalization"),
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "attachments",
+			Usage:   "keep files sent with 1337;File that aren't shown as images, e.g. JUnit XML: collect (in --format json) or list (after the HTML, with links to download them)",
+			EnvVars: envVars("attachments"),
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    "legacy-erase-display",
+			Usage:   "make erasing the display and erasing the scrollback both clear everything, as in earlier versions",
+			EnvVars: envVars("legacy-erase-display"),
+		}),
+		&cli.BoolFlag{
+			Name:  "separate",
+			Usage: "convert each file separately, under a header with a link to it, rather than concatenating them",
+		},
 		&cli.StringFlag{
-			Name:  "http",
-			Value: "",
-			Usage: "HTTP service mode (eg --http :6060), endpoint is /terminal",
+			Name:  "output-dir",
+			Usage: "convert each file into a file of the same name in this directory, rather than to stdout",
 		},
 		&cli.BoolFlag{
-			Name:  "preview",
-			Usage: "wrap output in HTML & CSS so it can be easily viewed directly in a browser",
+			Name:  "strict",
+			Usage: "exit with status 2 after reporting the byte offsets of any unknown or malformed escape sequences, which are otherwise ignored",
+		},
+		&cli.BoolFlag{
+			Name:  "show-escapes",
+			Usage: "show the escape sequences and control characters in the input rather than applying them, marking unknown ones, to debug odd output",
+		},
+		&cli.BoolFlag{
+			Name:  "stats",
+			Usage: "print statistics about the conversion to stderr, such as the escape sequences used and the time taken",
+		},
+		&cli.StringFlag{
+			Name:  "cpuprofile",
+			Usage: "write a CPU profile of the conversion to this file, for go tool pprof",
+		},
+		&cli.StringFlag{
+			Name:  "memprofile",
+			Usage: "write a memory profile to this file once the conversion has finished, for go tool pprof",
+		},
+		&cli.StringFlag{
+			Name:  "trace",
+			Usage: "write an execution trace of the conversion to this file, for go tool trace",
+		},
+		&cli.StringFlag{
+			Name:  "parse-trace",
+			Usage: "write a line to this file for each escape sequence, newline, carriage return and backspace parsed, with its byte offset and the action taken, to debug odd output",
+		},
+		&cli.BoolFlag{
+			Name:  "follow",
+			Usage: "keep converting input appended to the file, like tail -f, until interrupted",
 		},
 	}
-	app.Action = func(c *cli.Context) error {
+	app.Before = func(c *cli.Context) error {
+		config, err := configSource(c)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("could not read config: %v", err), 1)
+		}
+		return altsrc.ApplyInputSourceValues(c, config, app.Flags)
+	}
+	app.Action = func(c *cli.Context) (err error) {
+		theme, ok := assets.FindTheme(c.String("theme"))
+		if !ok {
+			return cli.Exit(fmt.Sprintf("unknown theme %q, expected %s", c.String("theme"), themeNames()), 1)
+		}
+		Theme = theme
+		if c.Bool("css") {
+			styleSheet, err := assets.ThemeCSS(Theme)
+			check("could not load stylesheet", err)
+			_, err = os.Stdout.Write(styleSheet)
+			check("could not write stylesheet", err)
+			return nil
+		}
 		PreviewMode = c.Bool("preview")
-		if c.String("http") != "" {
-			webservice(c.String("http"))
-		} else {
-			stdin()
+		MaxBytes = c.Int64("max-bytes")
+		RenderOptions = terminal.Options{
+			MaxLines:            c.Int("max-lines"),
+			MaxInlineImageBytes: c.Int("max-image-bytes"),
+			Width:               c.Int("width"),
+			Height:              c.Int("height"),
+			LegacyEraseDisplay:  c.Bool("legacy-erase-display"),
+			TmuxCapture:         c.Bool("tmux-capture"),
+			ConPTY:              c.Bool("conpty"),
+			TruncateColumns:     c.Int("truncate-columns"),
+			ElapsedTime:         c.Bool("elapsed-time"),
+			TitleHeaders:        c.Bool("title-headers"),
+			EagerImages:         c.Bool("eager-images"),
+			AllowedURLSchemes:   c.StringSlice("allowed-url-schemes"),
+		}
+		switch name := c.String("input-encoding"); name {
+		case "":
+		case "auto":
+			DetectEncoding = true
+		default:
+			enc, err := terminal.LookupInputEncoding(name)
+			if err != nil {
+				return cli.Exit(fmt.Sprintf("invalid --input-encoding: %v", err), 1)
+			}
+			RenderOptions.InputEncoding = enc
+		}
+		switch format := c.String("timestamp-format"); format {
+		case "processing-instruction":
+		case "time-element":
+			RenderOptions.TimestampFormat = terminal.TimestampTimeElement
+		case "data-attribute":
+			RenderOptions.TimestampFormat = terminal.TimestampDataAttribute
+		default:
+			return cli.Exit(fmt.Sprintf("unknown --timestamp-format %q, expected processing-instruction, time-element or data-attribute", format), 1)
+		}
+		switch normalization := c.String("timestamp-normalization"); normalization {
+		case "":
+		case "clamp":
+			RenderOptions.TimestampNormalization = terminal.TimestampsClamped
+		case "offset":
+			RenderOptions.TimestampNormalization = terminal.TimestampsOffset
+		default:
+			return cli.Exit(fmt.Sprintf("unknown --timestamp-normalization %q, expected clamp or offset", normalization), 1)
+		}
+		switch attachments := c.String("attachments"); attachments {
+		case "":
+		case "collect":
+			RenderOptions.Attachments = terminal.AttachmentsCollected
+		case "list":
+			RenderOptions.Attachments = terminal.AttachmentsListed
+		default:
+			return cli.Exit(fmt.Sprintf("unknown --attachments %q, expected collect or list", attachments), 1)
+		}
+		FetchTimeout = c.Duration("fetch-timeout")
+		FetchMaxBytes = c.Int64("fetch-max-bytes")
+		format := c.String("format")
+		if _, ok := formats[format]; !ok {
+			return cli.Exit(fmt.Sprintf("unknown format %q, expected html, plain, json, ansi or lines", format), 1)
+		}
+		if format != "html" && (c.Bool("follow") || c.String("http") != "") {
+			return cli.Exit("--format is only supported when converting files or stdin", 1)
+		}
+		// From here on, errors are returned rather than exiting, so that the
+		// profiles are written
+		stop, err := startProfiling(c.String("cpuprofile"), c.String("memprofile"), c.String("trace"))
+		defer func() {
+			if stopErr := stop(); err == nil {
+				err = stopErr
+			}
+		}()
+		if err != nil {
+			return fmt.Errorf("could not start profiling: %w", err)
+		}
+
+		if c.Bool("strict") {
+			if c.Bool("follow") || c.String("http") != "" {
+				return cli.Exit("--strict is only supported when converting files or stdin", 1)
+			}
+			Strict = true
+		}
+		if c.Bool("show-escapes") {
+			if format != "html" || c.Bool("follow") || c.String("http") != "" {
+				return cli.Exit("--show-escapes is only supported when converting files or stdin to html", 1)
+			}
+			ShowEscapes = true
+		}
+		if c.Bool("stats") {
+			if c.Bool("follow") || c.String("http") != "" {
+				return cli.Exit("--stats is only supported when converting files or stdin", 1)
+			}
+			Stats = newRenderStats()
+			defer Stats.write(os.Stderr)
+		}
+		if path := c.String("parse-trace"); path != "" {
+			if c.Bool("follow") || c.String("http") != "" {
+				return cli.Exit("--parse-trace is only supported when converting files or stdin", 1)
+			}
+			f, err := os.Create(path)
+			if err != nil {
+				return fmt.Errorf("could not create parse trace: %w", err)
+			}
+			w := bufio.NewWriter(f)
+			RenderOptions.Trace = w
+			defer func() {
+				if traceErr := errors.Join(w.Flush(), f.Close()); err == nil && traceErr != nil {
+					err = fmt.Errorf("could not write parse trace: %w", traceErr)
+				}
+			}()
+		}
+		if c.Bool("follow") {
+			if c.NArg() != 1 || c.Args().First() == "-" {
+				return cli.Exit("--follow needs exactly one file", 1)
+			}
+			if err := follow(c.Args().First()); err != nil {
+				return fmt.Errorf("could not follow file: %w", err)
+			}
+		} else if c.String("http") != "" {
+			if err := webservice(c.String("http")); err != nil {
+				return err
+			}
+		} else if c.String("output-dir") != "" {
+			files := c.Args().Slice()
+			if len(files) == 0 {
+				files = []string{"-"}
+			}
+			if err := outputDir(files, format, c.String("output-dir")); err != nil {
+				return err
+			}
+		} else if c.Bool("separate") {
+			if err := separate(c.Args().Slice(), format); err != nil {
+				return err
+			}
+		} else if err := stdin(c.Args().Slice(), format); err != nil {
+			return err
+		}
+		if StrictFailed {
+			return cli.Exit("", 2)
 		}
 		return nil
 	}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain runs the command itself, rather than the tests, when
+// RUN_TERMINAL_TO_HTML is set, so that tests can run it end to end.
+func TestMain(m *testing.M) {
+	if os.Getenv("RUN_TERMINAL_TO_HTML") != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// run runs the command with the arguments and standard input, returning its
+// standard output and error, and its exit code.
+func run(t *testing.T, stdin string, args ...string) (stdout, stderr string, code int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "RUN_TERMINAL_TO_HTML=1")
+	cmd.Stdin = strings.NewReader(stdin)
+	var out, errOut bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &errOut
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		code = exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatalf("running %q: %v", args, err)
+	}
+	return out.String(), errOut.String(), code
+}
+
+// writeFile writes a file in a temporary directory, returning its path.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestStrict(t *testing.T) {
+	file := writeFile(t, "build.log", "ok\n\x1b[5xoops")
+	stdout, stderr, code := run(t, "", "--strict", file)
+	if code != 2 || stdout != "ok\n[5xoops" || !strings.Contains(stderr, `build.log: unknown or malformed escape sequence at byte 3: "\x1b[5x"`) {
+		t.Errorf("--strict = %q, %q, exit %d, want the sequence reported and exit 2", stdout, stderr, code)
+	}
+}
+
+func TestStrictInputEncoding(t *testing.T) {
+	// The input grows when transcoded, so offsets into it are past the end
+	// of the file
+	file := writeFile(t, "build.log", strings.Repeat("\xe9", 20)+"\x1b[5x")
+	stdout, stderr, code := run(t, "", "--strict", "--input-encoding", "windows-1252", file)
+	want := strings.Repeat("é", 20) + "[5x"
+	if code != 2 || stdout != want || !strings.Contains(stderr, `unknown or malformed escape sequence at byte 40 of the decoded input: "\x1b[5x"`) {
+		t.Errorf("--strict --input-encoding = %q, %q, exit %d, want %q and the sequence reported", stdout, stderr, code, want)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/build.log" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("\x1b[31mfetched\x1b[0m"))
+	}))
+	defer srv.Close()
+
+	if stdout, stderr, code := run(t, "", srv.URL+"/build.log"); code != 0 || stdout != `<span class="term-fg31">fetched</span>` {
+		t.Errorf("fetching = %q, %q, exit %d, want the log converted", stdout, stderr, code)
+	}
+	if _, stderr, code := run(t, "", "--fetch-max-bytes", "4", srv.URL+"/build.log"); code != 1 || !strings.Contains(stderr, "response is larger than --fetch-max-bytes") {
+		t.Errorf("fetching with --fetch-max-bytes = %q, exit %d, want an error", stderr, code)
+	}
+	if _, stderr, code := run(t, "", srv.URL+"/missing.log"); code != 1 || !strings.Contains(stderr, "404 Not Found") {
+		t.Errorf("fetching a missing log = %q, exit %d, want an error", stderr, code)
+	}
+}
+
+func TestServeTerminalMaxBytes(t *testing.T) {
+	defer func(maxBytes int64) { MaxBytes = maxBytes }(MaxBytes)
+	MaxBytes = 5
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	srv := httptest.NewServer(http.HandlerFunc(serveTerminal))
+	defer srv.Close()
+	resp, err := http.Post(srv.URL+"/terminal", "text/plain", strings.NewReader("hello world"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || string(body) != "hello" {
+		t.Errorf("POST /terminal = %q, %v, want the first 5 bytes", body, err)
+	}
+	if want := "/terminal: ignoring input after the first 5 bytes (--max-bytes)"; !strings.Contains(logged.String(), want) {
+		t.Errorf("logged %q, want %q", logged.String(), want)
+	}
+}
+
+func TestTheme(t *testing.T) {
+	stdout, _, code := run(t, "", "--css", "--theme", "light")
+	if code != 0 || !strings.Contains(stdout, ".term-container { background: #ffffff;") {
+		t.Errorf("--css --theme light = exit %d, want the light theme's colors", code)
+	}
+
+	stdout, _, code = run(t, "hi", "--preview", "--theme", "light")
+	if code != 0 || !strings.Contains(stdout, `<meta name="color-scheme" content="light">`) || !strings.Contains(stdout, "background: #ffffff; }") {
+		t.Errorf("--preview --theme light = exit %d, want a light page", code)
+	}
+
+	if _, stderr, code := run(t, "", "--css", "--theme", "neon"); code != 1 || !strings.Contains(stderr, `unknown theme "neon", expected dark, light`) {
+		t.Errorf("--theme neon = %q, exit %d, want an error", stderr, code)
+	}
+}
@@ -0,0 +1,79 @@
+package terminal
+
+import (
+	"bytes"
+	"math"
+)
+
+// handleConPTYSequence handles the final character of a control sequence if
+// it's one that ConPTY uses to position the cursor or erase, and reports
+// whether it was.
+func (p *parser) handleConPTYSequence(final rune) bool {
+	switch final {
+	case 'H', 'f', 'd', 'G', 'X':
+	default:
+		return false
+	}
+	p.screen.sequences.countCSI(final)
+	p.recordSequence(true)
+	// Split here rather than by addInstruction, which skips empty
+	// parameters, as the row of \x1b[;5H is
+	params := bytes.Split(p.ansi[p.escapeStartedAt+len("\x1b["):p.cursor], []byte(";"))
+	p.screen.applyConPTYEscape(final, params)
+	p.mode = MODE_NORMAL
+	return true
+}
+
+// applyConPTYEscape applies a sequence handled by handleConPTYSequence, with
+// its parameters.
+func (s *Screen) applyConPTYEscape(code rune, params [][]byte) {
+	row := s.y - s.displayTop() + 1
+	switch code {
+	case 'H', 'f':
+		// Cursor position (CUP, HVP)
+		s.cursorTo(conPTYParam(params, 0), conPTYParam(params, 1))
+	case 'd':
+		// Line position absolute (VPA)
+		s.cursorTo(conPTYParam(params, 0), s.x+1)
+	case 'G':
+		// Cursor character absolute (CHA)
+		s.cursorTo(row, conPTYParam(params, 0))
+	case 'X':
+		// Erase character (ECH), leaving the cursor where it is
+		s.clear(s.y, s.x, s.x+conPTYParam(params, 0)-1)
+	}
+}
+
+// conPTYParam returns parameter i, counting from 0, of a sequence, or 1 if
+// it's missing or 0.
+func conPTYParam(params [][]byte, i int) int {
+	if i >= len(params) {
+		return 1
+	}
+	n, ok := parseUint(params[i])
+	if !ok || n == 0 {
+		return 1
+	}
+	return min(n, math.MaxInt16)
+}
+
+// cursorTo moves the cursor to a row and column of the display, counting
+// from 1, with the same limits as moving it down and forward.
+func (s *Screen) cursorTo(row, column int) {
+	if s.opts.Height > 0 {
+		row = min(row, s.opts.Height)
+	}
+	y := s.displayTop() + row - 1
+	if bottom := max(s.y, len(s.screen)-1); y > bottom {
+		y = bottom + min(y-bottom, maxSkippedLines)
+	}
+	s.y = y
+
+	x := column - 1
+	if s.opts.Width > 0 {
+		x = min(x, s.opts.Width-1)
+	} else {
+		x = min(x, max(s.x, maxCursorColumn))
+	}
+	s.x = x
+}
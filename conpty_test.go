@@ -0,0 +1,37 @@
+package terminal
+
+import "testing"
+
+func TestConPTYFixture(t *testing.T) {
+	// The fixture is from a pseudo console 40 columns wide and 8 lines high
+	raw := loadFixture(t, "conpty.sh", "raw")
+	want := string(loadFixture(t, "conpty.sh", "rendered"))
+	if got := string(RenderWithOptions(raw, Options{ConPTY: true, Width: 40, Height: 8})); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestConPTY(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		opts  Options
+		want  string
+	}{
+		{"cursor position", "one\r\ntwo\r\nthree\x1b[2;2HW\x1b[1;4H!", Options{}, "one!\ntWo\nthree"},
+		{"cursor position defaults", "one\r\ntwo\x1b[HO\x1b[;3HE", Options{}, "OnE\ntwo"},
+		{"rows of the display", "1\r\n2\r\n3\r\n4\x1b[1;1Htop\x1b[9;1Hend", Options{Height: 2}, "1\n2\ntop\nend"},
+		{"columns past the width", "abc\x1b[1;99Hz", Options{Width: 5}, "abc z"},
+		{"line position absolute", "one\r\ntwo\x1b[1dX", Options{}, "oneX\ntwo"},
+		{"cursor character absolute", "abcdef\x1b[3GX", Options{}, "abXdef"},
+		{"erase characters", "abcdef\x1b[2G\x1b[2Xz", Options{}, "az def"},
+		{"erase characters to the end", "abcdef\x1b[5G\x1b[9X", Options{}, "abcd"},
+		{"modes are still ignored", "\x1b[?25lhi\x1b[?25h\x1b[?9001h", Options{}, "hi"},
+	}
+	for _, tc := range testCases {
+		tc.opts.ConPTY = true
+		if got := string(RenderWithOptions([]byte(tc.input), tc.opts)); got != tc.want {
+			t.Errorf("%s: RenderWithOptions(%q) = %q, want %q", tc.name, tc.input, got, tc.want)
+		}
+	}
+}
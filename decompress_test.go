@@ -0,0 +1,63 @@
+package terminal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestDecompress(t *testing.T) {
+	input := []byte("\x1b[31mbuilding\x1b[0m\ndone\n")
+
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write(input)
+	w.Close()
+
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("zstd.NewWriter() error = %v", err)
+	}
+	zst := enc.EncodeAll(input, nil)
+
+	testCases := []struct {
+		name       string
+		input      []byte
+		compressed bool
+	}{
+		{name: "plain", input: input},
+		{name: "gzip", input: gz.Bytes(), compressed: true},
+		{name: "zstd", input: zst, compressed: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsCompressed(tc.input); got != tc.compressed {
+				t.Errorf("IsCompressed() = %t, want %t", got, tc.compressed)
+			}
+			r, err := Decompress(bytes.NewReader(tc.input))
+			if err != nil {
+				t.Fatalf("Decompress() error = %v", err)
+			}
+			defer r.Close()
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("io.ReadAll(Decompress()) error = %v", err)
+			}
+			if !bytes.Equal(got, input) {
+				t.Errorf("io.ReadAll(Decompress()) = %q, want %q", got, input)
+			}
+		})
+	}
+
+	// Input shorter than the longest magic number
+	r, err := Decompress(bytes.NewReader([]byte("a")))
+	if err != nil {
+		t.Fatalf("Decompress() error = %v", err)
+	}
+	if got, _ := io.ReadAll(r); string(got) != "a" {
+		t.Errorf("io.ReadAll(Decompress()) = %q, want %q", got, "a")
+	}
+}
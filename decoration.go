@@ -0,0 +1,272 @@
+package terminal
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A decoration wraps the nodes of a line from start up to (but not including)
+// end in extra markup, e.g. a link around a URL found in the text.
+// Decorations on a line may nest, but must not otherwise overlap.
+type decoration struct {
+	start, end int
+	open       string
+	close      string
+
+	// weak decorations, such as syntax highlighting, give way to links, and
+	// are split up to nest inside or around other decorations
+	weak bool
+}
+
+// clipDecorations returns the decorations that start before end, ending no
+// later than it, for lines cut short by Options.TruncateColumns.
+func clipDecorations(decorations []decoration, end int) []decoration {
+	var clipped []decoration
+	for _, d := range decorations {
+		if d.start < end {
+			d.end = min(d.end, end)
+			clipped = append(clipped, d)
+		}
+	}
+	return clipped
+}
+
+// objectReplacementChar stands in for elements in lineText, so that pattern
+// matching never runs across an image or link.
+const objectReplacementChar = '\uFFFC'
+
+// lineText returns the text of a line, one rune per node, along with the node
+// index of every byte offset in the text (plus one for the end of the text).
+func lineText(line screenLine) (string, []int) {
+	var b strings.Builder
+	nodeAt := make([]int, 0, len(line.nodes)+1)
+	for idx, node := range line.nodes {
+		r, ok := node.getRune()
+		if !ok {
+			r = objectReplacementChar
+		}
+		n, _ := b.WriteRune(r)
+		for i := 0; i < n; i++ {
+			nodeAt = append(nodeAt, idx)
+		}
+	}
+	nodeAt = append(nodeAt, len(line.nodes))
+	return b.String(), nodeAt
+}
+
+// lineDecorations collects the decorations enabled in opts for a line, along
+// with any extra decorations, sorted so that outer decorations come before the
+// decorations they contain.
+func lineDecorations(line screenLine, opts *Options, extra []decoration) []decoration {
+	var decorations, weak []decoration
+	for _, d := range extra {
+		if d.weak {
+			weak = append(weak, d)
+		} else {
+			decorations = append(decorations, d)
+		}
+	}
+	if !opts.Linkify && opts.PathLinker == nil && opts.Search == nil && len(weak) == 0 {
+		return extra
+	}
+
+	text, nodeAt := lineText(line)
+
+	if opts.Linkify {
+		decorations = append(decorations, linkifyURLs(text, nodeAt, opts)...)
+	}
+	if opts.PathLinker != nil {
+		// Links can't nest, so paths inside URLs are left alone
+		for _, d := range linkifyPaths(text, nodeAt, opts) {
+			if !overlapsAny(d, decorations) {
+				decorations = append(decorations, d)
+			}
+		}
+	}
+	var pieces []decoration
+	for _, d := range weak {
+		pieces = append(pieces, splitAround(d, decorations)...)
+	}
+	decorations = append(decorations, pieces...)
+	if opts.Search != nil {
+		// Matches can span anything, so are split up to nest inside it
+		var marks []decoration
+		for _, d := range searchMatches(text, nodeAt, opts.Search) {
+			marks = append(marks, splitAround(d, decorations)...)
+		}
+		decorations = append(decorations, marks...)
+	}
+
+	sort.SliceStable(decorations, func(i, j int) bool {
+		if decorations[i].start != decorations[j].start {
+			return decorations[i].start < decorations[j].start
+		}
+		return decorations[i].end > decorations[j].end
+	})
+	return decorations
+}
+
+// overlapsAny reports whether d shares any nodes with any of decorations.
+func overlapsAny(d decoration, decorations []decoration) bool {
+	for _, o := range decorations {
+		if d.start < o.end && o.start < d.end {
+			return true
+		}
+	}
+	return false
+}
+
+// splitAround splits d into pieces at the boundaries of any of decorations,
+// so that each piece nests inside or around them.
+func splitAround(d decoration, decorations []decoration) []decoration {
+	var boundaries []int
+	for _, o := range decorations {
+		for _, b := range []int{o.start, o.end} {
+			if d.start < b && b < d.end {
+				boundaries = append(boundaries, b)
+			}
+		}
+	}
+	if len(boundaries) == 0 {
+		return []decoration{d}
+	}
+	sort.Ints(boundaries)
+
+	var pieces []decoration
+	start := d.start
+	for _, b := range append(boundaries, d.end) {
+		if b > start {
+			pieces = append(pieces, decoration{start: start, end: b, open: d.open, close: d.close})
+			start = b
+		}
+	}
+	return pieces
+}
+
+// searchMatches finds non-empty matches of the search pattern in the text of a
+// line, and marks them.
+func searchMatches(text string, nodeAt []int, search *regexp.Regexp) []decoration {
+	var decorations []decoration
+	for _, loc := range search.FindAllStringIndex(text, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		decorations = append(decorations, decoration{
+			start: nodeAt[loc[0]],
+			end:   nodeAt[loc[1]],
+			open:  `<mark class="term-search-match">`,
+			close: "</mark>",
+		})
+	}
+	return decorations
+}
+
+// SearchMatchCount returns the number of matches of Options.Search on the
+// screen, as highlighted by AsHTML.
+func (s *Screen) SearchMatchCount() int {
+	if s.opts.Search == nil {
+		return 0
+	}
+	count := 0
+	for _, line := range s.screen {
+		text, nodeAt := lineText(line)
+		count += len(searchMatches(text, nodeAt, s.opts.Search))
+	}
+	return count
+}
+
+var plainURLRegexp = regexp.MustCompile(`\bhttps?://[^\s<>"'\x{FFFC}]+`)
+
+// linkifyURLs finds bare http and https URLs in the text of a line and links
+// them, with the same checks applied to links from 1339 escape sequences.
+func linkifyURLs(text string, nodeAt []int, opts *Options) []decoration {
+	var decorations []decoration
+	for _, loc := range plainURLRegexp.FindAllStringIndex(text, -1) {
+		url := trimURLPunctuation(text[loc[0]:loc[1]])
+		if strings.HasSuffix(url, "://") {
+			continue
+		}
+		href := opts.sanitizeURL(url)
+		if href == unsafeURLSubstitution {
+			// leave it as text rather than linking to nowhere
+			continue
+		}
+		decorations = append(decorations, decoration{
+			start: nodeAt[loc[0]],
+			end:   nodeAt[loc[0]+len(url)],
+			open:  fmt.Sprintf(`<a href="%s"%s>`, html.EscapeString(href), opts.linkAttributes()),
+			close: "</a>",
+		})
+	}
+	return decorations
+}
+
+// trimURLPunctuation removes trailing punctuation that is more likely part of
+// the surrounding prose than the URL, such as a full stop or a closing
+// parenthesis without a matching opening one.
+func trimURLPunctuation(url string) string {
+	for len(url) > 0 {
+		last := url[len(url)-1]
+		switch {
+		case strings.IndexByte(".,:;!?'\"", last) >= 0:
+			url = url[:len(url)-1]
+		case last == ')' && strings.Count(url, "(") < strings.Count(url, ")"):
+			url = url[:len(url)-1]
+		case last == ']' && strings.Count(url, "[") < strings.Count(url, "]"):
+			url = url[:len(url)-1]
+		default:
+			return url
+		}
+	}
+	return url
+}
+
+var pathRegexp = regexp.MustCompile(`(?:\.{0,2}/)?(?:[\w.\-]+/)*[\w\-]+(?:\.[\w\-]+)+:(\d+)(?::(\d+))?\b`)
+
+// linkifyPaths finds path:line:column references in the text of a line and
+// links them to the URLs returned by opts.PathLinker.
+func linkifyPaths(text string, nodeAt []int, opts *Options) []decoration {
+	var decorations []decoration
+	for _, loc := range pathRegexp.FindAllStringSubmatchIndex(text, -1) {
+		if loc[0] > 0 && isPathChar(text[loc[0]-1]) {
+			// matched the tail of something that isn't a path, e.g. a URL
+			continue
+		}
+		line, err := strconv.Atoi(text[loc[2]:loc[3]])
+		if err != nil {
+			continue
+		}
+		column := 0
+		if loc[4] >= 0 {
+			if column, err = strconv.Atoi(text[loc[4]:loc[5]]); err != nil {
+				continue
+			}
+		}
+		path := text[loc[0] : loc[2]-1]
+
+		href := opts.PathLinker(path, line, column)
+		if href == "" {
+			continue
+		}
+		href = opts.sanitizeURL(href)
+		if href == unsafeURLSubstitution {
+			continue
+		}
+		decorations = append(decorations, decoration{
+			start: nodeAt[loc[0]],
+			end:   nodeAt[loc[1]],
+			open:  fmt.Sprintf(`<a href="%s"%s>`, html.EscapeString(href), opts.linkAttributes()),
+			close: "</a>",
+		})
+	}
+	return decorations
+}
+
+func isPathChar(c byte) bool {
+	return c == '/' || c == ':' || c == '.' || c == '-' || c == '_' ||
+		('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
@@ -0,0 +1,192 @@
+package terminal
+
+import (
+	"bytes"
+)
+
+// DiffFormat is a layout for RenderDiff.
+type DiffFormat int
+
+const (
+	// DiffUnified renders one line after another, each in a span classed by
+	// whether it is in both inputs (term-diff-equal), only the first
+	// (term-diff-delete), or only the second (term-diff-insert).
+	DiffUnified DiffFormat = iota
+
+	// DiffSideBySide renders a <table class="term-diff"> with a row for each
+	// line, the first input on the left and the second on the right.
+	DiffSideBySide
+)
+
+type diffOpKind int
+
+const (
+	diffEqual diffOpKind = iota
+	diffDelete
+	diffInsert
+)
+
+// A diffOp keeps, deletes or inserts a line. a and b are the indexes of the
+// line in each input, where relevant.
+type diffOp struct {
+	kind diffOpKind
+	a, b int
+}
+
+// RenderDiff converts two ANSI inputs to HTML and returns a diff of their
+// final screens, e.g. to compare the output of a passing and a failing build.
+// Lines are the same only if both their text and styles are. Options that
+// apply to whole lines are honoured, but sections aren't rendered.
+func RenderDiff(a, b []byte, opts Options, format DiffFormat) []byte {
+	screenA, screenB := NewScreen(opts), NewScreen(opts)
+	screenA.parseAll(a)
+	screenB.parseAll(b)
+	linesA, linesB := screenA.screen, screenB.screen
+	rendererA, rendererB := htmlRenderer{opts: &screenA.opts}, htmlRenderer{opts: &screenB.opts}
+
+	ops := diffLines(linesA, linesB)
+	var buf bytes.Buffer
+	if format == DiffSideBySide {
+		buf.WriteString(`<table class="term-diff">`)
+		for i := 0; i < len(ops); {
+			if ops[i].kind == diffEqual {
+				op := ops[i]
+				buf.WriteString("\n<tr>")
+				writeDiffCell(&buf, "term-diff-equal", rendererA.lineAsHTML(op.a, linesA[op.a]))
+				writeDiffCell(&buf, "term-diff-equal", rendererB.lineAsHTML(op.b, linesB[op.b]))
+				buf.WriteString("</tr>")
+				i++
+				continue
+			}
+
+			// Pair up the deletions and insertions of each change
+			var deleted, inserted []int
+			for ; i < len(ops) && ops[i].kind != diffEqual; i++ {
+				if ops[i].kind == diffDelete {
+					deleted = append(deleted, ops[i].a)
+				} else {
+					inserted = append(inserted, ops[i].b)
+				}
+			}
+			for j := 0; j < len(deleted) || j < len(inserted); j++ {
+				buf.WriteString("\n<tr>")
+				if j < len(deleted) {
+					writeDiffCell(&buf, "term-diff-delete", rendererA.lineAsHTML(deleted[j], linesA[deleted[j]]))
+				} else {
+					writeDiffCell(&buf, "term-diff-empty", "")
+				}
+				if j < len(inserted) {
+					writeDiffCell(&buf, "term-diff-insert", rendererB.lineAsHTML(inserted[j], linesB[inserted[j]]))
+				} else {
+					writeDiffCell(&buf, "term-diff-empty", "")
+				}
+				buf.WriteString("</tr>")
+			}
+		}
+		buf.WriteString("\n</table>")
+		return buf.Bytes()
+	}
+
+	for i, op := range ops {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		switch op.kind {
+		case diffEqual:
+			writeDiffLine(&buf, "term-diff-equal", rendererA.lineAsHTML(op.a, linesA[op.a]))
+		case diffDelete:
+			writeDiffLine(&buf, "term-diff-delete", rendererA.lineAsHTML(op.a, linesA[op.a]))
+		case diffInsert:
+			writeDiffLine(&buf, "term-diff-insert", rendererB.lineAsHTML(op.b, linesB[op.b]))
+		}
+	}
+	return buf.Bytes()
+}
+
+func writeDiffLine(buf *bytes.Buffer, class, html string) {
+	buf.WriteString(`<span class="term-diff-line ` + class + `">`)
+	buf.WriteString(html)
+	buf.WriteString(`</span>`)
+}
+
+func writeDiffCell(buf *bytes.Buffer, class, html string) {
+	buf.WriteString(`<td class="` + class + `">`)
+	buf.WriteString(html)
+	buf.WriteString(`</td>`)
+}
+
+// diffLines finds the shortest edit script turning lines a into lines b,
+// using Myers' algorithm:
+// http://www.xmailserver.org/diff2.pdf
+func diffLines(a, b []screenLine) []diffOp {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+
+	// trace[d] holds v[-d..d] as it was before round d
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && sameNodes(a[x].nodes, b[y].nodes) {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrackDiff(trace, n, m)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrackDiff follows the trace of diffLines back from the end of both
+// inputs, returning the edits in order.
+func backtrackDiff(trace [][]int, x, y int) []diffOp {
+	var ops []diffOp
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[d+k-1] < v[d+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := 0
+		if d > 0 {
+			prevX = v[d+prevK]
+		}
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, diffOp{kind: diffEqual, a: x, b: y})
+		}
+		if d > 0 {
+			if x == prevX {
+				y--
+				ops = append(ops, diffOp{kind: diffInsert, a: x, b: y})
+			} else {
+				x--
+				ops = append(ops, diffOp{kind: diffDelete, a: x, b: y})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
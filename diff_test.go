@@ -0,0 +1,86 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiffLines(t *testing.T) {
+	testCases := []struct {
+		a, b string
+		want string
+	}{
+		{a: "", b: "", want: ""},
+		{a: "a\nb\nc", b: "a\nb\nc", want: "==="},
+		{a: "a\nb\nc", b: "a\nc", want: "=-="},
+		{a: "a\nc", b: "a\nb\nc", want: "=+="},
+		{a: "a\nb", b: "c\nd", want: "--++"},
+		{a: "a\nb\nc\na\nb\nb\na", b: "c\nb\na\nb\na\nc", want: "--=+==-=+"},
+		{a: "\x1b[31ma\x1b[0m", b: "a", want: "-+"},
+	}
+
+	for _, tc := range testCases {
+		a, b := NewScreen(Options{}), NewScreen(Options{})
+		a.Parse([]byte(tc.a))
+		b.Parse([]byte(tc.b))
+		ops := diffLines(a.screen, b.screen)
+
+		var got strings.Builder
+		var x, y int
+		for _, op := range ops {
+			switch op.kind {
+			case diffEqual:
+				got.WriteString("=")
+				if op.a != x || op.b != y {
+					t.Errorf("diffLines(%q, %q) equal op at (%d, %d), want (%d, %d)", tc.a, tc.b, op.a, op.b, x, y)
+				}
+				x++
+				y++
+			case diffDelete:
+				got.WriteString("-")
+				x++
+			case diffInsert:
+				got.WriteString("+")
+				y++
+			}
+		}
+		if got.String() != tc.want {
+			t.Errorf("diffLines(%q, %q) = %q, want %q", tc.a, tc.b, got.String(), tc.want)
+		}
+		if x != len(a.screen) || y != len(b.screen) {
+			t.Errorf("diffLines(%q, %q) covered (%d, %d) lines, want (%d, %d)", tc.a, tc.b, x, y, len(a.screen), len(b.screen))
+		}
+	}
+}
+
+func TestRenderDiff(t *testing.T) {
+	a := []byte("build\n\x1b[32mok\x1b[0m\ndone")
+	b := []byte("build\n\x1b[31mFAIL\x1b[0m\nretrying\ndone")
+
+	got := string(RenderDiff(a, b, Options{}, DiffUnified))
+	want := strings.Join([]string{
+		`<span class="term-diff-line term-diff-equal">build</span>`,
+		`<span class="term-diff-line term-diff-delete"><span class="term-fg32">ok</span></span>`,
+		`<span class="term-diff-line term-diff-insert"><span class="term-fg31">FAIL</span></span>`,
+		`<span class="term-diff-line term-diff-insert">retrying</span>`,
+		`<span class="term-diff-line term-diff-equal">done</span>`,
+	}, "\n")
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("RenderDiff(DiffUnified) diff (-got +want):\n%s", diff)
+	}
+
+	got = string(RenderDiff(a, b, Options{}, DiffSideBySide))
+	want = strings.Join([]string{
+		`<table class="term-diff">`,
+		`<tr><td class="term-diff-equal">build</td><td class="term-diff-equal">build</td></tr>`,
+		`<tr><td class="term-diff-delete"><span class="term-fg32">ok</span></td><td class="term-diff-insert"><span class="term-fg31">FAIL</span></td></tr>`,
+		`<tr><td class="term-diff-empty"></td><td class="term-diff-insert">retrying</td></tr>`,
+		`<tr><td class="term-diff-equal">done</td><td class="term-diff-equal">done</td></tr>`,
+		`</table>`,
+	}, "\n")
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("RenderDiff(DiffSideBySide) diff (-got +want):\n%s", diff)
+	}
+}
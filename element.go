@@ -1,10 +1,12 @@
 package terminal
 
 import (
+	"bytes"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"html"
+	"io"
 	"mime"
 	"strings"
 )
@@ -13,6 +15,7 @@ const (
 	ELEMENT_ITERM_IMAGE = iota
 	ELEMENT_IMAGE
 	ELEMENT_LINK
+	ELEMENT_IMAGE_PLACEHOLDER
 )
 
 type element struct {
@@ -27,7 +30,7 @@ type element struct {
 
 var errUnsupportedElementSequence = errors.New("Unsupported element sequence")
 
-func (i *element) asHTML() string {
+func (i *element) asHTML(opts *Options) string {
 	h := html.EscapeString
 
 	if i.elementType == ELEMENT_LINK {
@@ -35,7 +38,14 @@ func (i *element) asHTML() string {
 		if content == "" {
 			content = i.url
 		}
-		return fmt.Sprintf(`<a href="%s">%s</a>`, h(sanitizeURL(i.url)), h(content))
+		return fmt.Sprintf(`<a href="%s"%s>%s</a>`, h(opts.sanitizeURL(i.url)), opts.linkAttributes(), h(content))
+	}
+
+	if i.elementType == ELEMENT_IMAGE_PLACEHOLDER {
+		if opts.Accessible {
+			return fmt.Sprintf(`<span class="term-image-placeholder" role="img" aria-label="Image omitted: %s">[image omitted: %s]</span>`, h(i.url), h(i.url))
+		}
+		return fmt.Sprintf(`<span class="term-image-placeholder">[image omitted: %s]</span>`, h(i.url))
 	}
 
 	alt := i.alt
@@ -44,18 +54,21 @@ func (i *element) asHTML() string {
 	}
 
 	parts := []string{fmt.Sprintf(`alt="%s"`, h(alt))}
+	if opts.Accessible {
+		parts = append(parts, fmt.Sprintf(`aria-label="Image: %s"`, h(alt)))
+	}
 
 	switch i.elementType {
 	case ELEMENT_ITERM_IMAGE:
 		src := fmt.Sprintf(`src="data:%s;base64,%s"`, h(i.contentType), h(i.content))
 		parts = append(parts, src)
 	case ELEMENT_IMAGE:
-		url := sanitizeURL(i.url)
+		url := opts.sanitizeURL(i.url)
 		if url == "" || url == unsafeURLSubstitution {
 			// don't emit an <img> at all if the URL is empty or didn't sanitize
 			return ""
 		}
-		src := fmt.Sprintf(`src="%s"`, h(url))
+		src := fmt.Sprintf(`src="%s"`, h(opts.proxyImageURL(url)))
 		parts = append(parts, src)
 	default:
 		// unreachable, but…
@@ -68,11 +81,45 @@ func (i *element) asHTML() string {
 	if i.height != "" {
 		parts = append(parts, fmt.Sprintf(`height="%s"`, h(i.height)))
 	}
+	if !opts.EagerImages {
+		// So that a log of many screenshots doesn't hold up the page
+		parts = append(parts, `loading="lazy"`, `decoding="async"`)
+	}
 
 	return fmt.Sprintf(`<img %s>`, strings.Join(parts, " "))
 }
 
-func parseElementSequence(sequence string) (*element, error) {
+// inlineImageSize returns the decoded size in bytes of an inline image's
+// base64 content.
+func inlineImageSize(content []byte) int {
+	padding := len(content) - len(bytes.TrimRight(content, "="))
+	return base64.StdEncoding.DecodedLen(len(content)) - padding
+}
+
+// validBase64 reports whether content is valid base64, decoding it a little
+// at a time rather than all at once, so that checking a large image doesn't
+// take as much memory again.
+func validBase64(content []byte) bool {
+	_, err := io.Copy(io.Discard, base64.NewDecoder(base64.StdEncoding, bytes.NewReader(content)))
+	return err == nil
+}
+
+// replaceWithPlaceholder turns the element into a placeholder that shows only
+// its name, dropping any content.
+func (i *element) replaceWithPlaceholder() {
+	i.elementType = ELEMENT_IMAGE_PLACEHOLDER
+	i.content = ""
+}
+
+// elementSequencePrefix begins all the OSC sequences that parseElementSequence
+// supports, so the parser can skip others without converting them to strings.
+var elementSequencePrefix = []byte("133")
+
+// parseElementSequence parses an OSC sequence for an element. fits is called
+// with the decoded size of an inline image, and if it returns false the image
+// is replaced with a placeholder before its content is copied out of the
+// sequence. A nil fits allows images of any size.
+func parseElementSequence(sequence []byte, fits func(size int) bool) (*element, error) {
 	// Expect:
 	// - iTerm style inline image: 1337;File=name=1.gif;inline=1:BASE64
 	// - Buildkite external image: 1338;url=…;alt=…;width=…;height=…
@@ -93,7 +140,7 @@ func parseElementSequence(sequence string) (*element, error) {
 
 	imageInline := false
 
-	elem := &element{content: content, elementType: elementType}
+	elem := &element{elementType: elementType}
 
 	for _, token := range tokens {
 		parts := strings.SplitN(token, "=", 2)
@@ -142,6 +189,12 @@ func parseElementSequence(sequence string) (*element, error) {
 		// in iTerm2, if you don't specify inline=1, the image is merely downloaded
 		// and not displayed.
 		elem = nil
+	} else if elem.elementType == ELEMENT_ITERM_IMAGE {
+		if fits == nil || fits(inlineImageSize(content)) {
+			elem.content = string(content)
+		} else {
+			elem.replaceWithPlaceholder()
+		}
 	}
 	return elem, nil
 }
@@ -163,35 +216,36 @@ func parseImageDimension(s string) string {
 	}
 }
 
-func splitAndVerifyElementSequence(s string) (arguments string, elementType int, content string, err error) {
-	if strings.HasPrefix(s, "1338;") {
-		return s[len("1338;"):], ELEMENT_IMAGE, "", nil
+// splitAndVerifyElementSequence splits a sequence into its arguments and, for
+// inline images, its content, which is left in the sequence as it may be
+// large.
+func splitAndVerifyElementSequence(s []byte) (arguments string, elementType int, content []byte, err error) {
+	if bytes.HasPrefix(s, []byte("1338;")) {
+		return string(s[len("1338;"):]), ELEMENT_IMAGE, nil, nil
 	}
-	if strings.HasPrefix(s, "1339;") {
-		return s[len("1339;"):], ELEMENT_LINK, "", nil
+	if bytes.HasPrefix(s, []byte("1339;")) {
+		return string(s[len("1339;"):]), ELEMENT_LINK, nil, nil
 	}
 
 	prefixLen := len("1337;File=")
-	if !strings.HasPrefix(s, "1337;File=") {
-		return "", 0, "", errUnsupportedElementSequence
+	if !bytes.HasPrefix(s, []byte("1337;File=")) {
+		return "", 0, nil, errUnsupportedElementSequence
 	}
 	s = s[prefixLen:]
 
-	parts := strings.Split(s, ":")
-	if len(parts) != 2 {
-		return "", 0, "", fmt.Errorf("expected sequence to have one arguments part and one content part, got %d part(s)", len(parts))
+	if n := bytes.Count(s, []byte(":")) + 1; n != 2 {
+		return "", 0, nil, fmt.Errorf("expected sequence to have one arguments part and one content part, got %d part(s)", n)
 	}
 
 	elementType = ELEMENT_ITERM_IMAGE
-	arguments = parts[0]
-	content = parts[1]
+	args, content, _ := bytes.Cut(s, []byte(":"))
+	arguments = string(args)
 	if len(content) == 0 {
-		return "", 0, "", fmt.Errorf("image content missing")
+		return "", 0, nil, fmt.Errorf("image content missing")
 	}
 
-	_, err = base64.StdEncoding.DecodeString(content)
-	if err != nil {
-		return "", 0, "", fmt.Errorf("expected content part to be valid Base64")
+	if !validBase64(content) {
+		return "", 0, nil, fmt.Errorf("expected content part to be valid Base64")
 	}
 
 	return
@@ -1,7 +1,9 @@
 package terminal
 
 import (
+	"bytes"
 	"reflect"
+	"runtime"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -54,7 +56,7 @@ var errorCases = []struct {
 func TestErrorCases(t *testing.T) {
 	for _, c := range errorCases {
 		t.Run(c.name, func(t *testing.T) {
-			elem, err := parseElementSequence(c.input)
+			elem, err := parseElementSequence([]byte(c.input), nil)
 			if elem != nil {
 				t.Fatalf("%s\ninput\t\t%q\nexpected no image, received %+v", c.name, c.input, elem)
 			}
@@ -155,7 +157,7 @@ var validCases = []struct {
 func TestElementCases(t *testing.T) {
 	for _, c := range validCases {
 		t.Run(c.name, func(t *testing.T) {
-			elem, err := parseElementSequence(c.input)
+			elem, err := parseElementSequence([]byte(c.input), nil)
 			if err != nil {
 				t.Errorf("%s\ninput\t\t%q\nexpected no error, received %s", c.name, c.input, err.Error())
 			} else if !reflect.DeepEqual(elem, c.expected) {
@@ -178,7 +180,7 @@ var asHTMLCases = []struct {
 			contentType: "image/png",
 			content:     "AA==",
 		},
-		`<img alt="test.png" src="data:image/png;base64,AA==">`,
+		`<img alt="test.png" src="data:image/png;base64,AA==" loading="lazy" decoding="async">`,
 	}, {
 		"inline image (HTML minefield)",
 		element{
@@ -189,11 +191,15 @@ var asHTMLCases = []struct {
 			width:       "<'&'>%",
 			height:      "<'&'>px",
 		},
-		`<img alt="&lt;script&gt;.pdf" src="data:application/pdf;base64,&lt;script&gt;" width="&lt;&#39;&amp;&#39;&gt;%" height="&lt;&#39;&amp;&#39;&gt;px">`,
+		`<img alt="&lt;script&gt;.pdf" src="data:application/pdf;base64,&lt;script&gt;" width="&lt;&#39;&amp;&#39;&gt;%" height="&lt;&#39;&amp;&#39;&gt;px" loading="lazy" decoding="async">`,
+	}, {
+		"inline image placeholder (HTML minefield)",
+		element{elementType: ELEMENT_IMAGE_PLACEHOLDER, url: "<script>.png"},
+		`<span class="term-image-placeholder">[image omitted: &lt;script&gt;.png]</span>`,
 	}, {
 		"external image (simple)",
 		element{elementType: ELEMENT_IMAGE, url: "https://example.com/a.png"},
-		`<img alt="https://example.com/a.png" src="https://example.com/a.png">`,
+		`<img alt="https://example.com/a.png" src="https://example.com/a.png" loading="lazy" decoding="async">`,
 	}, {
 		"external image (HTML minefield)",
 		element{
@@ -203,7 +209,7 @@ var asHTMLCases = []struct {
 			width:       "<'&'>%",
 			height:      "<'&'>px",
 		},
-		`<img alt="&lt;script&gt;&#39;hello &amp; world&#39;&lt;/script&gt;" src="https://example.com/?tag=&lt;script&gt;&amp;a=b" width="&lt;&#39;&amp;&#39;&gt;%" height="&lt;&#39;&amp;&#39;&gt;px">`,
+		`<img alt="&lt;script&gt;&#39;hello &amp; world&#39;&lt;/script&gt;" src="https://example.com/?tag=&lt;script&gt;&amp;a=b" width="&lt;&#39;&amp;&#39;&gt;%" height="&lt;&#39;&amp;&#39;&gt;px" loading="lazy" decoding="async">`,
 	}, {
 		"link (simple)",
 		element{elementType: ELEMENT_LINK, url: "https://example.com/"},
@@ -222,10 +228,42 @@ var asHTMLCases = []struct {
 func TestAsHTMLCases(t *testing.T) {
 	for _, c := range asHTMLCases {
 		t.Run(c.name, func(t *testing.T) {
-			html := c.element.asHTML()
+			html := c.element.asHTML(&Options{})
 			if diff := cmp.Diff(html, c.expected); diff != "" {
 				t.Errorf("%v.asHTML() diff (-got +want):\n%s", c.element, diff)
 			}
 		})
 	}
 }
+
+func TestLargeInlineImage(t *testing.T) {
+	content := bytes.Repeat([]byte("AAAA"), 1<<20)
+	sequence := append([]byte("1337;File=name="+base64Encode("big.png")+";inline=1:"), content...)
+
+	var sizes []int
+	fits := func(size int) bool {
+		sizes = append(sizes, size)
+		return false
+	}
+	// Load the table of MIME types, which is only done once, beforehand
+	contentTypeForFile("big.png")
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	elem, err := parseElementSequence(sequence, fits)
+	runtime.ReadMemStats(&after)
+	if err != nil {
+		t.Fatalf("parseElementSequence() error = %v", err)
+	}
+
+	want := &element{elementType: ELEMENT_IMAGE_PLACEHOLDER, url: "big.png", contentType: "image/png"}
+	if diff := cmp.Diff(elem, want, cmp.AllowUnexported(element{})); diff != "" {
+		t.Errorf("parseElementSequence() diff (-got +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(sizes, []int{3 << 20}); diff != "" {
+		t.Errorf("fits() sizes diff (-got +want):\n%s", diff)
+	}
+	// Neither the content nor its decoded bytes are copied
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > uint64(len(content)/16) {
+		t.Errorf("parseElementSequence() allocated %d bytes for %d bytes of content", allocated, len(content))
+	}
+}
@@ -0,0 +1,72 @@
+package terminal
+
+// escapeSpan is the range of the input taken up by an escape sequence, and
+// whether the parser recognised it.
+type escapeSpan struct {
+	start, end int
+	known      bool
+}
+
+// RenderEscapes renders input as HTML with its escape sequences and control
+// characters shown rather than applied, like cat -v, to help work out why it
+// renders oddly. Control characters are shown in caret notation, e.g. ^[ for
+// escape and ^M for carriage return. Sequences that were recognised are
+// wrapped in <span class="term-escape">, as are the carriage returns and
+// backspaces that move the cursor. Unknown sequences, of which only the
+// escape character is skipped before the rest is rendered as text, and other
+// control characters, which are rendered as they are, are wrapped in
+// <span class="term-escape term-escape-unknown">.
+func RenderEscapes(input []byte) []byte {
+	s := NewScreen(Options{})
+	s.recordEscapes = true
+	s.Parse(input)
+
+	b := make([]byte, 0, len(input)+len(s.escapes)*len(`<span class="term-escape"></span>`))
+	start := 0
+	for _, e := range s.escapes {
+		b = appendVisibleText(b, input[start:e.start])
+		b = appendEscapeSpan(b, input[e.start:e.end], e.known)
+		start = e.end
+	}
+	return appendVisibleText(b, input[start:])
+}
+
+// appendVisibleText appends text, with its control characters other than
+// newlines and tabs in spans of their own.
+func appendVisibleText(b, text []byte) []byte {
+	start := 0
+	for i, c := range text {
+		if isControl(c) {
+			b = appendEscaped(b, text[start:i])
+			b = appendEscapeSpan(b, text[i:i+1], c == '\r' || c == '\b')
+			start = i + 1
+		}
+	}
+	return appendEscaped(b, text[start:])
+}
+
+// appendEscapeSpan appends a sequence in a span classed by whether it's known,
+// with its control characters in caret notation.
+func appendEscapeSpan(b, sequence []byte, known bool) []byte {
+	if known {
+		b = append(b, `<span class="term-escape">`...)
+	} else {
+		b = append(b, `<span class="term-escape term-escape-unknown">`...)
+	}
+	start := 0
+	for i, c := range sequence {
+		if isControl(c) {
+			b = appendEscaped(b, sequence[start:i])
+			b = append(b, '^', c^0x40)
+			start = i + 1
+		}
+	}
+	b = appendEscaped(b, sequence[start:])
+	return append(b, "</span>"...)
+}
+
+// isControl reports whether c is a control character that isn't visible in
+// the text, i.e. not a newline or tab.
+func isControl(c byte) bool {
+	return (c < ' ' && c != '\n' && c != '\t') || c == 0x7f
+}
@@ -0,0 +1,62 @@
+package terminal
+
+import "testing"
+
+func TestRenderEscapes(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		expected string
+	}{{
+		"plain text",
+		"hello <friend>\n\tthere",
+		"hello &lt;friend&gt;\n\tthere",
+	}, {
+		"colours",
+		"\x1b[31mred\x1b[0m",
+		`<span class="term-escape">^[[31m</span>red<span class="term-escape">^[[0m</span>`,
+	}, {
+		"carriage returns and backspaces",
+		"50%\r100%\bX",
+		`50%<span class="term-escape">^M</span>100%<span class="term-escape">^H</span>X`,
+	}, {
+		"other control characters",
+		"ding\a\x00\x7f",
+		`ding<span class="term-escape term-escape-unknown">^G</span><span class="term-escape term-escape-unknown">^@</span><span class="term-escape term-escape-unknown">^?</span>`,
+	}, {
+		"unknown control sequence",
+		"\x1b[31zred",
+		`<span class="term-escape term-escape-unknown">^[</span>[31zred`,
+	}, {
+		"unknown escape",
+		"\x1bZok",
+		`<span class="term-escape term-escape-unknown">^[</span>Zok`,
+	}, {
+		"other escapes",
+		"\x1b7\x1b(Bsaved\x1b8",
+		`<span class="term-escape">^[7</span><span class="term-escape">^[(B</span>saved<span class="term-escape">^[8</span>`,
+	}, {
+		"operating system commands",
+		"\x1b]0;title\a\x1b]1339;url=https://example.com\a",
+		`<span class="term-escape">^[]0;title^G</span><span class="term-escape">^[]1339;url=https:&#47;&#47;example.com^G</span>`,
+	}, {
+		"application program commands",
+		"\x1b_bk;t=123\aline",
+		`<span class="term-escape">^[_bk;t=123^G</span>line`,
+	}, {
+		"modes",
+		"\x1b[?25lhidden\x1b[?25h",
+		`<span class="term-escape">^[[?25l</span>hidden<span class="term-escape">^[[?25h</span>`,
+	}, {
+		"unterminated sequence",
+		"text\x1b]1337;File=",
+		`text<span class="term-escape term-escape-unknown">^[]1337;File=</span>`,
+	}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := string(RenderEscapes([]byte(c.input))); got != c.expected {
+				t.Errorf("RenderEscapes(%q) = %q, want %q", c.input, got, c.expected)
+			}
+		})
+	}
+}
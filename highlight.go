@@ -0,0 +1,131 @@
+package terminal
+
+import (
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// A HighlightRange gives the CSS class of a range of code, e.g. a keyword.
+// Start and End are byte offsets into the code, End being exclusive.
+type HighlightRange struct {
+	Start, End int
+	Class      string
+}
+
+// A codeBlock is a run of lines of code, from start up to (but not including)
+// end, found between fences or heredoc delimiters.
+type codeBlock struct {
+	start, end int
+	language   string
+}
+
+var (
+	heredocStartRegexp  = regexp.MustCompile(`<<-?\s*(['"]?)([A-Za-z_][A-Za-z0-9_]*)(['"]?)`)
+	heredocFileRegexp   = regexp.MustCompile(`[\w./-]+\.([A-Za-z0-9]+)\b`)
+	heredocEndRegexp    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	codeFenceStart      = "```"
+	codeFenceInfoRegexp = regexp.MustCompile("^\\s*```\\s*([\\w+#-]*)\\s*$")
+)
+
+// findCodeBlocks finds blocks of code in the text of the lines, either
+// between Markdown-style fences (```go ... ```), or in heredocs
+// (cat > main.go <<EOF ... EOF). A fence's info string is the block's
+// language, as is the extension of a file named on a heredoc's first line.
+// Unterminated blocks are ignored.
+func findCodeBlocks(lines []screenLine) []codeBlock {
+	// Index the lines that could end a block, so that finding the end of each
+	// block doesn't mean searching through the rest of the lines
+	texts := make([]string, len(lines))
+	ends := map[string][]int{}
+	for i, line := range lines {
+		texts[i] = lineString(line, len(line.nodes))
+		trimmed := strings.TrimSpace(texts[i])
+		if trimmed == codeFenceStart || heredocEndRegexp.MatchString(trimmed) {
+			ends[trimmed] = append(ends[trimmed], i)
+		}
+	}
+
+	var blocks []codeBlock
+	for i := 0; i < len(lines); i++ {
+		text := texts[i]
+
+		var language, delimiter string
+		if m := codeFenceInfoRegexp.FindStringSubmatch(text); m != nil {
+			language, delimiter = m[1], codeFenceStart
+		} else if m := heredocStartRegexp.FindStringSubmatchIndex(text); m != nil && text[m[2]:m[3]] == text[m[6]:m[7]] && (m[0] == 0 || text[m[0]-1] != '<') {
+			delimiter = text[m[4]:m[5]]
+			if f := heredocFileRegexp.FindStringSubmatch(text[:m[0]] + " " + text[m[1]:]); f != nil {
+				language = strings.ToLower(f[1])
+			}
+		} else {
+			continue
+		}
+
+		candidates := ends[delimiter]
+		if k := sort.SearchInts(candidates, i+1); k < len(candidates) {
+			blocks = append(blocks, codeBlock{start: i + 1, end: candidates[k], language: language})
+			i = candidates[k]
+		}
+	}
+	return blocks
+}
+
+// codeHighlights finds blocks of code in the lines and highlights them with
+// opts.Highlighter, returning the resulting decorations of each line.
+func codeHighlights(lines []screenLine, opts *Options) map[int][]decoration {
+	if opts.Highlighter == nil {
+		return nil
+	}
+	highlights := map[int][]decoration{}
+	for _, block := range findCodeBlocks(lines) {
+		// Join the text of the block's lines, remembering where each starts
+		var code strings.Builder
+		texts := make([]string, 0, block.end-block.start)
+		nodeAts := make([][]int, 0, block.end-block.start)
+		offsets := make([]int, 0, block.end-block.start)
+		for i := block.start; i < block.end; i++ {
+			if i > block.start {
+				code.WriteByte('\n')
+			}
+			text, nodeAt := lineText(lines[i])
+			offsets = append(offsets, code.Len())
+			texts = append(texts, text)
+			nodeAts = append(nodeAts, nodeAt)
+			code.WriteString(text)
+		}
+
+		end := 0
+		for _, r := range opts.Highlighter(block.language, code.String()) {
+			// Ranges must be in order and not overlap
+			if r.Class == "" || r.Start < end || r.End <= r.Start || r.End > code.Len() {
+				continue
+			}
+			end = r.End
+			open := `<span class="` + html.EscapeString(r.Class) + `">`
+
+			// Split the range at line breaks
+			for j, offset := range offsets {
+				start, stop := r.Start-offset, r.End-offset
+				if start < 0 {
+					start = 0
+				}
+				if stop > len(texts[j]) {
+					stop = len(texts[j])
+				}
+				if start >= stop || nodeAts[j][start] >= nodeAts[j][stop] {
+					continue
+				}
+				highlights[block.start+j] = append(highlights[block.start+j], decoration{
+					start: nodeAts[j][start],
+					end:   nodeAts[j][stop],
+					open:  open,
+					close: "</span>",
+					weak:  true,
+				})
+			}
+		}
+	}
+	return highlights
+}
@@ -0,0 +1,44 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFindCodeBlocks(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		want  []codeBlock
+	}{
+		{
+			name:  "fences",
+			input: "```ruby\nputs 1\n```\n```\nplain\n```",
+			want:  []codeBlock{{start: 1, end: 2, language: "ruby"}, {start: 4, end: 5}},
+		},
+		{
+			name:  "heredocs",
+			input: "cat <<-\"END\" > config.YAML\na: 1\n  END\npython3 <<EOF\nprint(1)\nEOF",
+			want:  []codeBlock{{start: 1, end: 2, language: "yaml"}, {start: 4, end: 5}},
+		},
+		{
+			name:  "unterminated",
+			input: "std::cout << endl;\n```go\nfunc main() {}",
+		},
+		{
+			name:  "here-strings and mismatched quotes",
+			input: "cat <<<EOF\ncat <<'EOF\"\nEOF",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewScreen(Options{})
+			s.Parse([]byte(tc.input))
+			if diff := cmp.Diff(findCodeBlocks(s.screen), tc.want, cmp.AllowUnexported(codeBlock{})); diff != "" {
+				t.Errorf("findCodeBlocks() diff (-got +want):\n%s", diff)
+			}
+		})
+	}
+}
@@ -7,11 +7,60 @@ import (
 	"io"
 )
 
-//go:embed terminal.css
+//go:embed terminal.css themes/*.css
 var fs embed.FS
 
+// A Theme is a color palette for the stylesheet.
+type Theme struct {
+	Name string
+
+	// ColorScheme and Background suit a page showing only the output, as
+	// the "color-scheme" and background of its body
+	ColorScheme string
+	Background  string
+}
+
+// Themes are the built-in themes, the first being the default, which is
+// terminal.css as it is.
+var Themes = []Theme{
+	{Name: "dark", ColorScheme: "dark", Background: "#171717"},
+	{Name: "light", ColorScheme: "light", Background: "#ffffff"},
+	{Name: "solarized-dark", ColorScheme: "dark", Background: "#002b36"},
+	{Name: "dracula", ColorScheme: "dark", Background: "#282a36"},
+	{Name: "colorblind", ColorScheme: "dark", Background: "#171717"},
+	{Name: "colorblind-light", ColorScheme: "light", Background: "#ffffff"},
+	{Name: "high-contrast", ColorScheme: "dark", Background: "#000000"},
+}
+
+// FindTheme returns the built-in theme with the name.
+func FindTheme(name string) (Theme, bool) {
+	for _, theme := range Themes {
+		if theme.Name == name {
+			return theme, true
+		}
+	}
+	return Theme{}, false
+}
+
 func TerminalCSS() ([]byte, error) {
-	f, err := fs.Open("terminal.css")
+	return readFile("terminal.css")
+}
+
+// ThemeCSS returns the stylesheet with the theme's colors.
+func ThemeCSS(theme Theme) ([]byte, error) {
+	css, err := TerminalCSS()
+	if err != nil || theme.Name == Themes[0].Name {
+		return css, err
+	}
+	colors, err := readFile("themes/" + theme.Name + ".css")
+	if err != nil {
+		return nil, err
+	}
+	return append(append(css, '\n'), colors...), nil
+}
+
+func readFile(name string) ([]byte, error) {
+	f, err := fs.Open(name)
 	if err != nil {
 		return nil, fmt.Errorf("%w", err)
 	}
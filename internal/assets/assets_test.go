@@ -0,0 +1,55 @@
+package assets
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+// textColorRegexp matches the rules setting the color of a term-fg* class.
+var textColorRegexp = regexp.MustCompile(`(?m)^\.(term-fg[ix]?\d+) \{ color: #([0-9a-fA-F]{6}); \}`)
+
+func TestHighContrastTheme(t *testing.T) {
+	theme, ok := FindTheme("high-contrast")
+	if !ok {
+		t.Fatal(`FindTheme("high-contrast") not found`)
+	}
+	css, err := ThemeCSS(theme)
+	if err != nil {
+		t.Fatalf("ThemeCSS(%q) error = %v", theme.Name, err)
+	}
+
+	// The theme's rules come after terminal.css's, so the last color of each
+	// class is the one shown
+	colors := map[string]string{}
+	for _, m := range textColorRegexp.FindAllSubmatch(css, -1) {
+		colors[string(m[1])] = string(m[2])
+	}
+	background := relativeLuminance(theme.Background[1:])
+	for class, color := range colors {
+		if ratio := contrastRatio(relativeLuminance(color), background); ratio < 4.5 {
+			t.Errorf("contrast of .%s #%s on %s = %.2f, want at least 4.5", class, color, theme.Background, ratio)
+		}
+	}
+}
+
+// relativeLuminance returns the WCAG relative luminance of a color given as
+// six hex digits.
+func relativeLuminance(hex string) float64 {
+	var rgb [3]float64
+	for i := range rgb {
+		n, _ := strconv.ParseUint(hex[2*i:2*i+2], 16, 8)
+		c := float64(n) / 255
+		if c <= 0.03928 {
+			rgb[i] = c / 12.92
+		} else {
+			rgb[i] = math.Pow((c+0.055)/1.055, 2.4)
+		}
+	}
+	return 0.2126*rgb[0] + 0.7152*rgb[1] + 0.0722*rgb[2]
+}
+
+func contrastRatio(a, b float64) float64 {
+	return (max(a, b) + 0.05) / (min(a, b) + 0.05)
+}
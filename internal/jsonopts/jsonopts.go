@@ -0,0 +1,197 @@
+// Package jsonopts parses terminal.Options from JSON, for the WebAssembly and
+// C builds, whose callers can't construct Go values.
+package jsonopts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/buildkite/terminal-to-html/v3"
+)
+
+// options are the fields of terminal.Options that can be given as data, named
+// in camelCase. The enums are named as strings, e.g. "gutter", and Search and
+// the patterns of LineClasses are regular expressions. InputEncoding is the
+// name of an encoding, or "auto" to detect it. Of the Go functions, Redact is
+// given as the strings to redact, redactStrings, but LineFilter, Highlighter
+// and PathLinker aren't available. SourceMap, LineCache and Trace are left
+// out too, as the callers only get the HTML.
+type options struct {
+	MaxInlineImageBytes        int               `json:"maxInlineImageBytes"`
+	MaxTotalInlineImageBytes   int               `json:"maxTotalInlineImageBytes"`
+	MaxLines                   int               `json:"maxLines"`
+	Width                      int               `json:"width"`
+	Height                     int               `json:"height"`
+	LegacyEraseDisplay         bool              `json:"legacyEraseDisplay"`
+	TmuxCapture                bool              `json:"tmuxCapture"`
+	ConPTY                     bool              `json:"conpty"`
+	TruncateColumns            int               `json:"truncateColumns"`
+	ImageProxyURL              string            `json:"imageProxyURL"`
+	AllowedURLSchemes          []string          `json:"allowedURLSchemes"`
+	LinkAttributes             map[string]string `json:"linkAttributes"`
+	CSPSafe                    bool              `json:"cspSafe"`
+	CollapseRepeatedLines      bool              `json:"collapseRepeatedLines"`
+	ProgressFrames             int               `json:"progressFrames"`
+	Accessible                 bool              `json:"accessible"`
+	SemanticElements           bool              `json:"semanticElements"`
+	BreakElements              bool              `json:"breakElements"`
+	Linkify                    bool              `json:"linkify"`
+	APCNamespaces              []string          `json:"apcNamespaces"`
+	TimestampFormat            string            `json:"timestampFormat"`
+	OmitProcessingInstructions bool              `json:"omitProcessingInstructions"`
+	UnknownAPCComments         bool              `json:"unknownAPCComments"`
+	BuildkiteGroups            bool              `json:"buildkiteGroups"`
+	GitHubActionsGroups        bool              `json:"githubActionsGroups"`
+	GitLabSections             bool              `json:"gitlabSections"`
+	TravisFolds                bool              `json:"travisFolds"`
+	TitleHeaders               bool              `json:"titleHeaders"`
+	GitHubActionsAnnotations   bool              `json:"githubActionsAnnotations"`
+	Search                     string            `json:"search"`
+	LineNumbers                string            `json:"lineNumbers"`
+	ElapsedTime                bool              `json:"elapsedTime"`
+	TimestampNormalization     string            `json:"timestampNormalization"`
+	InputEncoding              string            `json:"inputEncoding"`
+	Attachments                string            `json:"attachments"`
+	EagerImages                bool              `json:"eagerImages"`
+	HighlightLines             []lineRange       `json:"highlightLines"`
+	LineClasses                []lineClass       `json:"lineClasses"`
+	InsertHTML                 map[int]string    `json:"insertHTML"`
+	RedactStrings              []string          `json:"redactStrings"`
+}
+
+// lineRange is a terminal.LineRange, e.g. {"start": 3, "end": 5}.
+type lineRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// lineClass is a terminal.LineClass, e.g. {"pattern": "error", "class": "err"}.
+type lineClass struct {
+	Pattern string `json:"pattern"`
+	Class   string `json:"class"`
+}
+
+// Parse parses options from a JSON object, e.g.
+// {"lineNumbers": "gutter", "buildkiteGroups": true, "search": "error"}.
+// Empty input gives the zero Options.
+func Parse(data []byte) (terminal.Options, error) {
+	var o options
+	if len(bytes.TrimSpace(data)) > 0 {
+		d := json.NewDecoder(bytes.NewReader(data))
+		d.DisallowUnknownFields()
+		if err := d.Decode(&o); err != nil {
+			return terminal.Options{}, fmt.Errorf("invalid options: %w", err)
+		}
+	}
+
+	opts := terminal.Options{
+		MaxInlineImageBytes:        o.MaxInlineImageBytes,
+		MaxTotalInlineImageBytes:   o.MaxTotalInlineImageBytes,
+		MaxLines:                   o.MaxLines,
+		Width:                      o.Width,
+		Height:                     o.Height,
+		LegacyEraseDisplay:         o.LegacyEraseDisplay,
+		TmuxCapture:                o.TmuxCapture,
+		ConPTY:                     o.ConPTY,
+		TruncateColumns:            o.TruncateColumns,
+		ImageProxyURL:              o.ImageProxyURL,
+		AllowedURLSchemes:          o.AllowedURLSchemes,
+		LinkAttributes:             o.LinkAttributes,
+		CSPSafe:                    o.CSPSafe,
+		CollapseRepeatedLines:      o.CollapseRepeatedLines,
+		ProgressFrames:             o.ProgressFrames,
+		Accessible:                 o.Accessible,
+		SemanticElements:           o.SemanticElements,
+		BreakElements:              o.BreakElements,
+		Linkify:                    o.Linkify,
+		APCNamespaces:              o.APCNamespaces,
+		OmitProcessingInstructions: o.OmitProcessingInstructions,
+		UnknownAPCComments:         o.UnknownAPCComments,
+		BuildkiteGroups:            o.BuildkiteGroups,
+		GitHubActionsGroups:        o.GitHubActionsGroups,
+		GitLabSections:             o.GitLabSections,
+		TravisFolds:                o.TravisFolds,
+		TitleHeaders:               o.TitleHeaders,
+		EagerImages:                o.EagerImages,
+		GitHubActionsAnnotations:   o.GitHubActionsAnnotations,
+		ElapsedTime:                o.ElapsedTime,
+		InsertHTML:                 o.InsertHTML,
+	}
+
+	switch o.LineNumbers {
+	case "":
+	case "gutter":
+		opts.LineNumbers = terminal.LineNumberGutter
+	case "data-attribute":
+		opts.LineNumbers = terminal.LineNumberDataAttribute
+	default:
+		return opts, fmt.Errorf("unknown lineNumbers %q, expected gutter or data-attribute", o.LineNumbers)
+	}
+
+	switch o.TimestampFormat {
+	case "", "processing-instruction":
+	case "time-element":
+		opts.TimestampFormat = terminal.TimestampTimeElement
+	case "data-attribute":
+		opts.TimestampFormat = terminal.TimestampDataAttribute
+	default:
+		return opts, fmt.Errorf("unknown timestampFormat %q, expected processing-instruction, time-element or data-attribute", o.TimestampFormat)
+	}
+
+	switch o.TimestampNormalization {
+	case "":
+	case "clamp":
+		opts.TimestampNormalization = terminal.TimestampsClamped
+	case "offset":
+		opts.TimestampNormalization = terminal.TimestampsOffset
+	default:
+		return opts, fmt.Errorf("unknown timestampNormalization %q, expected clamp or offset", o.TimestampNormalization)
+	}
+
+	switch o.Attachments {
+	case "":
+	case "collect":
+		opts.Attachments = terminal.AttachmentsCollected
+	case "list":
+		opts.Attachments = terminal.AttachmentsListed
+	default:
+		return opts, fmt.Errorf("unknown attachments %q, expected collect or list", o.Attachments)
+	}
+
+	switch o.InputEncoding {
+	case "":
+	case "auto":
+		opts.DetectInputEncoding = true
+	default:
+		enc, err := terminal.LookupInputEncoding(o.InputEncoding)
+		if err != nil {
+			return opts, fmt.Errorf("invalid inputEncoding: %w", err)
+		}
+		opts.InputEncoding = enc
+	}
+
+	for _, r := range o.HighlightLines {
+		opts.HighlightLines = append(opts.HighlightLines, terminal.LineRange{Start: r.Start, End: r.End})
+	}
+	for _, lc := range o.LineClasses {
+		re, err := regexp.Compile(lc.Pattern)
+		if err != nil {
+			return opts, fmt.Errorf("invalid lineClasses pattern: %w", err)
+		}
+		opts.LineClasses = append(opts.LineClasses, terminal.LineClass{Pattern: re, Class: lc.Class})
+	}
+	if len(o.RedactStrings) > 0 {
+		opts.Redact = terminal.RedactStrings(o.RedactStrings...)
+	}
+
+	if o.Search != "" {
+		re, err := regexp.Compile(o.Search)
+		if err != nil {
+			return opts, fmt.Errorf("invalid search: %w", err)
+		}
+		opts.Search = re
+	}
+	return opts, nil
+}
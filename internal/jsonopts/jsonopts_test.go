@@ -0,0 +1,61 @@
+package jsonopts
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/buildkite/terminal-to-html/v3"
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestParse(t *testing.T) {
+	input := []byte("\x1b]2;Build\a\x1b[31merror\x1b[0m\n--- group\n\x1b_ci;job=1\x07\x1b_bk;t=2000\x07skewed\n\x1b_bk;t=1000\x07done\x1b]1337;File=name=anVuaXQueG1s:PHRlc3RzdWl0ZS8+\a\x1b]1338;url=a.png\a")
+	testCases := []struct {
+		json string
+		opts terminal.Options
+	}{
+		{json: ``, opts: terminal.Options{}},
+		{json: `{}`, opts: terminal.Options{}},
+		{
+			json: `{"buildkiteGroups": true, "lineNumbers": "data-attribute", "linkAttributes": {"target": "_blank"}}`,
+			opts: terminal.Options{BuildkiteGroups: true, LineNumbers: terminal.LineNumberDataAttribute, LinkAttributes: map[string]string{"target": "_blank"}},
+		},
+		{json: `{"elapsedTime": true, "timestampNormalization": "offset"}`, opts: terminal.Options{ElapsedTime: true, TimestampNormalization: terminal.TimestampsOffset}},
+		{json: `{"eagerImages": true}`, opts: terminal.Options{EagerImages: true}},
+		{json: `{"attachments": "list"}`, opts: terminal.Options{Attachments: terminal.AttachmentsListed}},
+		{json: `{"unknownAPCComments": true}`, opts: terminal.Options{UnknownAPCComments: true}},
+		{json: `{"titleHeaders": true}`, opts: terminal.Options{TitleHeaders: true}},
+		{json: `{"inputEncoding": "auto"}`, opts: terminal.Options{DetectInputEncoding: true}},
+		{json: `{"inputEncoding": "latin1"}`, opts: terminal.Options{InputEncoding: charmap.Windows1252}},
+		{json: `{"highlightLines": [{"start": 2, "end": 3}]}`, opts: terminal.Options{HighlightLines: []terminal.LineRange{{Start: 2, End: 3}}}},
+		{
+			json: `{"lineClasses": [{"pattern": "^err", "class": "term-error"}]}`,
+			opts: terminal.Options{LineClasses: []terminal.LineClass{{Pattern: regexp.MustCompile("^err"), Class: "term-error"}}},
+		},
+		{json: `{"insertHTML": {"0": "<div>banner</div>", "2": "<hr>"}}`, opts: terminal.Options{InsertHTML: map[int]string{0: "<div>banner</div>", 2: "<hr>"}}},
+		{json: `{"redactStrings": ["Build", "skewed"]}`, opts: terminal.Options{Redact: terminal.RedactStrings("Build", "skewed")}},
+	}
+	for _, tc := range testCases {
+		opts, err := Parse([]byte(tc.json))
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tc.json, err)
+			continue
+		}
+		// Compare how the options render, as they can contain a regexp
+		got, want := terminal.RenderWithOptions(input, opts), terminal.RenderWithOptions(input, tc.opts)
+		if string(got) != string(want) {
+			t.Errorf("Parse(%q) renders %q, want %q", tc.json, got, want)
+		}
+	}
+
+	opts, err := Parse([]byte(`{"search": "err(or)?"}`))
+	if err != nil || opts.Search == nil || opts.Search.String() != "err(or)?" {
+		t.Errorf(`Parse({"search": "err(or)?"}) = %v, %v, want Search err(or)?`, opts.Search, err)
+	}
+
+	for _, json := range []string{`{"lineNumbers": "left"}`, `{"timestampFormat": "iso"}`, `{"search": "("}`, `{"inputEncoding": "ebcdic"}`, `{"timestampNormalization": "sort"}`, `{"attachments": "keep"}`, `{"lineClasses": [{"pattern": "("}]}`, `{"insertHTML": {"first": ""}}`, `{"bogus": 1}`, `[]`} {
+		if _, err := Parse([]byte(json)); err == nil {
+			t.Errorf("Parse(%q) error = nil, want an error", json)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package terminal
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"sort"
+	"sync"
+	"unicode/utf8"
+)
+
+// A LineCache keeps the HTML of lines it has rendered, keyed by a hash of
+// their content, so that rendering a log again, e.g. each time a UI polls one
+// that's still being written, only renders the lines that have changed. Set
+// Options.LineCache to use one, and use it with only one set of options, as
+// the options aren't part of the key. It's safe for concurrent use.
+type LineCache struct {
+	mu   sync.Mutex
+	seed maphash.Seed
+	size int
+
+	// Lines used since current was started, and in the generation before,
+	// which are dropped once current is full
+	current, previous map[uint64][]byte
+
+	hits, misses int
+}
+
+// NewLineCache returns a LineCache that keeps at least the size most
+// recently rendered lines, and at most twice that many.
+func NewLineCache(size int) *LineCache {
+	return &LineCache{
+		seed:    maphash.MakeSeed(),
+		size:    size,
+		current: make(map[uint64][]byte),
+	}
+}
+
+// Stats returns the number of lines rendered from the cache, and the number
+// that weren't in it.
+func (c *LineCache) Stats() (hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+func (c *LineCache) get(key uint64) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	html, ok := c.current[key]
+	if !ok {
+		if html, ok = c.previous[key]; ok {
+			c.add(key, html)
+		}
+	}
+	if ok {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	return html, ok
+}
+
+func (c *LineCache) put(key uint64, html []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.add(key, html)
+}
+
+func (c *LineCache) add(key uint64, html []byte) {
+	if len(c.current) >= c.size {
+		c.previous, c.current = c.current, make(map[uint64][]byte, c.size)
+	}
+	c.current[key] = html
+}
+
+// key hashes everything the HTML of a line, other than its wrapper element,
+// depends on, given the options: its nodes, its metadata, any decorations
+// from other lines, and its number if that's rendered (or -1 otherwise). The
+// line is encoded into scratch to be hashed in one go, and scratch returned
+// for reuse.
+func (c *LineCache) key(scratch []byte, line screenLine, number int, extra []decoration) (uint64, []byte) {
+	b := binary.LittleEndian.AppendUint64(scratch[:0], uint64(number))
+
+	// Styles are only written where they change, followed by the characters
+	// in them, so a line in one style is little more than its text
+	var last *style
+	for i, n := range line.nodes {
+		if i == 0 || !n.style.isEqual(last) {
+			last = n.style
+			b = append(b, 0, boolByte(last != nil))
+			if s := last; s != nil {
+				b = append(b, s.fgColor, s.bgColor)
+				for _, set := range [...]bool{s.fgColorX, s.bgColorX, s.bold, s.faint, s.italic, s.underline, s.strike, s.blink} {
+					b = append(b, boolByte(set))
+				}
+			}
+		}
+		if e := n.elem; e != nil {
+			b = append(b, 0, 2)
+			for _, s := range [...]string{e.url, e.alt, e.contentType, e.content, e.height, e.width} {
+				b = appendHashString(b, s)
+			}
+			b = binary.LittleEndian.AppendUint64(b, uint64(e.elementType))
+		} else if n.blob == 0 {
+			// Distinct from the markers above
+			b = append(b, 0, 3)
+		} else {
+			b = utf8.AppendRune(b, n.blob)
+		}
+	}
+	b = append(b, 0, 4)
+
+	// Map order is random, so the metadata is written in sorted order
+	namespaces := make([]string, 0, len(line.metadata))
+	for namespace := range line.metadata {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	for _, namespace := range namespaces {
+		data := line.metadata[namespace]
+		b = appendHashString(b, namespace)
+		b = binary.LittleEndian.AppendUint64(b, uint64(len(data)))
+		keys := make([]string, 0, len(data))
+		for k := range data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			b = appendHashString(b, k)
+			b = appendHashString(b, data[k])
+		}
+	}
+
+	b = binary.LittleEndian.AppendUint64(b, uint64(len(line.apcs)))
+	for _, payload := range line.apcs {
+		b = appendHashString(b, payload)
+	}
+	if l := line.label; l != nil {
+		b = appendHashString(b, l.text)
+		b = binary.LittleEndian.AppendUint64(b, uint64(l.color))
+	}
+
+	for _, d := range extra {
+		b = binary.LittleEndian.AppendUint64(b, uint64(d.start))
+		b = binary.LittleEndian.AppendUint64(b, uint64(d.end))
+		b = appendHashString(b, d.open)
+		b = appendHashString(b, d.close)
+		b = append(b, boolByte(d.weak))
+	}
+	return maphash.Bytes(c.seed, b), b
+}
+
+// appendHashString appends s prefixed with its length, so that adjacent
+// strings can't run into each other.
+func appendHashString(b []byte, s string) []byte {
+	b = binary.LittleEndian.AppendUint64(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
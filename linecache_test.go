@@ -0,0 +1,71 @@
+package terminal
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+)
+
+func TestLineCacheMatchesUncached(t *testing.T) {
+	fixtures := []string{"control.sh", "curl.sh", "homer.sh", "docker-pull.sh", "pikachu.sh", "npm.sh", "rustfmt.sh", "weather.sh"}
+	optionSets := []Options{
+		{},
+		{LineNumbers: LineNumberGutter, Search: regexp.MustCompile(`e`)},
+		{ElapsedTime: true, LineNumbers: LineNumberDataAttribute, BuildkiteGroups: true},
+	}
+	for _, fixture := range fixtures {
+		raw := loadFixture(t, fixture, "raw")
+		for _, opts := range optionSets {
+			want := RenderWithOptions(raw, opts)
+			opts.LineCache = NewLineCache(100)
+			// Rendered twice, to render from the cache the second time
+			for i := 0; i < 2; i++ {
+				if got := RenderWithOptions(raw, opts); !bytes.Equal(got, want) {
+					t.Errorf("%s: render %d with cache differs from without\ngot:  %q\nwant: %q", fixture, i+1, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestLineCacheRendersOnlyChangedLines(t *testing.T) {
+	cache := NewLineCache(100)
+	opts := Options{LineCache: cache}
+
+	RenderWithOptions([]byte("one\n\x1b[31mtwo\x1b[0m\nthree"), opts)
+	if hits, misses := cache.Stats(); hits != 0 || misses != 3 {
+		t.Errorf("first render: hits, misses = %d, %d, want 0, 3", hits, misses)
+	}
+
+	// A different style or metadata is a change, even with the same text
+	got := RenderWithOptions([]byte("one\ntwo\n\x1b_bk;t=123\x07three\nfour"), opts)
+	if hits, misses := cache.Stats(); hits != 1 || misses != 6 {
+		t.Errorf("second render: hits, misses = %d, %d, want 1, 6", hits, misses)
+	}
+	if want := []byte("one\ntwo\n<?bk t=\"123\"?>three\nfour"); !bytes.Equal(got, want) {
+		t.Errorf("second render = %q, want %q", got, want)
+	}
+}
+
+func TestLineCacheEviction(t *testing.T) {
+	cache := NewLineCache(2)
+	for _, key := range []uint64{1, 2, 3, 4, 5} {
+		cache.put(key, []byte{byte(key)})
+	}
+	// 5 is in the current generation, and 3 and 4 in the previous one
+	for key, want := range map[uint64]bool{1: false, 2: false, 3: true, 4: true, 5: true} {
+		if _, ok := cache.get(key); ok != want {
+			t.Errorf("get(%d) found = %t, want %t", key, ok, want)
+		}
+	}
+}
+
+func BenchmarkRendererNpmLineCache(b *testing.B) {
+	raw := loadFixture(b, "npm.sh", "raw")
+	opts := Options{LineCache: NewLineCache(10000)}
+	RenderWithOptions(raw, opts)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = RenderWithOptions(raw, opts)
+	}
+}
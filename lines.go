@@ -0,0 +1,114 @@
+package terminal
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+)
+
+// An HTMLLine is one line of the screen rendered as HTML, for frontends that
+// show lines individually, such as virtual scrolling lists.
+type HTMLLine struct {
+	// HTML is the line as AsHTML renders it, without the newline after it.
+	// Every element opened in it is closed in it. The header of a group holds
+	// the group's summary, without the <details> and <summary> elements.
+	HTML template.HTML `json:"html"`
+
+	// Number is the line's number, counting from 1.
+	Number int `json:"number"`
+
+	// Metadata is the line's metadata by namespace, e.g. its Buildkite
+	// timestamp is Metadata["bk"]["t"].
+	Metadata map[string]map[string]string `json:"metadata,omitempty"`
+
+	// Group is the number of the header line of the innermost group the line
+	// is in, or 0 if it isn't in one.
+	Group int `json:"group,omitempty"`
+
+	// Header is set for the header line of a group, and Open for the header of
+	// a group that starts expanded.
+	Header bool `json:"header,omitempty"`
+	Open   bool `json:"open,omitempty"`
+}
+
+// HTMLLines renders each line of the screen as HTML, with the same options as
+// AsHTML, so that a frontend doesn't need to split the HTML of the whole
+// screen into lines itself. Lines that AsHTML leaves out, such as ones
+// dropped by LineFilter or collapsed as repeats, are left out, and
+// InsertHTML isn't inserted.
+func (s *Screen) HTMLLines() []HTMLLine {
+	r := htmlRenderer{opts: &s.opts}
+	return r.htmlLines(s.screen)
+}
+
+// htmlLines renders lines one by one, as render does all together.
+func (r *htmlRenderer) htmlLines(lines []screenLine) []HTMLLine {
+	lines, dropped := r.filterLines(lines)
+	layout := findSections(lines, r.opts)
+	for i := range dropped {
+		layout.hidden[i] = true
+	}
+	if r.opts.CollapseRepeatedLines {
+		r.repeats = layout.collapseRepeats(lines)
+	}
+	r.highlights = codeHighlights(lines, r.opts)
+	sections := layout.sections
+	var groups []int
+	var openSections []section
+
+	var buf bytes.Buffer
+	htmlLines := make([]HTMLLine, 0, len(lines))
+	for i, line := range lines {
+		if layout.hidden[i] {
+			for len(sections) > 0 && sections[0].start == i {
+				sections = sections[1:]
+			}
+			continue
+		}
+		for len(openSections) > 0 && openSections[len(openSections)-1].end <= i {
+			openSections = openSections[:len(openSections)-1]
+			groups = groups[:len(groups)-1]
+		}
+
+		number := r.offset + i + 1
+		htmlLine := HTMLLine{Number: number, Metadata: line.metadata}
+		if len(groups) > 0 {
+			htmlLine.Group = groups[len(groups)-1]
+		}
+		buf.Reset()
+
+		if len(sections) > 0 && sections[0].start == i {
+			section := sections[0]
+			sections = sections[1:]
+			openSections = append(openSections, section)
+			groups = append(groups, number)
+
+			r.writeLine(&buf, i, section.title)
+			if section.hasDuration {
+				fmt.Fprintf(&buf, `<span class="term-group-duration">%s</span>`, formatSectionDuration(section.duration))
+			}
+			htmlLine.Header, htmlLine.Open = true, section.open
+		} else if a, message, ok := r.annotation(line); ok {
+			r.writeLine(&buf, i, message, a.decoration(len(message.nodes)))
+		} else {
+			r.writeLine(&buf, i, line, r.highlights[i]...)
+		}
+		// Blank lines are filled as AsHTML fills them
+		if buf.Len() == 0 && !r.opts.BreakElements {
+			buf.WriteString("&nbsp;")
+		}
+
+		htmlLine.HTML = template.HTML(buf.String())
+		htmlLines = append(htmlLines, htmlLine)
+	}
+	return htmlLines
+}
+
+// annotation parses the line's annotation and its message, if annotations
+// are enabled.
+func (r *htmlRenderer) annotation(line screenLine) (Annotation, screenLine, bool) {
+	if !r.opts.GitHubActionsAnnotations {
+		return Annotation{}, screenLine{}, false
+	}
+	return parseAnnotationMessage(line)
+}
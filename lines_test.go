@@ -0,0 +1,60 @@
+package terminal
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRenderLines(t *testing.T) {
+	input := []byte("\x1b[31mred\nstill red\x1b[0m\n\n\x1b_bk;t=1700000000000\x07timed\n--- group\nin group\n+++ open\ndone")
+	got := RenderLines(input, Options{BuildkiteGroups: true})
+	want := []HTMLLine{
+		{HTML: `<span class="term-fg31">red</span>`, Number: 1},
+		{HTML: `<span class="term-fg31">still red</span>`, Number: 2},
+		{HTML: `&nbsp;`, Number: 3},
+		{HTML: `<?bk t="1700000000000"?>timed`, Number: 4, Metadata: map[string]map[string]string{"bk": {"t": "1700000000000"}}},
+		{HTML: `group`, Number: 5, Header: true},
+		{HTML: `in group`, Number: 6, Group: 5},
+		{HTML: `open`, Number: 7, Header: true, Open: true},
+		{HTML: `done`, Number: 8, Group: 7},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("RenderLines() diff (-got +want):\n%s", diff)
+	}
+}
+
+func TestRenderLinesMatchesAsHTML(t *testing.T) {
+	// Without groups, the lines joined together are the HTML of the screen
+	input := []byte("\x1b[1;32mgreen\r\n\x1b[4mand underlined\n\n\x1b]8;;https://example.com\x1b\\a\nlink\x1b]8;;\x1b\\\x1b[0m\ngo test ./...\n::warning::\x1b[33mslow\x1b[0m test")
+	for _, opts := range []Options{{}, {LineNumbers: LineNumberGutter}, {TruncateColumns: 6}, {Search: regexp.MustCompile("e")}, {GitHubActionsAnnotations: true}} {
+		var lines []string
+		for _, line := range RenderLines(input, opts) {
+			if err := Validate([]byte(line.HTML)); err != nil {
+				t.Errorf("RenderLines(%+v) line %d isn't valid: %v", opts, line.Number, err)
+			}
+			lines = append(lines, string(line.HTML))
+		}
+		if got, want := strings.Join(lines, "\n"), string(RenderWithOptions(input, opts)); got != want {
+			t.Errorf("RenderLines(%+v) joined = %q, want %q", opts, got, want)
+		}
+	}
+}
+
+func TestSerializeLines(t *testing.T) {
+	output := RenderAll([]byte("a < b\n\x1b[33mwarn"), Options{}, SerializeLines)[0]
+	var got []HTMLLine
+	if err := json.Unmarshal(output, &got); err != nil {
+		t.Fatalf("SerializeLines output %s isn't JSON: %v", output, err)
+	}
+	want := []HTMLLine{
+		{HTML: `a &lt; b`, Number: 1},
+		{HTML: `<span class="term-fg33">warn</span>`, Number: 2},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("SerializeLines diff (-got +want):\n%s", diff)
+	}
+}
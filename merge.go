@@ -0,0 +1,149 @@
+package terminal
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A Stream is one of the logs merged by RenderMerged, such as the output of
+// one of several steps run in parallel.
+type Stream struct {
+	Input []byte
+
+	// Name, if set, labels each line of the stream, as docker compose labels
+	// the output of each container: <span class="term-stream term-fg36">web |</span>
+	Name string
+
+	// Color is the SGR code of the colour of the label, 30-37 or 90-97. If
+	// it's 0, a colour is picked from the name, so that a stream has the
+	// same colour each time.
+	Color int
+}
+
+// streamColors are the colours picked for labels of streams, as in docker
+// compose: cyan, yellow, green, magenta and blue, then their bright versions.
+var streamColors = []int{36, 33, 32, 35, 34, 96, 93, 92, 95, 94}
+
+// A streamLabel is rendered at the start of each line of a named stream.
+type streamLabel struct {
+	text  string
+	color int
+}
+
+// streamLabels returns the label of each named stream, padded to line up.
+func streamLabels(streams []Stream) []*streamLabel {
+	width := 0
+	for _, stream := range streams {
+		width = max(width, utf8.RuneCountInString(stream.Name))
+	}
+	labels := make([]*streamLabel, len(streams))
+	for i, stream := range streams {
+		if stream.Name == "" {
+			continue
+		}
+		color := stream.Color
+		if color == 0 {
+			h := fnv.New32a()
+			h.Write([]byte(stream.Name))
+			color = streamColors[h.Sum32()%uint32(len(streamColors))]
+		}
+		padding := strings.Repeat(" ", width-utf8.RuneCountInString(stream.Name))
+		labels[i] = &streamLabel{text: stream.Name + padding + " |", color: color}
+	}
+	return labels
+}
+
+// RenderMerged converts several streams of ANSI input to a single log of
+// HTML, with their lines interleaved in the order of their Buildkite
+// timestamps. Each stream is parsed on a screen of its own, so that its
+// styles and cursor movement don't affect the others.
+//
+// A line without a timestamp is kept after the line before it in its stream,
+// and lines with the same time are taken from the streams in the order given.
+// The lines of a stream with a Name start with it as a label, which isn't
+// part of the line as far as cursor movement, groups etc. are concerned.
+func RenderMerged(streams []Stream, opts Options) []byte {
+	// Each stream is decoded as a whole, as RenderWithOptions does
+	decode := opts.InputEncoding != nil || opts.DetectInputEncoding
+	decodeOpts := opts
+	opts.InputEncoding, opts.DetectInputEncoding = nil, false
+
+	labels := streamLabels(streams)
+	screens := make([][]screenLine, len(streams))
+	attachments := make([][]Attachment, len(streams))
+	total := 0
+	for i, stream := range streams {
+		input := stream.Input
+		if decode {
+			input = decodeInput(input, decodeOpts)
+		}
+		screen := NewScreen(opts)
+		screen.parseAll(input)
+		for y := range screen.screen {
+			screen.screen[y].label = labels[i]
+		}
+		screens[i] = screen.screen
+		attachments[i] = screen.attachments
+		total += len(screen.screen)
+	}
+
+	merged := NewScreen(opts)
+	var moved [][]int
+	merged.screen, moved = mergeLines(screens, total)
+	// Each stream's attachments are listed at the line they were sent on in
+	// the merged log
+	for i, as := range attachments {
+		for _, a := range as {
+			if a.Line > 0 && a.Line <= len(moved[i]) {
+				a.Line = moved[i][a.Line-1] + 1
+			}
+			merged.attachments = append(merged.attachments, a)
+		}
+	}
+	sort.SliceStable(merged.attachments, func(i, j int) bool {
+		return merged.attachments[i].Line < merged.attachments[j].Line
+	})
+	return merged.AsHTML()
+}
+
+// mergeLines interleaves the lines of each screen by their timestamps,
+// taking the earliest next line of any screen each time. It also returns
+// where each line of each screen ended up.
+func mergeLines(screens [][]screenLine, total int) ([]screenLine, [][]int) {
+	// The time of the latest line taken from each screen, which lines
+	// without a timestamp of their own are taken at
+	times := make([]int64, len(screens))
+	lines := make([]screenLine, 0, total)
+	moved := make([][]int, len(screens))
+	for len(lines) < total {
+		next := -1
+		var nextTime int64
+		for i, screen := range screens {
+			if len(screen) == 0 {
+				continue
+			}
+			t, ok := lineTimestamp(screen[0])
+			if !ok {
+				t = times[i]
+			}
+			if next < 0 || t < nextTime {
+				next, nextTime = i, t
+			}
+		}
+		times[next] = nextTime
+		moved[next] = append(moved[next], len(lines))
+		lines = append(lines, screens[next][0])
+		screens[next] = screens[next][1:]
+	}
+	return lines, moved
+}
+
+// lineTimestamp returns the line's Buildkite timestamp, in milliseconds since
+// the Unix epoch, if it has one.
+func lineTimestamp(line screenLine) (int64, bool) {
+	ms, err := strconv.ParseInt(line.metadata[bkNamespace]["t"], 10, 64)
+	return ms, err == nil
+}
@@ -0,0 +1,95 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestRenderMerged(t *testing.T) {
+	testCases := []struct {
+		name     string
+		streams  []string
+		opts     Options
+		expected string
+	}{{
+		name: "interleaves lines by timestamp",
+		streams: []string{
+			"\x1b_bk;t=1000\x07a1\n\x1b_bk;t=3000\x07a2\n",
+			"\x1b_bk;t=2000\x07b1\n\x1b_bk;t=4000\x07b2",
+		},
+		opts:     Options{OmitProcessingInstructions: true},
+		expected: "a1\nb1\na2\nb2",
+	}, {
+		name: "keeps styles to their own stream",
+		streams: []string{
+			"\x1b_bk;t=1000\x07\x1b[31mred\n\x1b_bk;t=3000\x07still red",
+			"\x1b_bk;t=2000\x07plain\r\x1b[Kcleared",
+		},
+		opts: Options{OmitProcessingInstructions: true},
+		expected: strings.Join([]string{
+			`<span class="term-fg31">red</span>`,
+			`cleared`,
+			`<span class="term-fg31">still red</span>`,
+		}, "\n"),
+	}, {
+		name: "keeps lines without timestamps after the line before",
+		streams: []string{
+			"\x1b_bk;t=1000\x07a1\na2\n\x1b_bk;t=5000\x07a3",
+			"\x1b_bk;t=2000\x07b1\nb2",
+			"untimed",
+		},
+		opts:     Options{OmitProcessingInstructions: true},
+		expected: "untimed\na1\na2\nb1\nb2\na3",
+	}, {
+		name: "takes lines at the same time in stream order",
+		streams: []string{
+			"\x1b_bk;t=1000\x07a",
+			"\x1b_bk;t=1000\x07b",
+		},
+		opts:     Options{TimestampFormat: TimestampDataAttribute},
+		expected: `<span class="term-line" data-timestamp="1000">a</span>` + "\n" + `<span class="term-line" data-timestamp="1000">b</span>`,
+	}, {
+		name:     "decodes each stream",
+		streams:  []string{"\x1b_bk;t=2000\x07caf\xe9", "\x1b_bk;t=1000\x07na\xefve"},
+		opts:     Options{InputEncoding: charmap.Windows1252, OmitProcessingInstructions: true},
+		expected: "naïve\ncafé",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			streams := make([]Stream, len(tc.streams))
+			for i, input := range tc.streams {
+				streams[i] = Stream{Input: []byte(input)}
+			}
+			if got := string(RenderMerged(streams, tc.opts)); got != tc.expected {
+				t.Errorf("RenderMerged() = %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestRenderMergedLabels(t *testing.T) {
+	streams := []Stream{
+		{Name: "web", Color: 36, Input: []byte("\x1b_bk;t=1000\x07--- starting <web>\n\x1b_bk;t=3000\x07\x1b[32mready\x1b[0m")},
+		{Name: "db", Color: 95, Input: []byte("\x1b_bk;t=2000\x0710%\r\x1b[K100%")},
+		{Input: []byte("\x1b_bk;t=4000\x07unlabelled")},
+	}
+	got := string(RenderMerged(streams, Options{BuildkiteGroups: true, OmitProcessingInstructions: true}))
+	want := strings.Join([]string{
+		`<details class="term-group"><summary><span class="term-stream term-fg36">web |</span> starting &lt;web&gt;</summary>` +
+			`<span class="term-stream term-fg95">db  |</span> 100%`,
+		`<span class="term-stream term-fg36">web |</span> <span class="term-fg32">ready</span>`,
+		`unlabelled</details>`,
+	}, "\n")
+	if got != want {
+		t.Errorf("RenderMerged() = %q, want %q", got, want)
+	}
+
+	// Colours are picked from the name, whatever the order of the streams
+	a := streamLabels([]Stream{{Name: "api"}, {Name: "worker"}})
+	b := streamLabels([]Stream{{Name: "worker"}, {Name: "api"}})
+	if a[0].color != b[1].color || a[1].color != b[0].color {
+		t.Errorf("streamLabels() colours differ by order: %v, %v", a, b)
+	}
+}
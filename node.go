@@ -12,6 +12,11 @@ func (n *node) hasSameStyle(o node) bool {
 	return n.style.isEqual(o.style)
 }
 
+// isEqual reports whether two nodes have the same content and style.
+func (n *node) isEqual(o node) bool {
+	return n.blob == o.blob && n.elem == o.elem && n.hasSameStyle(o)
+}
+
 func (n *node) getRune() (rune, bool) {
 	if n.elem != nil {
 		return 0, false
@@ -0,0 +1,533 @@
+package terminal
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/encoding"
+)
+
+// Options control how ANSI input is parsed and rendered. The zero value
+// renders exactly the same as Render.
+type Options struct {
+	// MaxInlineImageBytes caps the decoded size of each iTerm2 (1337) inline
+	// image. Larger images are rendered as a placeholder showing the filename.
+	// Zero means no limit.
+	MaxInlineImageBytes int
+
+	// MaxTotalInlineImageBytes caps the combined decoded size of all inline
+	// images in the input. Once the budget is spent, further images are
+	// rendered as placeholders. Zero means no limit.
+	MaxTotalInlineImageBytes int
+
+	// EagerImages leaves the loading="lazy" and decoding="async" attributes
+	// off images, so that they are loaded and decoded along with the page,
+	// as in earlier versions, rather than when they're scrolled to.
+	EagerImages bool
+
+	// MaxLines caps the number of lines of output, so that input moving the
+	// cursor far down can't use unbounded memory. Once the cursor moves past
+	// the last line, the rest of the input is ignored. Zero means no limit.
+	MaxLines int
+
+	// Width is the width of the terminal the input was recorded in. Text
+	// that reaches the right margin wraps on to the next line, as it would
+	// have in the terminal, and the cursor can't be moved past it. Zero
+	// means lines never wrap.
+	Width int
+
+	// InputEncoding is the encoding of the input, if it isn't UTF-8, e.g.
+	// charmap.Windows1252 from golang.org/x/text/encoding/charmap for logs
+	// from older Windows machines. The input is transcoded to UTF-8 before
+	// it's parsed, so byte offsets, such as those of Screen.SourceMap, are
+	// into the transcoded input. See LookupInputEncoding to find an encoding
+	// by name.
+	InputEncoding encoding.Encoding
+
+	// DetectInputEncoding, if InputEncoding isn't set, guesses the encoding
+	// of the input from the first of it that isn't plain ASCII: UTF-8 or
+	// UTF-16 from a byte order mark, UTF-16 from the zero bytes of mostly
+	// ASCII text, UTF-8 if it's valid, and Windows-1252 otherwise.
+	DetectInputEncoding bool
+
+	// Height is the number of lines of the terminal the input was recorded
+	// in. The last Height lines are its display, and the lines above are
+	// scrollback, so \x1b[2J clears only the display and \x1b[3J only the
+	// scrollback. Zero means there's no scrollback, as the cursor can be
+	// moved to any line, so \x1b[2J clears everything and \x1b[3J nothing.
+	Height int
+
+	// LegacyEraseDisplay makes \x1b[2J and \x1b[3J both clear everything,
+	// display and scrollback, whatever the Height, as in earlier versions.
+	LegacyEraseDisplay bool
+
+	// TmuxCapture renders logs captured with tmux capture-pane -e as tmux
+	// writes them: SGR parameters can have colon-separated subparameters,
+	// e.g. \x1b[4:3m for a curly underline, 24-bit colors such as
+	// \x1b[38;2;255;0;0m are skipped, operating system commands can
+	// end with ST (\x1b\\) as well as BEL, so the text of hyperlinks is
+	// kept, and the octal escapes of capture-pane -C, e.g. \033 for an escape
+	// character, are unescaped. Byte offsets, such as those of
+	// Screen.SourceMap, are into the unescaped input.
+	TmuxCapture bool
+
+	// ConPTY renders output from Windows' pseudo console, which repaints
+	// lines by moving the cursor to them, as a terminal would: \x1b[row;colH
+	// moves it to a row of the display (see Height), \x1b[colG to a column
+	// and \x1b[rowd to a row, and \x1b[nX erases characters. Otherwise
+	// these are ignored or misread, and repainted lines appear again below
+	// the first version of them. Set Height and Width to the size of the
+	// pseudo console.
+	ConPTY bool
+
+	// TruncateColumns, if set, cuts lines longer than this many columns
+	// short, for compact summaries of logs: the first TruncateColumns-1
+	// columns are rendered, followed by "…" in a term-ellipsis span. The
+	// line's wrapper has the class term-truncated, and the full text of the
+	// line in its title attribute. Only HTML output is truncated.
+	TruncateColumns int
+
+	// ImageProxyURL, if set, routes external (1338) images with an absolute
+	// http or https URL through a proxy such as camo. Every "{url}" in the
+	// template is replaced with the query-escaped original URL, e.g.
+	// "https://images.example.com/proxy?url={url}".
+	ImageProxyURL string
+
+	// AllowedURLSchemes restricts the URL schemes permitted in links (1339)
+	// and external images (1338), e.g. []string{"https", "artifact"}.
+	// Relative URLs are always permitted, and javascript: URLs never are.
+	// If empty, any other scheme is permitted.
+	AllowedURLSchemes []string
+
+	// LinkAttributes are extra attributes added to every generated <a>
+	// element, e.g. {"rel": "noopener noreferrer nofollow", "target": "_blank"}.
+	// Attributes are emitted in alphabetical order. Names that aren't valid
+	// attribute names, event handlers such as "onclick", and "href" or
+	// anything else that would replace the link, such as "xlink:href", are
+	// ignored.
+	LinkAttributes map[string]string
+
+	// CSPSafe guarantees the output contains no inline styles and no data:
+	// URIs, so it can be served under a strict Content-Security-Policy.
+	// Inline images are rendered as placeholders, and links and external
+	// images with data: URLs are treated as unsafe.
+	CSPSafe bool
+
+	// Redact, if set, is applied to the input before it is parsed, to hide
+	// secrets such as tokens and passwords. Because it runs before parsing,
+	// the secrets are redacted from text, links, images and metadata alike,
+	// as long as they aren't split by escape sequences or between calls to
+	// Screen.Parse. See RedactStrings and RedactPatterns.
+	Redact func(input string) string
+
+	// LineFilter, if set, is called with each line of the screen once
+	// parsing has finished, and returns whether to keep the line. A kept
+	// line whose replacement differs from its text is replaced by the
+	// replacement, without any styling. Return true, line.Text to keep a line
+	// unchanged. Dropped lines keep their numbers, so later lines are
+	// numbered the same as without the filter.
+	LineFilter func(line PlainLine) (keep bool, replacement string)
+
+	// CollapseRepeatedLines renders runs of identical consecutive lines
+	// (ignoring metadata such as timestamps) as their first line, followed by
+	// <span class="term-repeated">(repeated N times)</span>. Blank lines and
+	// group headers aren't collapsed.
+	CollapseRepeatedLines bool
+
+	// ProgressFrames keeps every Nth version of a line that is overwritten
+	// after a carriage return, as progress bars are, so that it can be played
+	// back. Each line is wrapped in a span, and lines with kept frames get a
+	// data-frames attribute holding a JSON array of Frames, plus a
+	// data-overwrites attribute counting all the overwrites. Zero keeps none,
+	// and one keeps every version. Screen.Frames lists the kept versions.
+	ProgressFrames int
+
+	// InsertHTML is HTML to insert after lines, keyed by line number
+	// (counting from 1, or 0 to insert before the first line), e.g. a banner
+	// saying that a failure matches a known issue. Lines are numbered after
+	// cursor movement has been applied. The HTML is inserted as it is, so it
+	// must be trusted. The next line follows it without a newline, so a block
+	// element such as a <div> is best.
+	InsertHTML map[int]string
+
+	// SourceMap records the byte ranges of the input that wrote each line,
+	// for Screen.SourceMap.
+	SourceMap bool
+
+	// Highlighter, if set, is called with the code in each Markdown-style
+	// fenced block (```go ... ```) or heredoc (cat > main.go <<EOF ... EOF),
+	// and returns the ranges of the code to wrap in spans of the given
+	// classes, in order. The language is the fence's info string, or the
+	// extension of a file named on the heredoc's first line, or "". Any colours
+	// in the code are kept inside the spans, and links take precedence.
+	Highlighter func(language, code string) []HighlightRange
+
+	// Accessible makes the output easier to use with a screen reader. It is
+	// wrapped in <div role="log">, bold and italic text is rendered in
+	// <strong> and <em> elements rather than spans, images are labelled with
+	// aria-label, and blinking is ignored.
+	Accessible bool
+
+	// SemanticElements renders bold, italic and strikethrough text in nested
+	// <strong>, <em> and <del> elements, rather than spans with classes, for
+	// use where classes are removed by a sanitizer. Any other styles are
+	// still rendered as classes on a span inside them.
+	SemanticElements bool
+
+	// BreakElements separates lines with <br> elements rather than newlines,
+	// for use where the output isn't styled with white-space: pre. Blank
+	// lines are left empty rather than filled with &nbsp;.
+	BreakElements bool
+
+	// Linkify turns bare http and https URLs in the text into links. The same
+	// checks are applied as for links from 1339 escape sequences.
+	Linkify bool
+
+	// PathLinker, if set, is called for each path:line or path:line:column
+	// reference in the text (e.g. "src/main.go:42:7"), and returns the URL
+	// to link it to, or "" to leave it unlinked. column is 0 if absent.
+	// Paths must have a file extension to be recognised.
+	PathLinker func(path string, line, column int) string
+
+	// APCNamespaces are extra Application Program Command namespaces to
+	// handle like Buildkite's "bk" namespace, which is always handled.
+	// An APC such as "\x1b_ci;job=123\x07" is parsed as key=value pairs
+	// and emitted as a processing instruction (<?ci job="123"?>) at the start
+	// of its line. Processing instructions are emitted in this order, after bk.
+	APCNamespaces []string
+
+	// TimestampFormat controls how Buildkite timestamps (bk;t=...) are
+	// rendered. By default they are left in the bk processing instruction.
+	TimestampFormat TimestampFormat
+
+	// OmitProcessingInstructions leaves the processing instructions for bk
+	// (and APCNamespaces) metadata out of the output. The APC sequences are
+	// still consumed, and timestamps are still available to TimestampFormat
+	// and ElapsedTime.
+	OmitProcessingInstructions bool
+
+	// UnknownAPCComments keeps Application Program Commands that aren't in
+	// the bk namespace or APCNamespaces, which are otherwise dropped, as an
+	// HTML comment at the start of their line, e.g. <!-- apc: ci;job=123 -->
+	// for \x1b_ci;job=123\x07. Percent signs, hyphens, angle brackets and
+	// control characters in the payload are percent-encoded, so it can't end
+	// the comment early, and url.PathUnescape recovers it.
+	UnknownAPCComments bool
+
+	// BuildkiteGroups wraps each group of output started by a line beginning
+	// with "--- ", "+++ " or "~~~ " in a collapsible <details> element, with
+	// the rest of that line as its <summary>. Groups started with "+++" are
+	// expanded, and a "^^^ +++" line expands the current group.
+	BuildkiteGroups bool
+
+	// GitHubActionsGroups wraps output between ::group::title and ::endgroup::
+	// lines (or ##[group]title and ##[endgroup] in downloaded logs) in a
+	// collapsible <details> element, with the title as its <summary>.
+	GitHubActionsGroups bool
+
+	// GitLabSections wraps output between GitLab's section_start and
+	// section_end markers in a collapsible <details> element, with the
+	// section's header as its <summary> followed by its duration. Sections
+	// are expanded unless started with [collapsed=true].
+	GitLabSections bool
+
+	// TravisFolds wraps output between travis_fold:start:name and
+	// travis_fold:end:name markers in a collapsible <details> element. The
+	// rest of the start marker's line, or the fold's name, is the <summary>.
+	// Folds are collapsed by default.
+	TravisFolds bool
+
+	// TitleHeaders shows each change of the terminal title, as set by tools
+	// that announce their progress in it with \x1b]0;title\a or
+	// \x1b]2;title\a, as a header on a line of its own, in a
+	// <span class="term-title" role="heading">. Titles are otherwise ignored.
+	TitleHeaders bool
+
+	// GitHubActionsAnnotations renders ::error, ::warning and ::notice
+	// workflow commands (or ##[error] etc. in downloaded logs) as their title,
+	// location and message, in an element classed by level (e.g.
+	// term-annotation-error) with the location in data attributes. Use
+	// Screen.Annotations to list them.
+	GitHubActionsAnnotations bool
+
+	// Search highlights every match of the pattern in the text by wrapping it
+	// in <mark class="term-search-match">. To search for a plain string, use
+	// regexp.QuoteMeta. Screen.SearchMatchCount counts the matches.
+	Search *regexp.Regexp
+
+	// LineNumbers numbers each line, counting from 1. Numbers are assigned
+	// after cursor movement has been applied, so they match the output.
+	LineNumbers LineNumberFormat
+
+	// HighlightLines wraps each line in a span, with the term-highlight class
+	// on lines in any of the ranges, e.g. for a permalinked selection.
+	HighlightLines []LineRange
+
+	// LineClasses wraps each line in a span, with the classes of all the
+	// patterns that match the line's text, e.g. to highlight errors with CSS.
+	// See DefaultLineClasses.
+	LineClasses []LineClass
+
+	// ElapsedTime wraps each line in a span with data-elapsed-ms and
+	// data-delta-ms attributes, holding the time since the first timestamped
+	// line and since the previous timestamped line respectively. Lines
+	// without a (numeric) Buildkite timestamp don't get these attributes.
+	ElapsedTime bool
+
+	// TimestampNormalization, if set, changes Buildkite timestamps that go
+	// backwards, e.g. because of clock skew on the agent or output from
+	// several producers, so that they never decrease and elapsed times are
+	// never negative. It applies to timestamps wherever they are rendered.
+	TimestampNormalization TimestampNormalization
+
+	// Attachments controls what happens to files sent with iTerm2's
+	// 1337;File sequence that aren't shown as images, because they don't
+	// have inline=1 or aren't images, e.g. JUnit XML or coverage reports. By
+	// default they are dropped, or an inline one is rendered as an image.
+	Attachments AttachmentMode
+
+	// LineCache, if set, reuses the HTML of lines it has already rendered
+	// with the same content, so that rendering a log again after more output
+	// is added only renders what's new. Use each cache with only one set of
+	// options.
+	LineCache *LineCache
+
+	// Trace, if set, is written a line for each escape sequence parsed, and
+	// each newline, carriage return and backspace, giving its byte offset in
+	// the input, its kind (CSI, OSC, APC, ESC or C0), the sequence quoted as
+	// a Go string, the parameters of a control sequence, and the action
+	// taken, separated by tabs, e.g. for working out why a log renders
+	// oddly. Errors writing to it are ignored.
+	Trace io.Writer
+}
+
+// LineNumberFormat is a way of rendering line numbers.
+type LineNumberFormat int
+
+const (
+	// NoLineNumbers leaves line numbers out of the output.
+	NoLineNumbers LineNumberFormat = iota
+
+	// LineNumberGutter starts each line with an empty
+	// <span class="term-line-number" data-line-number="1"></span> element,
+	// to be displayed with CSS so that the number can't be selected.
+	LineNumberGutter
+
+	// LineNumberDataAttribute wraps each line in a span with the line number
+	// in a data-line-number attribute.
+	LineNumberDataAttribute
+)
+
+// PlainLine is a line of the screen as plain text, as passed to
+// Options.LineFilter.
+type PlainLine struct {
+	// Number is the line's number, counting from 1.
+	Number int
+
+	// Text is the line's text, without styling. Elements such as images are
+	// represented by U+FFFC OBJECT REPLACEMENT CHARACTER.
+	Text string
+}
+
+// LineRange is a range of lines, numbered from 1, from Start to End
+// inclusive.
+type LineRange struct {
+	Start, End int
+}
+
+// isHighlighted reports whether line number n is in any of HighlightLines.
+func (o *Options) isHighlighted(n int) bool {
+	for _, r := range o.HighlightLines {
+		if r.Start <= n && n <= r.End {
+			return true
+		}
+	}
+	return false
+}
+
+// LineClass is a CSS class for lines matching a pattern.
+type LineClass struct {
+	Pattern *regexp.Regexp
+	Class   string
+}
+
+// DefaultLineClasses classify lines that look like errors or warnings as
+// term-error or term-warning.
+var DefaultLineClasses = []LineClass{
+	{regexp.MustCompile(`(?i)\berror:`), "term-error"},
+	{regexp.MustCompile(`\bFAIL(ED)?\b`), "term-error"},
+	{regexp.MustCompile(`^panic:`), "term-error"},
+	{regexp.MustCompile(`(?i)\bwarning:`), "term-warning"},
+}
+
+// wrapsLines reports whether each line needs a wrapper element to carry
+// per-line classes or attributes.
+func (o *Options) wrapsLines() bool {
+	return o.TimestampFormat == TimestampDataAttribute || o.ElapsedTime || o.TruncateColumns > 0 ||
+		o.LineNumbers == LineNumberDataAttribute || o.ProgressFrames > 0 ||
+		len(o.LineClasses) > 0 || len(o.HighlightLines) > 0
+}
+
+// spansLines reports whether rendering with the options looks at more than
+// one line at a time, e.g. to find collapsible groups, so can't be done a few
+// lines at a time.
+func (o *Options) spansLines() bool {
+	return o.Redact != nil || o.LineFilter != nil || o.CollapseRepeatedLines ||
+		len(o.InsertHTML) > 0 || o.Highlighter != nil || o.Accessible ||
+		o.BuildkiteGroups || o.GitHubActionsGroups || o.GitLabSections ||
+		o.TravisFolds || o.Attachments == AttachmentsListed
+}
+
+// isZero reports whether none of the options are set, so that rendering is
+// the same as Render.
+func (o *Options) isZero() bool {
+	return reflect.ValueOf(*o).IsZero()
+}
+
+// lineClasses returns the LineClasses matching a line's text, without
+// duplicates.
+func (o *Options) lineClasses(text string) []string {
+	if len(o.LineClasses) == 0 {
+		return nil
+	}
+	var classes []string
+	for _, lc := range o.LineClasses {
+		if !lc.Pattern.MatchString(text) {
+			continue
+		}
+		duplicate := false
+		for _, class := range classes {
+			duplicate = duplicate || class == lc.Class
+		}
+		if !duplicate {
+			classes = append(classes, lc.Class)
+		}
+	}
+	return classes
+}
+
+// truncation returns how many columns of a line to render for
+// TruncateColumns, leaving one for the ellipsis, and whether the line needs
+// truncating. Blanks at the end of the line, which aren't rendered, don't
+// count.
+func (o *Options) truncation(line screenLine) (int, bool) {
+	if o.TruncateColumns <= 0 {
+		return 0, false
+	}
+	n := len(line.nodes)
+	for n > 0 && line.nodes[n-1].elem == nil && line.nodes[n-1].blob == ' ' {
+		n--
+	}
+	return o.TruncateColumns - 1, n > o.TruncateColumns
+}
+
+// TimestampFormat is a way of rendering the Buildkite timestamp of a line.
+type TimestampFormat int
+
+const (
+	// TimestampProcessingInstruction leaves the timestamp in the line's bk
+	// processing instruction: <?bk t="1684881360000"?>
+	TimestampProcessingInstruction TimestampFormat = iota
+
+	// TimestampTimeElement renders the timestamp as an empty <time> element
+	// at the start of the line:
+	// <time datetime="2023-05-23T22:36:00.000Z"></time>
+	TimestampTimeElement
+
+	// TimestampDataAttribute wraps each line in a span with the timestamp in
+	// a data attribute: <span class="term-line" data-timestamp="1684881360000">
+	TimestampDataAttribute
+)
+
+// TimestampNormalization is a way of correcting Buildkite timestamps that go
+// backwards.
+type TimestampNormalization int
+
+const (
+	// TimestampsAsIs leaves timestamps as they are.
+	TimestampsAsIs TimestampNormalization = iota
+
+	// TimestampsClamped raises a timestamp earlier than the one before it to
+	// match it, so that the lines between the two show no time passing.
+	TimestampsClamped
+
+	// TimestampsOffset shifts a timestamp earlier than the one before it, and
+	// every timestamp after it, by the time it went back, keeping the time
+	// between the lines after it.
+	TimestampsOffset
+)
+
+// AttachmentMode is what is done with files that aren't shown as images.
+type AttachmentMode int
+
+const (
+	// AttachmentsDropped leaves them out, as iTerm2 does.
+	AttachmentsDropped AttachmentMode = iota
+
+	// AttachmentsCollected keeps them for Screen.Attachments and the JSON
+	// metadata, instead of rendering them.
+	AttachmentsCollected
+
+	// AttachmentsListed keeps them as AttachmentsCollected does, and lists
+	// them after the output of Screen.AsHTML, in a
+	// <details class="term-attachments"> element with links to download
+	// them.
+	AttachmentsListed
+)
+
+// attributeName matches the names LinkAttributes can add.
+var attributeName = regexp.MustCompile(`^[a-zA-Z_:][-a-zA-Z0-9_:.]*$`)
+
+// linkAttributes returns LinkAttributes formatted for inclusion in an <a> tag,
+// including a leading space.
+func (o *Options) linkAttributes() string {
+	if len(o.LinkAttributes) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(o.LinkAttributes))
+	for key := range o.LinkAttributes {
+		name := strings.ToLower(key)
+		// Without any namespace prefix, e.g. for xlink:href
+		local := name[strings.LastIndexByte(name, ':')+1:]
+		if !attributeName.MatchString(key) || strings.HasPrefix(name, "on") || local == "href" {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&b, ` %s="%s"`, html.EscapeString(key), html.EscapeString(o.LinkAttributes[key]))
+	}
+	return b.String()
+}
+
+// sanitizeURL sanitizes a link or image URL, honouring AllowedURLSchemes and
+// CSPSafe.
+func (o *Options) sanitizeURL(s string) string {
+	url := sanitizeURL(s, o.AllowedURLSchemes)
+	if o.CSPSafe && strings.HasPrefix(url, "data:") {
+		return unsafeURLSubstitution
+	}
+	return url
+}
+
+// proxyImageURL rewrites an external image URL using the ImageProxyURL
+// template. Relative URLs and other schemes are returned unchanged.
+func (o *Options) proxyImageURL(s string) string {
+	if o.ImageProxyURL == "" {
+		return s
+	}
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return s
+	}
+	return strings.ReplaceAll(o.ImageProxyURL, "{url}", url.QueryEscape(s))
+}
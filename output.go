@@ -2,29 +2,81 @@ package terminal
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"html"
+	"maps"
 	"sort"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 type outputBuffer struct {
 	buf bytes.Buffer
+
+	// semantic uses <strong> and <em> rather than <span> for bold and
+	// italic text. semanticElements instead nests <strong>, <em> and <del>
+	// elements for bold, italic and strikethrough text, without classes.
+	semantic         bool
+	semanticElements bool
+
+	// the elements opened by appendNodeStyle, innermost last
+	tags []string
+
+	// scratch space for the classes of a style
+	classes []byte
 }
 
 func (b *outputBuffer) appendNodeStyle(n node) {
-	b.buf.Write([]byte(`<span class="`))
-	for idx, class := range n.style.asClasses() {
-		if idx > 0 {
-			b.buf.Write([]byte(" "))
+	b.tags = b.tags[:0]
+	b.classes = n.style.appendClasses(b.classes[:0])
+
+	if b.semanticElements {
+		style := *n.style
+		for _, e := range []struct {
+			set *bool
+			tag string
+		}{{&style.bold, "strong"}, {&style.italic, "em"}, {&style.strike, "del"}} {
+			if *e.set {
+				b.openTag(e.tag, nil)
+				*e.set = false
+			}
 		}
-		b.buf.Write([]byte(class))
+		if b.classes = style.appendClasses(b.classes[:0]); len(b.classes) == 0 {
+			return
+		}
+	}
+
+	tag := "span"
+	if b.semantic && n.style.bold {
+		tag = "strong"
+	} else if b.semantic && n.style.italic {
+		tag = "em"
+	}
+	b.openTag(tag, b.classes)
+}
+
+// openTag opens an element with the given space-separated classes.
+func (b *outputBuffer) openTag(tag string, classes []byte) {
+	b.tags = append(b.tags, tag)
+	b.buf.WriteByte('<')
+	b.buf.WriteString(tag)
+	if len(classes) > 0 {
+		b.buf.WriteString(` class="`)
+		b.buf.Write(classes)
+		b.buf.WriteByte('"')
 	}
-	b.buf.Write([]byte(`">`))
+	b.buf.WriteByte('>')
 }
 
 func (b *outputBuffer) closeStyle() {
-	b.buf.Write([]byte("</span>"))
+	for i := len(b.tags) - 1; i >= 0; i-- {
+		b.buf.WriteString("</")
+		b.buf.WriteString(b.tags[i])
+		b.buf.WriteByte('>')
+	}
+	b.tags = b.tags[:0]
 }
 
 func (b *outputBuffer) appendMeta(namespace string, data map[string]string) {
@@ -42,46 +94,353 @@ func (b *outputBuffer) appendMeta(namespace string, data map[string]string) {
 	b.buf.WriteString("<?" + namespace)
 	for i := range keys {
 		key := keys[i]
-		fmt.Fprintf(&b.buf, ` %s="%s"`, key, html.EscapeString(data[key]))
+		fmt.Fprintf(&b.buf, ` %s="%s"`, html.EscapeString(key), html.EscapeString(data[key]))
 	}
 	b.buf.WriteString("?>")
 }
 
-// Append a character to our outputbuffer, escaping HTML bits as necessary.
-func (b *outputBuffer) appendChar(char rune) {
-	switch char {
-	case '&':
-		b.buf.WriteString("&amp;")
-	case '\'':
-		b.buf.WriteString("&#39;")
-	case '<':
-		b.buf.WriteString("&lt;")
-	case '>':
-		b.buf.WriteString("&gt;")
-	case '"':
-		b.buf.WriteString("&quot;")
-	case '/':
-		b.buf.WriteString("&#47;")
-	default:
-		b.buf.WriteRune(char)
+// htmlEscapes are the escaped forms of the ASCII characters that need
+// escaping in HTML, indexed by character.
+var htmlEscapes = [utf8.RuneSelf]string{
+	'&':  "&amp;",
+	'\'': "&#39;",
+	'<':  "&lt;",
+	'>':  "&gt;",
+	'"':  "&quot;",
+	'/':  "&#47;",
+}
+
+// Append the characters of nodes to our outputbuffer, escaping HTML bits as
+// necessary. They are appended to the buffer's spare capacity and written in
+// one go, as writing them one at a time is slow enough to show in profiles.
+func (b *outputBuffer) appendChars(nodes []node) {
+	text := b.buf.AvailableBuffer()
+	for _, n := range nodes {
+		if uint32(n.blob) >= utf8.RuneSelf {
+			text = utf8.AppendRune(text, n.blob)
+		} else if escaped := htmlEscapes[n.blob]; escaped != "" {
+			text = append(text, escaped...)
+		} else {
+			text = append(text, byte(n.blob))
+		}
+	}
+	b.buf.Write(text)
+}
+
+// htmlRenderer renders screen lines as HTML, keeping track of anything that
+// carries over from one line to the next.
+type htmlRenderer struct {
+	opts *Options
+
+	// offset is the index in the screen of the first line rendered, when
+	// rendering part of a screen
+	offset int
+
+	// The first and most recent line timestamps seen, in milliseconds since
+	// the Unix epoch, for opts.ElapsedTime
+	seenTimestamp     bool
+	firstTimestamp    int64
+	previousTimestamp int64
+
+	// The last timestamp rendered, and how far later timestamps are moved,
+	// for opts.TimestampNormalization
+	seenNormalized      bool
+	lastNormalized      int64
+	normalizationOffset int64
+
+	// The number of times each line is repeated, for
+	// opts.CollapseRepeatedLines
+	repeats map[int]int
+
+	// Syntax highlighting of lines of code, for opts.Highlighter
+	highlights map[int][]decoration
+
+	// The contents of the line being rendered, reused between lines
+	line outputBuffer
+
+	// Scratch space for the opts.LineCache key of a line
+	keyScratch []byte
+}
+
+// render renders the lines of a screen, separated by newlines and grouped
+// into any collapsible sections.
+func (r *htmlRenderer) render(lines []screenLine) []byte {
+	var buf bytes.Buffer
+	buf.Grow(estimateHTMLSize(lines))
+	if r.opts.Accessible {
+		buf.WriteString(`<div role="log">`)
+	}
+	lines, dropped := r.filterLines(lines)
+	layout := findSections(lines, r.opts)
+	for i := range dropped {
+		layout.hidden[i] = true
+	}
+	if r.opts.CollapseRepeatedLines {
+		r.repeats = layout.collapseRepeats(lines)
+	}
+	r.highlights = codeHighlights(lines, r.opts)
+	sections := layout.sections
+	var openSections []section
+
+	written := false
+	afterBlock := false
+	for i, line := range lines {
+		// HTML after the line before a part of the screen is rendered with
+		// that line instead
+		if snippet, ok := r.opts.InsertHTML[r.offset+i]; ok && (i > 0 || r.offset == 0) {
+			buf.WriteString(snippet)
+			written, afterBlock = true, true
+		}
+		if layout.hidden[i] {
+			// The contents of a section whose header is hidden aren't grouped
+			for len(sections) > 0 && sections[0].start == i {
+				sections = sections[1:]
+			}
+			continue
+		}
+		// No newline after a summary or inserted HTML, as it is already a
+		// block of its own.
+		// Newlines go before a closing </details>, not after it, to avoid
+		// blank lines in white-space: pre output.
+		if written && !afterBlock {
+			if r.opts.BreakElements {
+				buf.WriteString("<br>")
+			} else {
+				buf.WriteByte('\n')
+			}
+		}
+		written = true
+
+		for len(openSections) > 0 && openSections[len(openSections)-1].end <= i {
+			buf.WriteString("</details>")
+			openSections = openSections[:len(openSections)-1]
+		}
+
+		if len(sections) > 0 && sections[0].start == i {
+			section := sections[0]
+			sections = sections[1:]
+			openSections = append(openSections, section)
+
+			buf.WriteString(`<details class="term-group"`)
+			if section.open {
+				buf.WriteString(" open")
+			}
+			buf.WriteString(`><summary>`)
+			r.writeLine(&buf, i, section.title)
+			if section.hasDuration {
+				fmt.Fprintf(&buf, `<span class="term-group-duration">%s</span>`, formatSectionDuration(section.duration))
+			}
+			buf.WriteString(`</summary>`)
+			afterBlock = true
+			continue
+		}
+
+		if r.opts.GitHubActionsAnnotations {
+			if a, message, ok := parseAnnotationMessage(line); ok {
+				r.writeLine(&buf, i, message, a.decoration(len(message.nodes)))
+				afterBlock = false
+				continue
+			}
+		}
+
+		r.writeLine(&buf, i, line, r.highlights[i]...)
+		afterBlock = false
+	}
+	if snippet, ok := r.opts.InsertHTML[r.offset+len(lines)]; ok {
+		buf.WriteString(snippet)
+	}
+	for range openSections {
+		buf.WriteString("</details>")
+	}
+	if r.opts.Accessible {
+		buf.WriteString("</div>")
+	}
+	return buf.Bytes()
+}
+
+// The bytes of HTML a change of style or an element is assumed to take, e.g.
+// <span class="term-fg31 term-bg42"></span>
+const (
+	spanHTMLBytes    = 48
+	elementHTMLBytes = 96
+)
+
+// estimateHTMLSize guesses how long the HTML for lines will be, from the
+// number of characters and how often their style changes, so that render can
+// allocate its buffer once rather than growing it over and over for large
+// outputs.
+func estimateHTMLSize(lines []screenLine) int {
+	size := 0
+	for _, line := range lines {
+		size += len(line.nodes) + 1
+		last := &emptyStyle
+		for _, n := range line.nodes {
+			if n.elem != nil {
+				size += elementHTMLBytes
+			} else if !n.style.isEqual(last) {
+				if !n.style.isEmpty() {
+					size += spanHTMLBytes
+				}
+				last = n.style
+			}
+		}
+	}
+	// Leave room for multibyte characters and escaped HTML
+	return size + size/8
+}
+
+// filterLines applies opts.LineFilter, returning the lines with any
+// replacements made, and the indexes of lines to drop.
+func (r *htmlRenderer) filterLines(lines []screenLine) ([]screenLine, map[int]bool) {
+	if r.opts.LineFilter == nil {
+		return lines, nil
+	}
+	filtered := make([]screenLine, len(lines))
+	dropped := map[int]bool{}
+	for i, line := range lines {
+		text := strings.TrimRight(lineString(line, len(line.nodes)), " ")
+		keep, replacement := r.opts.LineFilter(PlainLine{Number: r.offset + i + 1, Text: text})
+		if !keep {
+			dropped[i] = true
+		} else if replacement != text {
+			line.nodes = textNodes(replacement)
+		}
+		filtered[i] = line
 	}
+	return filtered, dropped
 }
 
-func outputLineAsHTML(line screenLine) string {
+// lineAsHTML renders the line at index i of the lines being rendered, with
+// any extra decorations as well as those enabled in the options.
+func (r *htmlRenderer) lineAsHTML(i int, line screenLine, extra ...decoration) string {
+	var buf bytes.Buffer
+	r.writeLine(&buf, i, line, extra...)
+	return buf.String()
+}
+
+// writeLine writes the HTML of the line at index i of the lines being
+// rendered to buf, like lineAsHTML.
+func (r *htmlRenderer) writeLine(buf *bytes.Buffer, i int, line screenLine, extra ...decoration) {
+	opts := r.opts
+	var timestamp string
+	if data, ok := line.metadata[bkNamespace]; ok {
+		timestamp = data["t"]
+	}
+	if opts.TimestampNormalization != TimestampsAsIs && timestamp != "" {
+		line, timestamp = r.normalizeTimestamp(line, timestamp)
+	}
+	if line.title {
+		// The header goes around everything else on the line
+		extra = append([]decoration{{end: len(line.nodes), open: `<span class="term-title" role="heading">`, close: "</span>"}}, extra...)
+	}
+
+	// Only the line's wrapper and repeat count depend on other lines, so the
+	// rest can come from the cache
+	var key uint64
+	if cache := opts.LineCache; cache != nil {
+		number := -1
+		if opts.LineNumbers == LineNumberGutter {
+			number = r.offset + i + 1
+		}
+		key, r.keyScratch = cache.key(r.keyScratch, line, number, extra)
+		if body, ok := cache.get(key); ok {
+			r.finishLine(buf, i, line, timestamp, body)
+			return
+		}
+	}
+
 	var spanOpen bool
-	var lineBuf outputBuffer
+	lineBuf := &r.line
+	lineBuf.buf.Reset()
+	lineBuf.tags = lineBuf.tags[:0]
+	lineBuf.semantic, lineBuf.semanticElements = opts.Accessible, opts.SemanticElements
+
+	if opts.LineNumbers == LineNumberGutter {
+		fmt.Fprintf(&lineBuf.buf, `<span class="term-line-number" data-line-number="%d"></span>`, r.offset+i+1)
+	}
 
 	if data, ok := line.metadata[bkNamespace]; ok {
-		lineBuf.appendMeta(bkNamespace, data)
+		if opts.TimestampFormat != TimestampProcessingInstruction {
+			_, data = splitTimestamp(data)
+		}
+		if len(data) > 0 && !opts.OmitProcessingInstructions {
+			lineBuf.appendMeta(bkNamespace, data)
+		}
+	}
+	for _, namespace := range opts.APCNamespaces {
+		if opts.OmitProcessingInstructions {
+			break
+		}
+		if data, ok := line.metadata[namespace]; ok && namespace != bkNamespace {
+			lineBuf.appendMeta(namespace, data)
+		}
 	}
+	for _, payload := range line.apcs {
+		lineBuf.buf.WriteString("<!-- apc: " + escapeComment(payload) + " -->")
+	}
+	if opts.TimestampFormat == TimestampTimeElement && timestamp != "" {
+		if datetime, ok := timestampAsDatetime(timestamp); ok {
+			fmt.Fprintf(&lineBuf.buf, `<time datetime="%s"></time>`, datetime)
+		}
+	}
+	if l := line.label; l != nil {
+		fmt.Fprintf(&lineBuf.buf, `<span class="term-stream term-fg%d">%s</span> `, l.color, html.EscapeString(l.text))
+	}
+
+	decorations := lineDecorations(line, opts, extra)
+	var openDecorations []decoration
+
+	// The decorations are found in the whole line, so that e.g. a link cut
+	// short still goes to the whole URL
+	full := line
+	cut, truncated := opts.truncation(line)
+	if truncated {
+		line.nodes = line.nodes[:cut]
+		decorations = clipDecorations(decorations, cut)
+	}
+
+	// The style the previous node was rendered in, and the end of any run of
+	// blanks rendered in that style rather than their own
+	var previous *style
+	blanksEnd := 0
+
+	for idx := 0; idx < len(line.nodes); idx++ {
+		node := line.nodes[idx]
+		if idx > 0 && idx >= blanksEnd && !node.style.isEqual(previous) {
+			blanksEnd = blankRunEnd(line.nodes, idx, previous)
+		}
+		if idx < blanksEnd {
+			node.style = previous
+		}
 
-	for idx, node := range line.nodes {
-		if idx == 0 && !node.style.isEmpty() {
+		// Decorations starting or ending here need any open span closed first,
+		// so that the markup nests properly
+		decorationBoundary := false
+		for len(openDecorations) > 0 && openDecorations[len(openDecorations)-1].end == idx {
+			if spanOpen {
+				lineBuf.closeStyle()
+				spanOpen = false
+			}
+			lineBuf.buf.WriteString(openDecorations[len(openDecorations)-1].close)
+			openDecorations = openDecorations[:len(openDecorations)-1]
+			decorationBoundary = true
+		}
+		for len(decorations) > 0 && decorations[0].start == idx {
+			if spanOpen {
+				lineBuf.closeStyle()
+				spanOpen = false
+			}
+			lineBuf.buf.WriteString(decorations[0].open)
+			openDecorations = append(openDecorations, decorations[0])
+			decorations = decorations[1:]
+			decorationBoundary = true
+		}
+
+		if (idx == 0 || decorationBoundary) && !node.style.isEmpty() {
 			lineBuf.appendNodeStyle(node)
 			spanOpen = true
-		} else if idx > 0 {
-			previous := line.nodes[idx-1]
-			if !node.hasSameStyle(previous) {
+		} else if idx > 0 && !decorationBoundary {
+			if !node.style.isEqual(previous) {
 				if spanOpen {
 					lineBuf.closeStyle()
 					spanOpen = false
@@ -94,15 +453,158 @@ func outputLineAsHTML(line screenLine) string {
 		}
 
 		if elem := node.elem; elem != nil {
-			lineBuf.buf.WriteString(elem.asHTML())
-		}
-
-		if r, ok := node.getRune(); ok {
-			lineBuf.appendChar(r)
+			lineBuf.buf.WriteString(elem.asHTML(opts))
+		} else {
+			// Characters that follow in the same style, with no decoration
+			// starting or ending, need no markup between them
+			end := idx + 1
+			for ; end < len(line.nodes); end++ {
+				next := line.nodes[end]
+				if next.elem != nil || !(end < blanksEnd || next.style.isEqual(node.style)) ||
+					(len(decorations) > 0 && decorations[0].start == end) ||
+					(len(openDecorations) > 0 && openDecorations[len(openDecorations)-1].end == end) {
+					break
+				}
+			}
+			lineBuf.appendChars(line.nodes[idx:end])
+			idx = end - 1
 		}
+		previous = node.style
 	}
 	if spanOpen {
 		lineBuf.closeStyle()
 	}
-	return strings.TrimRight(lineBuf.buf.String(), " \t")
+	for i := len(openDecorations) - 1; i >= 0; i-- {
+		lineBuf.buf.WriteString(openDecorations[i].close)
+	}
+	// Decorations of nothing at the end of the line, e.g. on an empty line
+	for _, d := range decorations {
+		lineBuf.buf.WriteString(d.open)
+		lineBuf.buf.WriteString(d.close)
+	}
+	body := bytes.TrimRight(lineBuf.buf.Bytes(), " \t")
+	if truncated {
+		body = append(body, `<span class="term-ellipsis">…</span>`...)
+	}
+	if cache := opts.LineCache; cache != nil {
+		cache.put(key, bytes.Clone(body))
+	}
+	r.finishLine(buf, i, full, timestamp, body)
+}
+
+// normalizeTimestamp corrects the line's timestamp if it's earlier than the
+// last one, returning the line with the corrected timestamp in a copy of its
+// metadata.
+func (r *htmlRenderer) normalizeTimestamp(line screenLine, timestamp string) (screenLine, string) {
+	ms, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return line, timestamp
+	}
+	ms += r.normalizationOffset
+	if r.seenNormalized && ms < r.lastNormalized {
+		if r.opts.TimestampNormalization == TimestampsOffset {
+			r.normalizationOffset += r.lastNormalized - ms
+		}
+		ms = r.lastNormalized
+	}
+	r.seenNormalized, r.lastNormalized = true, ms
+
+	normalized := strconv.FormatInt(ms, 10)
+	if normalized == timestamp {
+		return line, timestamp
+	}
+	metadata := maps.Clone(line.metadata)
+	metadata[bkNamespace] = maps.Clone(metadata[bkNamespace])
+	metadata[bkNamespace]["t"] = normalized
+	line.metadata = metadata
+	return line, normalized
+}
+
+// finishLine writes the rendered body of a line, inside its wrapper if the
+// options need one, followed by its repeat count.
+func (r *htmlRenderer) finishLine(buf *bytes.Buffer, i int, line screenLine, timestamp string, body []byte) {
+	if r.opts.wrapsLines() {
+		r.writeLineWrapper(buf, i, line, timestamp)
+	}
+	buf.Write(body)
+	if n := r.repeats[i]; n > 1 {
+		fmt.Fprintf(buf, ` <span class="term-repeated">(repeated %d times)</span>`, n)
+	}
+	if r.opts.wrapsLines() {
+		buf.WriteString("</span>")
+	}
+}
+
+// blankRunEnd returns the end of the run of blank nodes starting at start,
+// if they are followed by a node in style s and would look the same in it,
+// so that spaces left between text in the same style, e.g. by erasing part of
+// a line, don't split it into separate spans. Otherwise it returns start.
+func blankRunEnd(nodes []node, start int, s *style) int {
+	for i := start; i < len(nodes); i++ {
+		n := nodes[i]
+		if n.style.isEqual(s) {
+			return i
+		}
+		if n.elem != nil || n.blob != ' ' || !n.style.looksSameBlank(s) {
+			break
+		}
+	}
+	return start
+}
+
+// writeLineWrapper writes the opening tag of the span wrapping a line,
+// carrying any per-line classes and data attributes.
+func (r *htmlRenderer) writeLineWrapper(b *bytes.Buffer, i int, line screenLine, timestamp string) {
+	_, truncated := r.opts.truncation(line)
+	// The line's text is only needed to match LineClasses against, and as
+	// the title of a truncated line, so it's built once and only for those
+	var text string
+	if len(r.opts.LineClasses) > 0 || truncated {
+		text = lineString(line, len(line.nodes))
+	}
+
+	b.WriteString(`<span class="term-line`)
+	for _, class := range r.opts.lineClasses(text) {
+		b.WriteString(" ")
+		b.WriteString(html.EscapeString(class))
+	}
+	if r.opts.isHighlighted(r.offset + i + 1) {
+		b.WriteString(" term-highlight")
+	}
+	if truncated {
+		b.WriteString(" term-truncated")
+	}
+	b.WriteString(`"`)
+
+	if truncated {
+		fmt.Fprintf(b, ` title="%s"`, html.EscapeString(strings.TrimRight(text, " ")))
+	}
+
+	if r.opts.LineNumbers == LineNumberDataAttribute {
+		fmt.Fprintf(b, ` data-line-number="%d"`, r.offset+i+1)
+	}
+
+	if len(line.frames) > 0 {
+		if frames, err := json.Marshal(line.frames); err == nil {
+			fmt.Fprintf(b, ` data-overwrites="%d" data-frames="%s"`, line.overwrites, html.EscapeString(string(frames)))
+		}
+	}
+
+	if r.opts.TimestampFormat == TimestampDataAttribute && timestamp != "" {
+		fmt.Fprintf(b, ` data-timestamp="%s"`, html.EscapeString(timestamp))
+	}
+
+	if r.opts.ElapsedTime && timestamp != "" {
+		if ms, err := strconv.ParseInt(timestamp, 10, 64); err == nil {
+			if !r.seenTimestamp {
+				r.seenTimestamp = true
+				r.firstTimestamp = ms
+				r.previousTimestamp = ms
+			}
+			fmt.Fprintf(b, ` data-elapsed-ms="%d" data-delta-ms="%d"`, ms-r.firstTimestamp, ms-r.previousTimestamp)
+			r.previousTimestamp = ms
+		}
+	}
+
+	b.WriteString(">")
 }
@@ -1,6 +1,8 @@
 package terminal
 
 import (
+	"bytes"
+	"fmt"
 	"unicode"
 	"unicode/utf8"
 )
@@ -21,13 +23,16 @@ type position struct {
 // Stateful ANSI parser
 type parser struct {
 	mode                 int
-	screen               *screen
+	screen               *Screen
 	ansi                 []byte
 	cursor               int
 	escapeStartedAt      int
-	instructions         []string
+	instructions         [][]byte
 	instructionStartedAt int
-	savePosition         position
+
+	// The number of unknown sequences when the last was traced, for
+	// opts.Trace
+	tracedUnknown int
 }
 
 /*
@@ -71,12 +76,26 @@ type parser struct {
  * normally designate the character set.
  */
 
-func parseANSIToScreen(s *screen, ansi []byte) {
-	p := parser{mode: MODE_NORMAL, ansi: ansi, screen: s}
+func parseANSIToScreen(s *Screen, ansi []byte) {
+	p := parser{mode: MODE_NORMAL, ansi: ansi, screen: s, tracedUnknown: len(s.sequences.unknown)}
 	p.mode = MODE_NORMAL
 	length := len(p.ansi)
 	for p.cursor = 0; p.cursor < length; {
+		if s.opts.MaxLines > 0 && s.dropped+s.y >= s.opts.MaxLines {
+			break
+		}
+		s.offset = s.parsed + p.cursor
+		if p.mode == MODE_NORMAL {
+			// Write runs of plain text in one go, rather than a character at a
+			// time, which is no faster for a single character between escapes
+			if n := plainTextLen(p.ansi[p.cursor:]); n > 1 {
+				s.appendText(p.ansi[p.cursor : p.cursor+n])
+				p.cursor += n
+				continue
+			}
+		}
 		char, charLen := utf8.DecodeRune(p.ansi[p.cursor:])
+		mode, at := p.mode, p.cursor
 
 		switch p.mode {
 		case MODE_ESCAPE:
@@ -100,21 +119,97 @@ func parseANSIToScreen(s *screen, ansi []byte) {
 		}
 
 		p.cursor += charLen
+		if s.opts.Trace != nil {
+			p.trace(mode, at, charLen)
+		}
+	}
+	if p.mode != MODE_NORMAL && p.cursor >= length {
+		// The input ended in the middle of a sequence
+		if s.recordEscapes {
+			s.escapes = append(s.escapes, escapeSpan{start: s.parsed + p.escapeStartedAt, end: s.parsed + length})
+		}
+		if s.opts.Trace != nil {
+			p.writeTrace(p.escapeStartedAt, "ESC", p.ansi[p.escapeStartedAt:], "unterminated, ignored")
+		}
+	}
+}
+
+// recordSequence records the escape sequence started at escapeStartedAt, for
+// RenderEscapes. A known sequence ends with the current character, but only
+// the escape character of an unknown one is skipped, as the rest is then
+// parsed as text.
+func (p *parser) recordSequence(known bool) {
+	s := p.screen
+	if !s.recordEscapes {
+		return
+	}
+	end := p.escapeStartedAt + 1
+	if known {
+		_, n := utf8.DecodeRune(p.ansi[p.cursor:])
+		end = p.cursor + n
+	}
+	s.escapes = append(s.escapes, escapeSpan{start: s.parsed + p.escapeStartedAt, end: s.parsed + end, known: known})
+}
+
+// recordUnknown records the unknown or malformed escape sequence ending at the
+// character being parsed, for Screen.UnknownSequences.
+func (p *parser) recordUnknown() {
+	_, n := utf8.DecodeRune(p.ansi[p.cursor:])
+	c := &p.screen.sequences
+	c.unknown = append(c.unknown, p.screen.parsed+p.escapeStartedAt)
+	c.unknownText = append(c.unknownText, string(p.ansi[p.escapeStartedAt:p.cursor+n]))
+}
+
+// plainTextLen returns the length of the text at the start of b that
+// handleNormal would append character by character, up to the next newline,
+// carriage return, backspace or escape.
+func plainTextLen(b []byte) int {
+	for i, c := range b {
+		switch c {
+		case '\n', '\r', '\b', '\x1b':
+			return i
+		}
 	}
+	return len(b)
 }
 
 func (p *parser) handleCharset(char rune) {
+	p.recordSequence(true)
 	p.mode = MODE_NORMAL
 }
 
 func (p *parser) handleOperatingSystemCommand(char rune) {
-	if char != '\a' {
+	end := p.cursor
+	if p.screen.opts.TmuxCapture && char == '\\' && p.ansi[p.cursor-1] == '\x1b' {
+		// Terminated with ST, as tmux writes hyperlinks
+		end--
+	} else if char != '\a' {
 		return
 	}
 	p.mode = MODE_NORMAL
+	p.recordSequence(true)
 
 	// Bell received, stop parsing our potential image
-	image, err := parseElementSequence(string(p.ansi[p.instructionStartedAt:p.cursor]))
+	sequence := p.ansi[p.instructionStartedAt:end]
+	p.screen.sequences.countOSC(sequence)
+	if p.screen.opts.TitleHeaders {
+		if title, ok := parseTitleSequence(sequence); ok {
+			p.screen.setTitle(title)
+			return
+		}
+	}
+	if !bytes.HasPrefix(sequence, elementSequencePrefix) {
+		// Not one of ours, e.g. a window title, nothing to render
+		return
+	}
+	if p.screen.opts.Attachments != AttachmentsDropped {
+		if a, ok := parseAttachmentSequence(sequence, p.screen.fitInlineImage); ok {
+			a.Line = p.screen.dropped + p.screen.y + 1
+			p.screen.attachments = append(p.screen.attachments, a)
+			return
+		}
+	}
+	image, err := parseElementSequence(sequence, p.screen.fitInlineImage)
 
 	if image == nil && err == nil {
 		// No image & no error, nothing to render
@@ -143,6 +238,17 @@ func (p *parser) handleOperatingSystemCommand(char rune) {
 	}
 }
 
+// parseTitleSequence returns the title set by an OSC sequence such as
+// 2;title, which sets the window title, or 0;title, which sets the icon name
+// too.
+func parseTitleSequence(sequence []byte) (string, bool) {
+	code, title, ok := bytes.Cut(sequence, []byte{';'})
+	if !ok || (string(code) != "0" && string(code) != "2") {
+		return "", false
+	}
+	return string(title), true
+}
+
 // handleApplicationProgramCommand is called for each character consumed while
 // in MODE_APC, but does nothing until the APC is terminated with BEL (0x07).
 //
@@ -167,7 +273,9 @@ func (p *parser) handleApplicationProgramCommand(char rune) {
 
 	// APC terminator has been received; return to normal mode and handle the APC...
 	p.mode = MODE_NORMAL
-	sequence := string(p.ansi[p.instructionStartedAt:p.cursor])
+	p.recordSequence(true)
+	sequence := p.ansi[p.instructionStartedAt:p.cursor]
+	p.screen.sequences.countAPC(sequence)
 
 	// this might be a Buildkite Application Program Command sequence...
 	data, err := parseApcBk(sequence)
@@ -176,15 +284,44 @@ func (p *parser) handleApplicationProgramCommand(char rune) {
 		p.screen.appendMany([]rune(err.Error()))
 		return
 	}
-
-	if data == nil {
+	if data != nil {
+		p.screen.setLineMetadata(bkNamespace, data)
 		return
 	}
-	p.screen.setLineMetadata(bkNamespace, data)
+
+	// ...or in one of the other namespaces we've been asked to handle
+	for _, namespace := range p.screen.opts.APCNamespaces {
+		if namespace == bkNamespace {
+			continue
+		}
+		data, err := parseApcNamespace(namespace, sequence)
+		if err != nil {
+			p.screen.appendMany([]rune(fmt.Sprintf("*** Error parsing %s APC ANSI escape sequence: ", namespace)))
+			p.screen.appendMany([]rune(err.Error()))
+			return
+		}
+		if data != nil {
+			p.screen.setLineMetadata(namespace, data)
+			return
+		}
+	}
+
+	if p.screen.opts.UnknownAPCComments {
+		line := p.screen.getCurrentLineForWriting()
+		line.apcs = append(line.apcs, string(sequence))
+	}
 }
 
 func (p *parser) handleControlSequence(char rune) {
+	final := char
 	char = unicode.ToUpper(char)
+	if char == ':' && p.screen.opts.TmuxCapture {
+		// Separates subparameters, handled by addInstruction
+		return
+	}
+	if p.screen.opts.ConPTY && p.handleConPTYSequence(final) {
+		return
+	}
 	switch char {
 	case '?', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
 		// Part of an instruction
@@ -192,14 +329,20 @@ func (p *parser) handleControlSequence(char rune) {
 		p.addInstruction()
 		p.instructionStartedAt = p.cursor + utf8.RuneLen(';')
 	case 'Q', 'J', 'K', 'G', 'A', 'B', 'C', 'D', 'M':
+		p.screen.sequences.countCSI(final)
+		p.recordSequence(true)
 		p.addInstruction()
 		p.screen.applyEscape(char, p.instructions)
 		p.mode = MODE_NORMAL
 	case 'H', 'L':
 		// Set/reset mode (SM/RM), ignore and continue
+		p.screen.sequences.countCSI(final)
+		p.recordSequence(true)
 		p.mode = MODE_NORMAL
 	default:
 		// unrecognized character, abort the escapeCode
+		p.recordUnknown()
+		p.recordSequence(false)
 		p.cursor = p.escapeStartedAt
 		p.mode = MODE_NORMAL
 	}
@@ -225,37 +368,88 @@ func (p *parser) handleEscape(char rune) {
 	switch char {
 	case '[':
 		p.instructionStartedAt = p.cursor + utf8.RuneLen('[')
-		p.instructions = make([]string, 0, 1)
+		p.instructions = p.instructions[:0]
 		p.mode = MODE_CONTROL
 	case ']':
 		p.instructionStartedAt = p.cursor + utf8.RuneLen('[')
 		p.mode = MODE_OSC
 	case ')', '(':
+		p.screen.sequences.countESC(char)
 		p.instructionStartedAt = p.cursor + utf8.RuneLen('(')
 		p.mode = MODE_CHARSET
 	case '_':
 		p.instructionStartedAt = p.cursor + utf8.RuneLen('[')
 		p.mode = MODE_APC
 	case 'M':
+		p.screen.sequences.countESC(char)
+		p.recordSequence(true)
 		p.screen.revNewLine()
 		p.mode = MODE_NORMAL
 	case '7':
-		p.savePosition = position{x: p.screen.x, y: p.screen.y}
+		p.screen.sequences.countESC(char)
+		p.recordSequence(true)
+		p.screen.savePosition = position{x: p.screen.x, y: p.screen.y}
 		p.mode = MODE_NORMAL
 	case '8':
-		p.screen.x = p.savePosition.x
-		p.screen.y = p.savePosition.y
+		p.screen.sequences.countESC(char)
+		p.recordSequence(true)
+		p.screen.x = p.screen.savePosition.x
+		p.screen.y = p.screen.savePosition.y
 		p.mode = MODE_NORMAL
 	default:
 		// Not an escape code, false alarm
+		p.recordUnknown()
+		p.recordSequence(false)
 		p.cursor = p.escapeStartedAt
 		p.mode = MODE_NORMAL
 	}
 }
 
+// maxInstructions is the most parameters of a control sequence that are kept,
+// as terminals such as xterm limit them too, so that a sequence of thousands
+// of semicolons can't grow the list of instructions with it. The rest are
+// ignored.
+const maxInstructions = 32
+
+// addInstruction adds the instruction ending at the cursor. Instructions are
+// slices of the input rather than copies, so are only valid until the
+// sequence has been applied.
 func (p *parser) addInstruction() {
-	instruction := string(p.ansi[p.instructionStartedAt:p.cursor])
-	if instruction != "" {
+	instruction := p.ansi[p.instructionStartedAt:p.cursor]
+	if p.screen.opts.TmuxCapture && bytes.IndexByte(instruction, ':') >= 0 {
+		p.addSubparameters(instruction)
+		return
+	}
+	if len(instruction) > 0 && len(p.instructions) < maxInstructions {
 		p.instructions = append(p.instructions, instruction)
 	}
 }
+
+// addSubparameters adds the instructions equivalent to one with
+// colon-separated subparameters, as tmux writes: 38:5:208 is 38;5;208, 4:3
+// (a curly underline) is 4 and 4:0 is 24. 24-bit colors and underline colors
+// (58) aren't rendered, so are dropped.
+func (p *parser) addSubparameters(instruction []byte) {
+	parameters := bytes.Split(instruction, []byte(":"))
+	switch string(parameters[0]) {
+	case "38", "48":
+		if len(parameters) != 3 || string(parameters[1]) != "5" {
+			return
+		}
+	case "4":
+		if string(parameters[1]) == "0" {
+			parameters = [][]byte{[]byte("24")}
+		} else {
+			parameters = parameters[:1]
+		}
+	case "58":
+		return
+	default:
+		parameters = parameters[:1]
+	}
+	for _, parameter := range parameters {
+		if len(parameter) > 0 && len(p.instructions) < maxInstructions {
+			p.instructions = append(p.instructions, parameter)
+		}
+	}
+}
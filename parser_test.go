@@ -5,6 +5,9 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/text/encoding/charmap"
 )
 
 func TestParseSimpleXY(t *testing.T) {
@@ -67,8 +70,8 @@ func TestParseDECCursorSaveRestore(t *testing.T) {
 
 // ----------------------------------------
 
-func parsedScreen(data string) *screen {
-	s := &screen{}
+func parsedScreen(data string) *Screen {
+	s := &Screen{}
 	parseANSIToScreen(s, []byte(data))
 	return s
 }
@@ -79,7 +82,7 @@ func csi(n int, code string) string {
 	return "\x1b[" + strconv.Itoa(n) + code
 }
 
-func assertXY(t *testing.T, s *screen, x, y int) error {
+func assertXY(t *testing.T, s *Screen, x, y int) error {
 	if s.x != x {
 		return fmt.Errorf("expected screen.x == %d, got %d", x, s.x)
 	}
@@ -89,14 +92,14 @@ func assertXY(t *testing.T, s *screen, x, y int) error {
 	return nil
 }
 
-func assertText(t *testing.T, s *screen, expected string) error {
+func assertText(t *testing.T, s *Screen, expected string) error {
 	if actual := s.asPlainText(); actual != expected {
 		return fmt.Errorf("expected text %q, got %q", expected, actual)
 	}
 	return nil
 }
 
-func assertTextXY(t *testing.T, s *screen, expected string, x, y int) error {
+func assertTextXY(t *testing.T, s *Screen, expected string, x, y int) error {
 	if err := assertXY(t, s, x, y); err != nil {
 		return err
 	}
@@ -105,3 +108,43 @@ func assertTextXY(t *testing.T, s *screen, expected string, x, y int) error {
 	}
 	return nil
 }
+
+func TestParseSequenceStats(t *testing.T) {
+	s := NewScreen(Options{})
+	s.Parse([]byte("\x1b[31mred\x1b[0m \x1b[2K\x1b[?25l\x1b7\x1b8\x1b(B\x1b]0;title\x07\x1b]1339;url=a\x07\x1b_bk;t=1\x07\x1b[5xoops\x1bz"))
+
+	want := SequenceStats{
+		CSI:     map[string]int{"m": 2, "K": 1, "l": 1},
+		ESC:     map[string]int{"7": 1, "8": 1, "(": 1},
+		OSC:     map[string]int{"0": 1, "1339": 1},
+		APC:     map[string]int{"bk": 1},
+		Unknown: 2,
+	}
+	if diff := cmp.Diff(s.SequenceStats(), want); diff != "" {
+		t.Errorf("SequenceStats() diff (-got +want):\n%s", diff)
+	}
+}
+
+func TestParseUnknownSequenceOffsets(t *testing.T) {
+	s := NewScreen(Options{})
+	s.Parse([]byte("ok\x1b[31mred\n"))
+	s.Parse([]byte("\x1b[5xoops\x1bz"))
+
+	if diff := cmp.Diff(s.UnknownSequenceOffsets(), []int{11, 19}); diff != "" {
+		t.Errorf("UnknownSequenceOffsets() diff (-got +want):\n%s", diff)
+	}
+	want := []UnknownSequence{{Offset: 11, Text: "\x1b[5x"}, {Offset: 19, Text: "\x1bz"}}
+	if diff := cmp.Diff(s.UnknownSequences(), want); diff != "" {
+		t.Errorf("UnknownSequences() diff (-got +want):\n%s", diff)
+	}
+}
+
+func TestParseUnknownSequencesTranscoded(t *testing.T) {
+	// The input is longer once transcoded, so its offsets can't slice it
+	s := NewScreen(Options{InputEncoding: charmap.Windows1252})
+	s.Parse([]byte("caf\xe9 \xe9\xe9\xe9\x1b[5x"))
+	want := []UnknownSequence{{Offset: 12, Text: "\x1b[5x"}}
+	if diff := cmp.Diff(s.UnknownSequences(), want); diff != "" {
+		t.Errorf("UnknownSequences() diff (-got +want):\n%s", diff)
+	}
+}
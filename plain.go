@@ -0,0 +1,44 @@
+package terminal
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// renderPlain renders input without escape sequences, carriage returns or
+// backspaces, as logs from tools with colours turned off are, without parsing
+// it onto a screen, as each line renders as its own text, escaped. It reports
+// false for any other input, which needs parsing.
+func renderPlain(input []byte) ([]byte, bool) {
+	if bytes.ContainsAny(input, "\x1b\r\b") || !utf8.Valid(input) {
+		return nil, false
+	}
+
+	// Newlines at the end move the cursor down without writing any lines
+	input = bytes.TrimRight(input, "\n")
+	html := make([]byte, 0, len(input)+len(input)/8)
+	for len(input) > 0 {
+		line, rest, more := bytes.Cut(input, []byte("\n"))
+		html = appendEscaped(html, bytes.TrimRight(line, " \t"))
+		if more {
+			html = append(html, '\n')
+		}
+		input = rest
+	}
+	var s Screen
+	return s.fillBlankLines(html), true
+}
+
+// appendEscaped appends text to b, escaping HTML bits the same as
+// outputBuffer.appendChars, copying the text between them in one go.
+func appendEscaped(b, text []byte) []byte {
+	start := 0
+	for i, c := range text {
+		if c < utf8.RuneSelf && htmlEscapes[c] != "" {
+			b = append(b, text[start:i]...)
+			b = append(b, htmlEscapes[c]...)
+			start = i + 1
+		}
+	}
+	return append(b, text[start:]...)
+}
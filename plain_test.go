@@ -0,0 +1,68 @@
+package terminal
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+)
+
+func TestRenderPlainMatchesScreen(t *testing.T) {
+	inputs := []string{
+		"",
+		"\n",
+		"  ",
+		"hello",
+		"hello\n",
+		"hello\n\n",
+		"hello\n\nfriend",
+		"hello\n\n\nfriend",
+		"hello\n\n\n\nfriend",
+		"\n\nhello",
+		"hello\n  \n",
+		"trailing \t\nspace  ",
+		"\ttabs\tin\tthe\tmiddle",
+		"hello <strong>friend</strong> & 'you' \"there\"",
+		"✔ Übersetzung — 日本語",
+		"\x00\x07\x7f\u0085",
+	}
+	for _, input := range inputs {
+		got, ok := renderPlain([]byte(input))
+		if !ok {
+			t.Errorf("renderPlain(%q) reported false, want true", input)
+			continue
+		}
+		screen := NewScreen(Options{})
+		screen.Parse([]byte(input))
+		if want := screen.AsHTML(); !bytes.Equal(got, want) {
+			t.Errorf("renderPlain(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestRenderPlainNeedsParsing(t *testing.T) {
+	for _, input := range []string{
+		"\x1b[31mred",
+		"50%\r100%",
+		"ab\bc",
+		"invalid \xff UTF-8",
+	} {
+		if _, ok := renderPlain([]byte(input)); ok {
+			t.Errorf("renderPlain(%q) reported true, want false", input)
+		}
+	}
+}
+
+// plainFixture returns the npm fixture with escape sequences and carriage
+// returns removed, as if colours had been turned off.
+func plainFixture(b *testing.B) []byte {
+	raw := loadFixture(b, "npm.sh", "raw")
+	return regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]|\r`).ReplaceAll(raw, nil)
+}
+
+func BenchmarkRendererPlain(b *testing.B) {
+	raw := plainFixture(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = Render(raw)
+	}
+}
@@ -0,0 +1,34 @@
+package terminal
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Redacted is the text that RedactStrings and RedactPatterns replace secrets
+// with.
+const Redacted = "*****"
+
+// RedactStrings returns a function for Options.Redact that replaces every
+// occurrence of the given secrets with Redacted. Empty secrets are ignored.
+func RedactStrings(secrets ...string) func(string) string {
+	var oldnew []string
+	for _, secret := range secrets {
+		if secret != "" {
+			oldnew = append(oldnew, secret, Redacted)
+		}
+	}
+	replacer := strings.NewReplacer(oldnew...)
+	return replacer.Replace
+}
+
+// RedactPatterns returns a function for Options.Redact that replaces every
+// match of the given patterns with Redacted.
+func RedactPatterns(patterns ...*regexp.Regexp) func(string) string {
+	return func(input string) string {
+		for _, pattern := range patterns {
+			input = pattern.ReplaceAllLiteralString(input, Redacted)
+		}
+		return input
+	}
+}
@@ -3,16 +3,82 @@ package terminal
 import (
 	"bytes"
 	"math"
+	"slices"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
-// A terminal 'screen'. Current cursor position, cursor style, and characters
-type screen struct {
+// Screen is a terminal 'screen': the current cursor position, cursor style,
+// and characters resulting from parsing ANSI input. Use a Screen rather than
+// Render to get information about the input, such as Annotations, as well as
+// the HTML.
+type Screen struct {
 	x      int
 	y      int
 	screen []screenLine
 	style  *style
+	opts   Options
+
+	// Every style set so far, for internStyle
+	styles map[style]*style
+
+	// Unused space for the nodes of new lines, for newLineNodes
+	nodeSlab  []node
+	freeNodes [][]node
+
+	// The number of lines a Streamer has rendered and dropped from the top
+	// of the screen
+	dropped int
+
+	// Transcodes the input for opts.InputEncoding
+	decoder inputDecoder
+
+	// Unescapes the input for opts.TmuxCapture
+	tmux tmuxUnescaper
+
+	// Set at the end of the input, so that neither the decoder nor the tmux
+	// unescaper keep anything cut off by it for the next call to Parse
+	atEOF bool
+
+	// The escape sequences parsed, if recordEscapes is set, for
+	// RenderEscapes
+	recordEscapes bool
+	escapes       []escapeSpan
+
+	// Cursor position saved by ESC 7, kept here rather than in the parser so
+	// that it lasts between calls to Parse
+	savePosition position
+
+	// Decoded bytes of inline images rendered so far, for enforcing
+	// opts.MaxTotalInlineImageBytes
+	inlineImageBytes int
+
+	// Whether the cursor has returned to the start of line returnedY, which
+	// had the text returnedFrame, without anything being written since
+	returned      bool
+	returnedY     int
+	returnedFrame Frame
+
+	// The number of lines overwritten after a carriage return
+	overwrites int
+
+	// For opts.SourceMap: the number of bytes parsed by earlier calls to
+	// Parse, the offset in the input of the byte being parsed, and the last
+	// line written to
+	parsed      int
+	offset      int
+	sourceY     int
+	sourceValid bool
+
+	// Counts of the escape sequences parsed, for SequenceStats
+	sequences sequenceCounts
+
+	// The terminal title last set, for opts.TitleHeaders
+	title string
+
+	// Files that aren't shown as images, for opts.Attachments
+	attachments []Attachment
 }
 
 type screenLine struct {
@@ -21,6 +87,54 @@ type screenLine struct {
 	// metadata is { namespace => { key => value, ... }, ... }
 	// e.g. { "bk" => { "t" => "1234" } }
 	metadata map[string]map[string]string
+
+	// section markers that were printed on the line and then erased
+	markers []sectionMarker
+
+	// the number of times the line was overwritten after a carriage return,
+	// and the versions kept for opts.ProgressFrames
+	overwrites int
+	frames     []Frame
+
+	// the ranges of the input that wrote to the line, for opts.SourceMap
+	source []ByteRange
+
+	// the label of the stream the line came from, for RenderMerged
+	label *streamLabel
+
+	// whether the line is the header for a change of the terminal title, for
+	// opts.TitleHeaders
+	title bool
+
+	// the payloads of APCs in namespaces that aren't handled, for
+	// opts.UnknownAPCComments
+	apcs []string
+}
+
+// A ByteRange is a range of bytes of input, from Start up to but not
+// including End.
+type ByteRange struct {
+	Start, End int
+}
+
+// A Frame is an earlier version of a line that was overwritten after a
+// carriage return, e.g. a step of a progress bar.
+type Frame struct {
+	Text string `json:"text"`
+
+	// Timestamp is the Buildkite timestamp (bk;t=...) of this version of the
+	// line in milliseconds since the Unix epoch, or 0 if it didn't have one.
+	Timestamp int64 `json:"timestamp,omitempty"`
+}
+
+// LineFrames are the versions of a line kept for Options.ProgressFrames.
+type LineFrames struct {
+	// Line is the line's number, counting from 1.
+	Line int `json:"line"`
+
+	// Frames are the kept versions in the order they were written, not
+	// including the final version of the line.
+	Frames []Frame `json:"frames"`
 }
 
 const (
@@ -30,7 +144,7 @@ const (
 
 // Clear part (or all) of a line on the screen. The range to clear is inclusive
 // of xStart and xEnd.
-func (s *screen) clear(y, xStart, xEnd int) {
+func (s *Screen) clear(y, xStart, xEnd int) {
 	if y < 0 || y >= len(s.screen) {
 		return
 	}
@@ -53,6 +167,12 @@ func (s *screen) clear(y, xStart, xEnd int) {
 	if xEnd >= len(line.nodes)-1 {
 		// Clear from start to end of the line
 		line.nodes = line.nodes[:xStart]
+		if xStart == 0 {
+			// Nothing written to the line before is left, so neither are the
+			// ranges of the input that wrote it, as progress bars redrawing
+			// the line would otherwise add more and more of them
+			line.source = line.source[:0]
+		}
 		return
 	}
 
@@ -62,43 +182,93 @@ func (s *screen) clear(y, xStart, xEnd int) {
 }
 
 // "Safe" parseint for parsing ANSI instructions
-func ansiInt(s string) int {
-	if s == "" {
+func ansiInt(b []byte) int {
+	if len(b) == 0 {
 		return 1
 	}
-	i, _ := strconv.ParseInt(s, 10, 8)
-	return int(i)
+	i, ok := parseUint(b)
+	if !ok {
+		return 0
+	}
+	return min(i, math.MaxInt8)
 }
 
+// parseUint parses a decimal number without converting it to a string.
+// Numbers too large for an int are clamped, as ANSI parameters are clamped to
+// much smaller limits anyway.
+func parseUint(b []byte) (int, bool) {
+	if len(b) == 0 {
+		return 0, false
+	}
+	n := 0
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		if n < math.MaxInt32 {
+			n = n*10 + int(c-'0')
+		}
+	}
+	return n, true
+}
+
+// Limits on how far cursor movement can take the cursor beyond the text
+// written so far, where opts.Width and opts.MaxLines don't set one. Each
+// sequence moves the cursor at most math.MaxInt8 places, but without these
+// input such as \x1b[127C repeated many times could make every line of the
+// screen a great deal longer than the input, and a cursor position such as
+// \x1b[30000;1H could add lines far past the end of it.
+const (
+	// maxCursorColumn is the furthest column, counting from 0, that moving
+	// the cursor forward can reach
+	maxCursorColumn = 1023
+
+	// maxSkippedLines is the most lines that one sequence positioning the
+	// cursor, as ConPTY does, can add past the end of the screen
+	maxSkippedLines = 1024
+)
+
 // Move the cursor up, if we can
-func (s *screen) up(i string) {
+func (s *Screen) up(i []byte) {
 	s.y -= ansiInt(i)
 	s.y = int(math.Max(0, float64(s.y)))
 }
 
 // Move the cursor down
-func (s *screen) down(i string) {
+func (s *Screen) down(i []byte) {
 	s.y += ansiInt(i)
 }
 
-// Move the cursor forward on the line
-func (s *screen) forward(i string) {
-	s.x += ansiInt(i)
+// Move the cursor forward on the line, up to the last column of the terminal
+// or maxCursorColumn, but not back from a column text has already reached
+func (s *Screen) forward(i []byte) {
+	if s.opts.Width > 0 {
+		s.x = min(s.x+ansiInt(i), s.opts.Width-1)
+		return
+	}
+	s.x = min(s.x+ansiInt(i), max(s.x, maxCursorColumn))
 }
 
 // Move the cursor backward, if we can
-func (s *screen) backward(i string) {
+func (s *Screen) backward(i []byte) {
 	s.x -= ansiInt(i)
 	s.x = int(math.Max(0, float64(s.x)))
 }
 
-func (s *screen) getCurrentLineForWriting() *screenLine {
-	// Add rows to our screen if necessary
-	for i := len(s.screen); i <= s.y; i++ {
-		s.screen = append(s.screen, screenLine{nodes: make([]node, 0, 80)})
+func (s *Screen) getCurrentLineForWriting() *screenLine {
+	// Add rows to our screen if necessary. Rows the cursor skipped over, e.g.
+	// with a cursor down sequence, are left without any nodes, so that large
+	// jumps don't allocate much.
+	if n := len(s.screen); n <= s.y {
+		s.screen = slices.Grow(s.screen, s.y+1-n)[:s.y+1]
+		// The screen may have been truncated, leaving old lines beyond its end
+		clear(s.screen[n:])
 	}
 
 	line := &s.screen[s.y]
+	if line.nodes == nil {
+		line.nodes = s.newLineNodes()
+	}
 
 	// Add columns if currently shorter than the cursor's x position
 	for i := len(line.nodes); i <= s.x; i++ {
@@ -107,34 +277,194 @@ func (s *screen) getCurrentLineForWriting() *screenLine {
 	return line
 }
 
+// lineWidth is the number of nodes set aside for each new line, enough for
+// most lines without growing.
+const lineWidth = 80
+
+// newLineNodes returns space for the nodes of a new line, reusing that of
+// lines a Streamer has dropped if there are any. Otherwise lines share
+// larger allocations, sized to the lines so far up to 64 lines, rather than
+// allocating their own, and move to one of their own if they grow longer
+// than lineWidth.
+func (s *Screen) newLineNodes() []node {
+	if n := len(s.freeNodes); n > 0 {
+		nodes := s.freeNodes[n-1]
+		s.freeNodes = s.freeNodes[:n-1]
+		return nodes
+	}
+	if len(s.nodeSlab) < lineWidth {
+		s.nodeSlab = make([]node, lineWidth*min(max(len(s.screen), 1), 64))
+	}
+	nodes := s.nodeSlab[:0:lineWidth]
+	s.nodeSlab = s.nodeSlab[lineWidth:]
+	return nodes
+}
+
 // Write a character to the screen's current X&Y, along with the current screen style
-func (s *screen) write(data rune) {
+func (s *Screen) write(data rune) {
 	line := s.getCurrentLineForWriting()
+	s.recordOverwrite(line)
+	s.recordSource(line, utf8.RuneLen(data))
 	line.nodes[s.x] = node{blob: data, style: s.style}
 }
 
 // Append a character to the screen
-func (s *screen) append(data rune) {
+func (s *Screen) append(data rune) {
+	s.autoWrap()
 	s.write(data)
 	s.x++
 }
 
+// appendText appends plain text, the same as appending its characters one at
+// a time, but only looking up the line again when the text wraps.
+func (s *Screen) appendText(text []byte) {
+	var line *screenLine
+	start := s.offset
+	for i := 0; i < len(text); {
+		char, size := rune(text[i]), 1
+		if char >= utf8.RuneSelf {
+			char, size = utf8.DecodeRune(text[i:])
+		}
+		if i > 0 && s.opts.MaxLines > 0 && s.dropped+s.y >= s.opts.MaxLines {
+			return
+		}
+		if s.opts.Width > 0 && s.x >= s.opts.Width {
+			s.autoWrap()
+			line = nil
+		}
+		if line == nil {
+			line = s.getCurrentLineForWriting()
+			s.recordOverwrite(line)
+		}
+		s.offset = start + i
+		s.recordSource(line, utf8.RuneLen(char))
+		n := node{blob: char, style: s.style}
+		if s.x < len(line.nodes) {
+			line.nodes[s.x] = n
+		} else {
+			line.nodes = append(line.nodes, n)
+		}
+		s.x++
+		i += size
+	}
+}
+
+// Move to the start of the next line if the cursor is past the right margin
+func (s *Screen) autoWrap() {
+	if s.opts.Width > 0 && s.x >= s.opts.Width {
+		s.x = 0
+		s.y++
+	}
+}
+
 // Append multiple characters to the screen
-func (s *screen) appendMany(data []rune) {
+func (s *Screen) appendMany(data []rune) {
 	for _, char := range data {
 		s.append(char)
 	}
 }
 
-func (s *screen) appendElement(i *element) {
+func (s *Screen) appendElement(i *element) {
+	s.autoWrap()
 	line := s.getCurrentLineForWriting()
+	s.recordOverwrite(line)
+	s.recordSource(line, 1)
 	line.nodes[s.x] = node{style: s.style, elem: i}
 	s.x++
 }
 
+// setTitle shows a change of the terminal title on a line of its own, as the
+// header of the output after it, without any escapes or runs of whitespace.
+// Setting the same title again, or clearing it, doesn't add a header.
+func (s *Screen) setTitle(title string) {
+	title = strings.Join(strings.Fields(string(Strip([]byte(title)))), " ")
+	if title == s.title {
+		return
+	}
+	s.title = title
+	if title == "" {
+		return
+	}
+	if s.x != 0 {
+		s.newLine()
+	}
+	s.clear(s.y, screenStartOfLine, screenEndOfLine)
+	s.appendMany([]rune(title))
+	s.screen[s.y].title = true
+	s.newLine()
+}
+
+// maxSourceRanges is the most ranges of the input that SourceMap keeps for a
+// line.
+const maxSourceRanges = 64
+
+// recordSource adds the n bytes of input at the current offset to the line's
+// source ranges. Consecutive writes to the same line extend its last range,
+// so that it includes any escape sequences between them.
+func (s *Screen) recordSource(line *screenLine, n int) {
+	if !s.opts.SourceMap {
+		return
+	}
+	end := s.offset + n
+	if s.sourceValid && s.sourceY == s.y && len(line.source) > 0 {
+		last := &line.source[len(line.source)-1]
+		if end > last.End {
+			last.End = end
+		}
+		return
+	}
+	if len(line.source) == maxSourceRanges {
+		// Keep memory bounded for lines returned to over and over, by merging
+		// the oldest ranges along with whatever came between them
+		line.source[1].Start = line.source[0].Start
+		line.source = append(line.source[:0], line.source[1:]...)
+	}
+	line.source = append(line.source, ByteRange{Start: s.offset, End: end})
+	s.sourceY = s.y
+	s.sourceValid = true
+}
+
+// SourceMap returns the ranges of the input that wrote each line, indexed by
+// line number minus one, when Options.SourceMap is set. Offsets count from
+// the start of the input to the first call to Parse, after Options.Redact
+// has been applied. Lines written in one go have a single range, which
+// includes any escape sequences between the first and last characters; lines
+// returned to after writing elsewhere, e.g. by moving the cursor, have more,
+// up to 64, after which the oldest are merged. Ranges that wrote to a line
+// before it was erased entirely are dropped.
+func (s *Screen) SourceMap() [][]ByteRange {
+	if !s.opts.SourceMap {
+		return nil
+	}
+	ranges := make([][]ByteRange, len(s.screen))
+	for i, line := range s.screen {
+		ranges[i] = line.source
+	}
+	return ranges
+}
+
+// fitInlineImage enforces the configured inline image limits, reporting
+// whether an image of size bytes can be included, or should be replaced with
+// a placeholder as it is too large, the total budget has been spent, or data:
+// URIs aren't allowed.
+func (s *Screen) fitInlineImage(size int) bool {
+	if s.opts.CSPSafe {
+		// Inline images can only be rendered as data: URIs
+		return false
+	}
+	if s.opts.MaxInlineImageBytes > 0 && size > s.opts.MaxInlineImageBytes {
+		return false
+	}
+	if s.opts.MaxTotalInlineImageBytes > 0 && s.inlineImageBytes+size > s.opts.MaxTotalInlineImageBytes {
+		return false
+	}
+	s.inlineImageBytes += size
+	return true
+}
+
 // Set line metadata. Merges the provided data into any existing
 // metadata for the current line, overwriting data when keys collide.
-func (s *screen) setLineMetadata(namespace string, data map[string]string) {
+func (s *Screen) setLineMetadata(namespace string, data map[string]string) {
 	line := s.getCurrentLineForWriting()
 	if line.metadata == nil {
 		line.metadata = map[string]map[string]string{
@@ -157,15 +487,44 @@ func (s *screen) setLineMetadata(namespace string, data map[string]string) {
 }
 
 // Apply color instruction codes to the screen's current style
-func (s *screen) color(i []string) {
-	s.style = s.style.color(i)
+func (s *Screen) color(i [][]byte) {
+	// 24-bit colors are only skipped for tmux, so that other logs render as
+	// they always have
+	style := s.style.color(i, s.opts.TmuxCapture)
+	if s.opts.Accessible {
+		// Blinking is purely decorative, and distracting
+		style.blink = false
+	}
+	s.style = s.internStyle(style)
 }
 
+// internStyle returns the screen's one copy of a style, so that each style
+// is only allocated once however many times it's set.
+func (s *Screen) internStyle(st style) *style {
+	if st.isEmpty() {
+		return &emptyStyle
+	}
+	if p, ok := s.styles[st]; ok {
+		return p
+	}
+	if s.styles == nil {
+		s.styles = map[style]*style{}
+	}
+	p := new(style)
+	*p = st
+	s.styles[st] = p
+	return p
+}
+
+// noInstructions stands in for the instructions of a sequence without any,
+// which default to an empty first instruction.
+var noInstructions = [][]byte{nil}
+
 // Apply an escape sequence to the screen
-func (s *screen) applyEscape(code rune, instructions []string) {
+func (s *Screen) applyEscape(code rune, instructions [][]byte) {
 	if len(instructions) == 0 {
 		// Ensure we always have a first instruction
-		instructions = []string{""}
+		instructions = noInstructions
 	}
 
 	switch code {
@@ -175,7 +534,7 @@ func (s *screen) applyEscape(code rune, instructions []string) {
 		s.x = 0
 	// "Erase in Display"
 	case 'J':
-		switch instructions[0] {
+		switch string(instructions[0]) {
 		// "erase from current position to end (inclusive)"
 		case "0", "":
 			// This line should be equivalent to K0
@@ -194,16 +553,31 @@ func (s *screen) applyEscape(code rune, instructions []string) {
 			}
 			// Adjust the cursor position to compensate
 			s.y = 0
-		// 2: "erase entire display", 3: "erase whole display including scroll-back buffer"
-		// Given we don't have a scrollback of our own, we treat these as equivalent
-		case "2", "3":
-			s.screen = nil
+		// "erase entire display", moving the cursor to its top left
+		case "2":
+			if s.opts.LegacyEraseDisplay {
+				s.clearAll()
+				break
+			}
+			top := min(s.displayTop(), len(s.screen))
+			s.screen = s.screen[:top]
 			s.x = 0
-			s.y = 0
+			s.y = top
+		// "erase saved lines", the scrollback above the display
+		case "3":
+			if s.opts.LegacyEraseDisplay {
+				s.clearAll()
+				break
+			}
+			top := s.displayTop()
+			s.screen = s.screen[min(top, len(s.screen)):]
+			// A cursor moved up into the scrollback goes to the top of the
+			// display
+			s.y = max(s.y-top, 0)
 		}
 	// "Erase in Line"
 	case 'K':
-		switch instructions[0] {
+		switch string(instructions[0]) {
 		case "0", "":
 			s.clear(s.y, s.x, screenEndOfLine)
 		case "1":
@@ -222,25 +596,170 @@ func (s *screen) applyEscape(code rune, instructions []string) {
 	}
 }
 
-// Parse ANSI input, populate our screen buffer with nodes
-func (s *screen) parse(ansi []byte) {
-	s.style = &emptyStyle
+// displayTop returns the index of the first line of the terminal's display,
+// below its scrollback: opts.Height lines up from the bottom of the screen, or
+// of the cursor if it's further down. Without opts.Height, there is no
+// scrollback, as cursor movement can reach every line.
+func (s *Screen) displayTop() int {
+	if s.opts.Height <= 0 {
+		return 0
+	}
+	bottom := max(len(s.screen)-1, s.y)
+	return max(bottom-s.opts.Height+1, 0)
+}
 
-	parseANSIToScreen(s, ansi)
+// clearAll clears the whole screen, scrollback included, for
+// opts.LegacyEraseDisplay.
+func (s *Screen) clearAll() {
+	s.screen = nil
+	s.x = 0
+	s.y = 0
+}
+
+// NewScreen returns an empty screen that parses and renders input with the
+// given options.
+func NewScreen(opts Options) *Screen {
+	return &Screen{style: &emptyStyle, opts: opts}
+}
+
+// Parse parses ANSI input onto the screen. Parse may be called more than once
+// to add more input, but escape sequences split between calls aren't
+// recognised. With InputEncoding or DetectInputEncoding, a character cut off
+// at the end of the input is kept for the next call, as is one of tmux's
+// octal escapes with TmuxCapture, so call Close at the end of the input.
+func (s *Screen) Parse(ansi []byte) {
+	s.parse(ansi)
 }
 
-func (s *screen) asHTML() []byte {
-	var lines []string
+// Close parses whatever Parse has kept for the next call, as the end of the
+// input, e.g. the first byte of a UTF-16 character, or the \03 of \033 with
+// TmuxCapture. Parse shouldn't be called after Close.
+func (s *Screen) Close() {
+	if !s.atEOF {
+		s.atEOF = true
+		s.parse(nil)
+	}
+}
+
+// parseAll parses the whole of the input, keeping nothing back for more.
+func (s *Screen) parseAll(ansi []byte) {
+	s.atEOF = true
+	s.parse(ansi)
+}
+
+// AsHTML renders the screen as HTML, the same as Render does.
+func (s *Screen) AsHTML() []byte {
+	html := s.fillBlankLines(s.asHTML())
+	if s.opts.Attachments == AttachmentsListed {
+		html = append(html, attachmentsHTML(s.attachments)...)
+	}
+	return html
+}
 
-	for _, line := range s.screen {
-		lines = append(lines, outputLineAsHTML(line))
+// PageCount returns the number of pages of linesPerPage lines that the
+// screen fills.
+func (s *Screen) PageCount(linesPerPage int) int {
+	if linesPerPage <= 0 {
+		linesPerPage = len(s.screen)
+	}
+	if len(s.screen) == 0 {
+		return 0
 	}
+	return (len(s.screen) + linesPerPage - 1) / linesPerPage
+}
 
-	return []byte(strings.Join(lines, "\n"))
+// Page renders page n (counting from 0) of linesPerPage lines of the screen
+// as HTML, or nil if there is no such page, using RangeAsHTML.
+func (s *Screen) Page(n, linesPerPage int) []byte {
+	if linesPerPage <= 0 {
+		linesPerPage = len(s.screen)
+	}
+	if n < 0 {
+		return nil
+	}
+	return s.RangeAsHTML(LineRange{Start: n*linesPerPage + 1, End: (n + 1) * linesPerPage})
+}
+
+// LineCount returns the number of lines on the screen.
+func (s *Screen) LineCount() int {
+	return len(s.screen)
+}
+
+// RangeAsHTML renders only the given range of lines of the screen as HTML,
+// or nil if none of them exist. Groups are split at the edges of the range,
+// but lines are still numbered from the start of the screen.
+func (s *Screen) RangeAsHTML(lines LineRange) []byte {
+	start, end := lines.Start-1, lines.End
+	if start < 0 {
+		start = 0
+	}
+	if end > len(s.screen) {
+		end = len(s.screen)
+	}
+	if start >= end {
+		return nil
+	}
+	r := htmlRenderer{opts: &s.opts, offset: start}
+	return s.renderPart(&r, s.screen[start:end])
+}
+
+// renderPart renders part of the screen, which is likely to start or end
+// with blank lines that would otherwise be lost.
+func (s *Screen) renderPart(r *htmlRenderer, lines []screenLine) []byte {
+	html := s.fillBlankLines(r.render(lines))
+	if !s.opts.BreakElements {
+		if bytes.HasPrefix(html, []byte("\n")) {
+			html = append([]byte("&nbsp;"), html...)
+		}
+		if bytes.HasSuffix(html, []byte("\n")) {
+			html = append(html, "&nbsp;"...)
+		}
+	}
+	return html
+}
+
+// fillBlankLines fills blank lines with &nbsp;, unless lines are separated by
+// <br> elements.
+func (s *Screen) fillBlankLines(html []byte) []byte {
+	if s.opts.BreakElements || !bytes.Contains(html, []byte("\n\n")) {
+		return html
+	}
+	return bytes.Replace(html, []byte("\n\n"), []byte("\n&nbsp;\n"), -1)
+}
+
+// Parse ANSI input, populate our screen buffer with nodes
+func (s *Screen) parse(ansi []byte) {
+	if s.style == nil {
+		s.style = &emptyStyle
+	}
+	if s.opts.InputEncoding != nil || s.opts.DetectInputEncoding {
+		s.decoder.atEOF = s.atEOF
+		ansi = s.decoder.decode(ansi, &s.opts)
+	}
+	if s.opts.TmuxCapture {
+		ansi = s.tmux.unescape(ansi, s.atEOF)
+	}
+	if s.opts.Redact != nil {
+		ansi = []byte(s.opts.Redact(string(ansi)))
+	}
+
+	parseANSIToScreen(s, ansi)
+	s.parsed += len(ansi)
+}
+
+func (s *Screen) asHTML() []byte {
+	r := htmlRenderer{opts: &s.opts}
+	return r.render(s.screen)
+}
+
+// AsPlainText renders the screen as plain text, without any styles, links
+// or images.
+func (s *Screen) AsPlainText() string {
+	return s.asPlainText()
 }
 
 // asPlainText renders the screen without any ANSI style etc.
-func (s *screen) asPlainText() string {
+func (s *Screen) asPlainText() string {
 	var buf bytes.Buffer
 	for i, line := range s.screen {
 		for _, node := range line.nodes {
@@ -255,22 +774,137 @@ func (s *screen) asPlainText() string {
 	return strings.TrimRight(buf.String(), " \t")
 }
 
-func (s *screen) newLine() {
+// AsANSI renders the screen as ANSI, with the cursor movement etc. of the
+// input resolved, so that only text, styles and links remain, e.g. to replay
+// a cleaned up log in a terminal. Links are written as OSC 8 hyperlinks, and
+// images are left out, as in plain text.
+func (s *Screen) AsANSI() []byte {
+	return s.asANSI()
+}
+
+// asANSI renders the screen as ANSI, writing the whole style wherever it
+// changes.
+func (s *Screen) asANSI() []byte {
+	var buf bytes.Buffer
+	for i, line := range s.screen {
+		writeANSINodes(&buf, line.nodes)
+		if i < len(s.screen)-1 {
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes()
+}
+
+// writeANSINodes writes nodes as ANSI, writing the whole style wherever it
+// changes, and resetting it at the end.
+func writeANSINodes(buf *bytes.Buffer, nodes []node) {
+	current := &emptyStyle
+	for _, node := range nodes {
+		if !node.style.isEqual(current) {
+			buf.WriteString(node.style.asSGR())
+			current = node.style
+		}
+		switch {
+		case node.elem == nil:
+			buf.WriteRune(node.blob)
+		case node.elem.elementType == ELEMENT_LINK:
+			buf.WriteString("\x1b]8;;" + node.elem.url + "\x1b\\" + linkText(node.elem) + "\x1b]8;;\x1b\\")
+		}
+	}
+	if !current.isEqual(&emptyStyle) {
+		buf.WriteString("\x1b[0m")
+	}
+}
+
+// linkText returns the text of a link, or its URL if it has none.
+func linkText(e *element) string {
+	if e.content == "" {
+		return e.url
+	}
+	return e.content
+}
+
+func (s *Screen) newLine() {
+	s.recordSectionMarker()
+	s.returned = false
 	s.x = 0
 	s.y++
 }
 
-func (s *screen) revNewLine() {
+func (s *Screen) revNewLine() {
 	if s.y > 0 {
 		s.y--
 	}
 }
 
-func (s *screen) carriageReturn() {
+func (s *Screen) carriageReturn() {
+	s.recordSectionMarker()
 	s.x = 0
+
+	s.returned = s.y < len(s.screen) && len(s.screen[s.y].nodes) > 0
+	if s.returned {
+		s.returnedY = s.y
+		if s.opts.ProgressFrames > 0 {
+			line := s.screen[s.y]
+			s.returnedFrame = Frame{Text: strings.TrimRight(lineString(line, len(line.nodes)), " ")}
+			if t, err := strconv.ParseInt(line.metadata[bkNamespace]["t"], 10, 64); err == nil {
+				s.returnedFrame.Timestamp = t
+			}
+		}
+	}
+}
+
+// A line that is written to after a carriage return, rather than moving to
+// the next line, is being overwritten, e.g. by a progress bar.
+func (s *Screen) recordOverwrite(line *screenLine) {
+	if !s.returned {
+		return
+	}
+	s.returned = false
+	if s.y != s.returnedY {
+		return
+	}
+	s.overwrites++
+	line.overwrites++
+	if s.opts.ProgressFrames > 0 && line.overwrites%s.opts.ProgressFrames == 0 {
+		line.frames = append(line.frames, s.returnedFrame)
+	}
+}
+
+// Frames returns the earlier versions of lines kept for
+// Options.ProgressFrames, for lines that have any.
+func (s *Screen) Frames() []LineFrames {
+	var frames []LineFrames
+	for i, line := range s.screen {
+		if len(line.frames) > 0 {
+			frames = append(frames, LineFrames{Line: i + 1, Frames: line.frames})
+		}
+	}
+	return frames
+}
+
+// Overwrites returns the number of times a line was overwritten after a
+// carriage return, as progress bars do.
+func (s *Screen) Overwrites() int {
+	return s.overwrites
+}
+
+// Section markers such as GitLab's section_start:1560896352:name are usually
+// followed by \r\x1b[0K to erase them, so remember them before they go.
+// Markers that are left on their own line are erased here instead.
+func (s *Screen) recordSectionMarker() {
+	if (!s.opts.GitLabSections && !s.opts.TravisFolds) || s.y >= len(s.screen) {
+		return
+	}
+	line := &s.screen[s.y]
+	text := strings.TrimRight(lineString(*line, len(line.nodes)), " ")
+	if marker, ok := parseSectionMarker(text, &s.opts); ok {
+		line.markers = append(line.markers, marker)
+		line.nodes = line.nodes[:0]
+	}
 }
 
-func (s *screen) backspace() {
+func (s *Screen) backspace() {
 	if s.x > 0 {
 		s.x--
 	}
@@ -0,0 +1,356 @@
+package terminal
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A section is a collapsible run of lines, rendered as a <details> element
+// with its header line as the <summary>.
+type section struct {
+	// start is the index of the header line, and end is the index of the
+	// line after the last line of the section.
+	start, end int
+
+	// title is rendered as the summary.
+	title screenLine
+
+	open bool
+
+	// duration is shown alongside the title, if known
+	duration    time.Duration
+	hasDuration bool
+}
+
+// A sectionMarker starts or ends a named section, e.g. GitLab's
+// section_start:1560896352:name[collapsed=true]
+type sectionMarker struct {
+	start        bool
+	name         string
+	timestamp    int64
+	hasTimestamp bool
+	collapsed    bool
+}
+
+// sectionLayout describes how the lines of a screen are grouped into
+// collapsible sections.
+type sectionLayout struct {
+	// sections in order of their start line
+	sections []section
+
+	// marker lines that are left out of the output
+	hidden map[int]bool
+}
+
+func findSections(lines []screenLine, opts *Options) sectionLayout {
+	layout := sectionLayout{hidden: map[int]bool{}}
+	if opts.BuildkiteGroups {
+		layout.addBuildkiteGroups(lines)
+	}
+	if opts.GitHubActionsGroups {
+		layout.addGitHubActionsGroups(lines)
+	}
+	if opts.GitLabSections || opts.TravisFolds {
+		layout.addMarkedSections(lines)
+	}
+	layout.nest()
+	return layout
+}
+
+// nest sorts sections by their start line, and shortens any section that
+// would otherwise overlap the end of the section containing it, so that the
+// sections can be rendered as nested elements. Only the outermost of sections
+// starting on the same line is kept, as a line can only be one summary.
+func (l *sectionLayout) nest() {
+	sort.SliceStable(l.sections, func(i, j int) bool {
+		if l.sections[i].start != l.sections[j].start {
+			return l.sections[i].start < l.sections[j].start
+		}
+		return l.sections[i].end > l.sections[j].end
+	})
+
+	var nested []section
+	var parents []section
+	for _, s := range l.sections {
+		if len(nested) > 0 && nested[len(nested)-1].start == s.start {
+			continue
+		}
+		for len(parents) > 0 && parents[len(parents)-1].end <= s.start {
+			parents = parents[:len(parents)-1]
+		}
+		if len(parents) > 0 && parents[len(parents)-1].end < s.end {
+			s.end = parents[len(parents)-1].end
+		}
+		parents = append(parents, s)
+		nested = append(nested, s)
+	}
+	l.sections = nested
+}
+
+// addBuildkiteGroups finds groups started by lines beginning with "--- ",
+// "+++ " or "~~~ ". Each group lasts until the next one starts; groups
+// started with "+++" are expanded, and "^^^ +++" expands the current group.
+// https://buildkite.com/docs/pipelines/managing-log-output#collapsing-output
+func (l *sectionLayout) addBuildkiteGroups(lines []screenLine) {
+	current := -1
+	for i, line := range lines {
+		if strings.TrimRight(lineString(line, 8), " ") == "^^^ +++" {
+			if current >= 0 {
+				l.sections[current].open = true
+			}
+			l.hidden[i] = true
+			continue
+		}
+
+		prefix := lineString(line, 4)
+		if prefix != "--- " && prefix != "+++ " && prefix != "~~~ " {
+			continue
+		}
+		if current >= 0 {
+			l.sections[current].end = i
+		}
+		l.sections = append(l.sections, section{
+			start: i,
+			end:   len(lines),
+			title: screenLine{nodes: line.nodes[4:], metadata: line.metadata, apcs: line.apcs, label: line.label},
+			open:  prefix == "+++ ",
+		})
+		current = len(l.sections) - 1
+	}
+}
+
+// addGitHubActionsGroups finds groups between ::group::title and ::endgroup::
+// workflow commands, or the ##[group]title and ##[endgroup] lines they become
+// in downloaded logs. Groups don't nest: starting a group ends the current one.
+// https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#grouping-log-lines
+func (l *sectionLayout) addGitHubActionsGroups(lines []screenLine) {
+	current := -1
+	for i, line := range lines {
+		prefix := lineString(line, len("::endgroup::"))
+		switch {
+		case strings.HasPrefix(prefix, "::group::"), strings.HasPrefix(prefix, "##[group]"):
+			if current >= 0 {
+				l.sections[current].end = i
+			}
+			l.sections = append(l.sections, section{
+				start: i,
+				end:   len(lines),
+				title: screenLine{nodes: line.nodes[len("::group::"):], metadata: line.metadata, apcs: line.apcs, label: line.label},
+			})
+			current = len(l.sections) - 1
+
+		case prefix == "::endgroup::", prefix == "##[endgroup]":
+			l.hidden[i] = true
+			if current >= 0 {
+				l.sections[current].end = i
+				current = -1
+			}
+		}
+	}
+}
+
+// parseSectionMarker parses a section marker of any of the kinds enabled in
+// opts.
+func parseSectionMarker(text string, opts *Options) (sectionMarker, bool) {
+	if opts.GitLabSections && strings.HasPrefix(text, "section_") {
+		return parseGitLabSectionMarker(text)
+	}
+	if opts.TravisFolds && strings.HasPrefix(text, "travis_fold:") {
+		return parseTravisFoldMarker(text)
+	}
+	return sectionMarker{}, false
+}
+
+var gitLabSectionRegexp = regexp.MustCompile(`^section_(start|end):(\d+):([^\[\s]+)(?:\[([^\]]*)\])?$`)
+
+// parseGitLabSectionMarker parses a GitLab section marker.
+// https://docs.gitlab.com/ee/ci/jobs/#custom-collapsible-sections
+func parseGitLabSectionMarker(text string) (sectionMarker, bool) {
+	m := gitLabSectionRegexp.FindStringSubmatch(text)
+	if m == nil {
+		return sectionMarker{}, false
+	}
+	timestamp, err := strconv.ParseInt(m[2], 10, 64)
+	if err != nil {
+		return sectionMarker{}, false
+	}
+	marker := sectionMarker{start: m[1] == "start", name: m[3], timestamp: timestamp, hasTimestamp: true}
+	for _, option := range strings.Split(m[4], ",") {
+		if strings.TrimSpace(option) == "collapsed=true" {
+			marker.collapsed = true
+		}
+	}
+	return marker, true
+}
+
+var travisFoldRegexp = regexp.MustCompile(`^travis_fold:(start|end):(\S+)$`)
+
+// parseTravisFoldMarker parses a travis_fold:start:name or
+// travis_fold:end:name marker. Travis folds are collapsed by default.
+func parseTravisFoldMarker(text string) (sectionMarker, bool) {
+	m := travisFoldRegexp.FindStringSubmatch(text)
+	if m == nil {
+		return sectionMarker{}, false
+	}
+	return sectionMarker{start: m[1] == "start", name: m[2], collapsed: true}, true
+}
+
+// addMarkedSections finds sections between start and end markers recorded on
+// lines. Sections are expanded unless marked as collapsed, and may nest.
+// The rest of the start marker's line is the title (or the section's name,
+// if the line is otherwise empty), and an end marker's line is hidden if it
+// is otherwise empty.
+func (l *sectionLayout) addMarkedSections(lines []screenLine) {
+	type openSection struct {
+		index        int
+		name         string
+		timestamp    int64
+		hasTimestamp bool
+	}
+	var stack []openSection
+
+	for i, line := range lines {
+		hasStart := false
+		for _, marker := range line.markers {
+			if marker.start {
+				hasStart = true
+				title := line
+				if len(title.nodes) == 0 {
+					title.nodes = textNodes(marker.name)
+				}
+				l.sections = append(l.sections, section{
+					start: i,
+					end:   len(lines),
+					title: title,
+					open:  !marker.collapsed,
+				})
+				stack = append(stack, openSection{len(l.sections) - 1, marker.name, marker.timestamp, marker.hasTimestamp})
+				continue
+			}
+
+			// Close the most recent section with this name, and any unclosed
+			// sections inside it
+			for j := len(stack) - 1; j >= 0; j-- {
+				if stack[j].name != marker.name {
+					continue
+				}
+				s := &l.sections[stack[j].index]
+				s.end = i
+				if s.end == s.start {
+					s.end++
+				}
+				if marker.hasTimestamp && stack[j].hasTimestamp {
+					s.duration = time.Duration(marker.timestamp-stack[j].timestamp) * time.Second
+					s.hasDuration = true
+				}
+				for _, inner := range stack[j+1:] {
+					l.sections[inner.index].end = s.end
+				}
+				stack = stack[:j]
+				break
+			}
+		}
+		if len(line.markers) > 0 && !hasStart && len(line.nodes) == 0 {
+			l.hidden[i] = true
+		}
+	}
+}
+
+// collapseRepeats hides lines that are the same as the visible line before
+// them, returning the number of times each remaining line is repeated. Runs
+// don't cross the start or end of a section.
+func (l *sectionLayout) collapseRepeats(lines []screenLine) map[int]int {
+	boundaries := map[int]bool{}
+	for _, s := range l.sections {
+		boundaries[s.start] = true
+		boundaries[s.start+1] = true
+		boundaries[s.end] = true
+	}
+
+	repeats := map[int]int{}
+	first := -1
+	for i, line := range lines {
+		if l.hidden[i] {
+			continue
+		}
+		if boundaries[i] || len(line.nodes) == 0 || first < 0 || !sameNodes(lines[first].nodes, line.nodes) {
+			first = i
+			if len(line.nodes) == 0 || (boundaries[i] && l.isSectionStart(i)) {
+				first = -1
+			}
+			continue
+		}
+		if repeats[first] == 0 {
+			repeats[first] = 1
+		}
+		repeats[first]++
+		l.hidden[i] = true
+	}
+	return repeats
+}
+
+// isSectionStart reports whether line i is the header of a section.
+func (l *sectionLayout) isSectionStart(i int) bool {
+	for _, s := range l.sections {
+		if s.start == i {
+			return true
+		}
+	}
+	return false
+}
+
+// sameNodes reports whether two lines have the same content and styles.
+func sameNodes(a, b []node) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].isEqual(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// formatSectionDuration formats a duration like GitLab does, e.g. 01:02 or
+// 1:02:03.
+func formatSectionDuration(d time.Duration) string {
+	seconds := int64(d / time.Second)
+	if seconds < 0 {
+		seconds = 0
+	}
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
+
+// textNodes returns unstyled nodes for a string.
+func textNodes(text string) []node {
+	var nodes []node
+	for _, r := range text {
+		nodes = append(nodes, node{blob: r, style: &emptyStyle})
+	}
+	return nodes
+}
+
+// lineString returns the text of up to the first n nodes of a line.
+// Elements are represented by objectReplacementChar.
+func lineString(line screenLine, n int) string {
+	if n > len(line.nodes) {
+		n = len(line.nodes)
+	}
+	runes := make([]rune, n)
+	for i, node := range line.nodes[:n] {
+		r, ok := node.getRune()
+		if !ok {
+			r = objectReplacementChar
+		}
+		runes[i] = r
+	}
+	return string(runes)
+}
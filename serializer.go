@@ -0,0 +1,72 @@
+package terminal
+
+import "encoding/json"
+
+// A Serializer renders a parsed screen in some format.
+type Serializer func(s *Screen) []byte
+
+var (
+	// SerializeHTML renders the screen as HTML, like Screen.AsHTML.
+	SerializeHTML Serializer = (*Screen).AsHTML
+
+	// SerializePlainText renders the screen as plain text, like
+	// Screen.AsPlainText.
+	SerializePlainText Serializer = func(s *Screen) []byte {
+		return []byte(s.AsPlainText())
+	}
+
+	// SerializeANSI renders the screen as ANSI, like Screen.AsANSI.
+	SerializeANSI Serializer = (*Screen).AsANSI
+
+	// SerializeLines renders the HTML of each line of the screen as a JSON
+	// array of Screen.HTMLLines, e.g. [{"html":"...","number":1},...].
+	SerializeLines Serializer = func(s *Screen) []byte {
+		// Marshalling these types can't fail
+		output, _ := json.Marshal(s.HTMLLines())
+		return output
+	}
+
+	// SerializeMetadata renders information about the screen as JSON, e.g.
+	// {"lines":120,"overwrites":0,"annotations":[...],"sequences":{...}}.
+	// Annotations, search matches, frames and attachments are only included
+	// if enabled in the options.
+	SerializeMetadata Serializer = func(s *Screen) []byte {
+		metadata := struct {
+			Lines         int           `json:"lines"`
+			Overwrites    int           `json:"overwrites"`
+			Annotations   []Annotation  `json:"annotations,omitempty"`
+			SearchMatches *int          `json:"searchMatches,omitempty"`
+			Frames        []LineFrames  `json:"frames,omitempty"`
+			Attachments   []Attachment  `json:"attachments,omitempty"`
+			Sequences     SequenceStats `json:"sequences"`
+		}{
+			Lines:       s.LineCount(),
+			Overwrites:  s.Overwrites(),
+			Frames:      s.Frames(),
+			Attachments: s.Attachments(),
+			Sequences:   s.SequenceStats(),
+		}
+		if s.opts.GitHubActionsAnnotations {
+			metadata.Annotations = s.Annotations()
+		}
+		if s.opts.Search != nil {
+			count := s.SearchMatchCount()
+			metadata.SearchMatches = &count
+		}
+		// Marshalling these types can't fail
+		output, _ := json.Marshal(metadata)
+		return output
+	}
+)
+
+// RenderAll parses ANSI input once, and returns its rendering by each of the
+// serializers, e.g. to produce both HTML and plain text for a search index.
+func RenderAll(input []byte, opts Options, serializers ...Serializer) [][]byte {
+	screen := NewScreen(opts)
+	screen.parseAll(input)
+	outputs := make([][]byte, len(serializers))
+	for i, serialize := range serializers {
+		outputs[i] = serialize(screen)
+	}
+	return outputs
+}
@@ -0,0 +1,58 @@
+package terminal
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRenderAll(t *testing.T) {
+	input := []byte("\x1b[31mbuilding\x1b[0m\n::error file=main.go,line=3::oops\n50%\r100%")
+	opts := Options{GitHubActionsAnnotations: true, Search: regexp.MustCompile("o")}
+	outputs := RenderAll(input, opts, SerializeHTML, SerializePlainText, SerializeMetadata)
+
+	var got []string
+	for _, output := range outputs {
+		got = append(got, string(output))
+	}
+	want := []string{
+		string(RenderWithOptions(input, opts)),
+		"building\n::error file=main.go,line=3::oops\n100%",
+		`{"lines":3,"overwrites":1,"annotations":[{"level":"error","message":"oops","file":"main.go","line":3,"screenLine":1}],"searchMatches":4,"sequences":{"csi":{"m":2},"esc":{},"osc":{},"apc":{},"unknown":0}}`,
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("RenderAll() diff (-got +want):\n%s", diff)
+	}
+}
+
+func TestSerializeANSI(t *testing.T) {
+	input := []byte("\x1b[1;31mbuilding\x1b[0m\r\x1b[32mbuilt\x1b[0m\n\x1b[38;5;208;44morange\x1b[0m plain\n\x1b]1339;url=https://example.com;content=link\x07\n\x1b[3m")
+	got := string(RenderAll(input, Options{}, SerializeANSI)[0])
+	want := "\x1b[0;32mbuilt\x1b[0;31;1ming\x1b[0m\n" +
+		"\x1b[0;38;5;208;44morange\x1b[0m plain\n" +
+		"\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\"
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("SerializeANSI diff (-got +want):\n%s", diff)
+	}
+
+	// Rendering the result again gives the same output, apart from the link,
+	// as OSC 8 hyperlinks aren't parsed
+	got, _, _ = strings.Cut(got, "\x1b]8;;")
+	input, _, _ = bytes.Cut(input, []byte("\x1b]1339;"))
+	if got, want := string(Render([]byte(got))), string(Render(input)); got != want {
+		t.Errorf("Render(SerializeANSI) = %q, want %q", got, want)
+	}
+}
+
+func TestScreenAsANSI(t *testing.T) {
+	screen := NewScreen(Options{})
+	screen.Parse([]byte("\x1b[33mwarning\x1b[0m: 10%"))
+	screen.Parse([]byte("\r\x1b[33mwarning\x1b[0m: done\n\x1b[1mbold"))
+	want := "\x1b[0;33mwarning\x1b[0m: done\n\x1b[0;1mbold\x1b[0m"
+	if got := string(screen.AsANSI()); got != want {
+		t.Errorf("AsANSI() = %q, want %q", got, want)
+	}
+}
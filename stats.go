@@ -0,0 +1,131 @@
+package terminal
+
+import "bytes"
+
+// SequenceStats counts the escape sequences in the input, to show which
+// terminal features it uses.
+type SequenceStats struct {
+	// CSI counts control sequences (ESC [) by their final character, e.g.
+	// "m" for colours and "K" for erasing lines.
+	CSI map[string]int `json:"csi"`
+
+	// ESC counts other escape sequences by the character after ESC, e.g. "7"
+	// for saving the cursor position, or "(" for selecting a character set.
+	ESC map[string]int `json:"esc"`
+
+	// OSC counts operating system commands (ESC ]) by their code, e.g. "1337"
+	// for iTerm2 images. Only 1337, 1338 and 1339 are rendered.
+	OSC map[string]int `json:"osc"`
+
+	// APC counts application program commands (ESC _) by their namespace,
+	// e.g. "bk" for Buildkite timestamps.
+	APC map[string]int `json:"apc"`
+
+	// Unknown counts escape sequences that weren't recognised, and were
+	// rendered as text.
+	Unknown int `json:"unknown"`
+}
+
+// sequenceCounts counts escape sequences while parsing. Arrays indexed by
+// character keep counting the most common sequences cheap.
+type sequenceCounts struct {
+	csi [128]int
+	esc [128]int
+	osc map[string]int
+	apc map[string]int
+
+	// Byte offsets of unknown or malformed sequences, and the sequences as
+	// far as they were parsed
+	unknown     []int
+	unknownText []string
+}
+
+func (c *sequenceCounts) countCSI(final rune) {
+	if final < 128 {
+		c.csi[final]++
+	}
+}
+
+func (c *sequenceCounts) countESC(char rune) {
+	if char < 128 {
+		c.esc[char]++
+	}
+}
+
+// countOSC counts an OSC by its code, the part of the sequence before the
+// first semicolon.
+func (c *sequenceCounts) countOSC(sequence []byte) {
+	if c.osc == nil {
+		c.osc = map[string]int{}
+	}
+	code, _, _ := bytes.Cut(sequence, []byte(";"))
+	c.osc[string(code)]++
+}
+
+// countAPC counts an APC by its namespace, the part of the sequence before
+// the first semicolon.
+func (c *sequenceCounts) countAPC(sequence []byte) {
+	if c.apc == nil {
+		c.apc = map[string]int{}
+	}
+	namespace, _, _ := bytes.Cut(sequence, []byte(";"))
+	c.apc[string(namespace)]++
+}
+
+// SequenceStats returns counts of the escape sequences parsed so far.
+func (s *Screen) SequenceStats() SequenceStats {
+	stats := SequenceStats{
+		CSI:     map[string]int{},
+		ESC:     map[string]int{},
+		OSC:     map[string]int{},
+		APC:     map[string]int{},
+		Unknown: len(s.sequences.unknown),
+	}
+	for char, n := range s.sequences.csi {
+		if n > 0 {
+			stats.CSI[string(rune(char))] = n
+		}
+	}
+	for char, n := range s.sequences.esc {
+		if n > 0 {
+			stats.ESC[string(rune(char))] = n
+		}
+	}
+	for code, n := range s.sequences.osc {
+		stats.OSC[code] = n
+	}
+	for namespace, n := range s.sequences.apc {
+		stats.APC[namespace] = n
+	}
+	return stats
+}
+
+// UnknownSequenceOffsets returns the byte offsets in the input of the escape
+// sequences that were unknown or malformed, and so were ignored. Offsets are
+// into the input after Options.Redact is applied.
+func (s *Screen) UnknownSequenceOffsets() []int {
+	return append([]int(nil), s.sequences.unknown...)
+}
+
+// An UnknownSequence is an escape sequence that was unknown or malformed.
+type UnknownSequence struct {
+	// Offset is the sequence's byte offset in the input, as for
+	// UnknownSequenceOffsets.
+	Offset int
+
+	// Text is the sequence up to and including the character that made it
+	// unknown, as it was parsed: after Options.InputEncoding is applied,
+	// tmux's escapes for TmuxCapture undone, and Options.Redact applied.
+	Text string
+}
+
+// UnknownSequences returns the escape sequences that were unknown or
+// malformed, with their text, which unlike slicing the input with
+// UnknownSequenceOffsets works whatever the options transformed it with.
+func (s *Screen) UnknownSequences() []UnknownSequence {
+	sequences := make([]UnknownSequence, len(s.sequences.unknown))
+	for i, offset := range s.sequences.unknown {
+		sequences[i] = UnknownSequence{Offset: offset, Text: s.sequences.unknownText[i]}
+	}
+	return sequences
+}
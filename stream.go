@@ -0,0 +1,152 @@
+package terminal
+
+import (
+	"bytes"
+	"io"
+)
+
+// streamWindow is the number of lines above the cursor that a Streamer keeps
+// on its screen, like the height of a terminal. Lines further up can no
+// longer be changed by cursor movement, so are rendered and dropped.
+const streamWindow = 100
+
+// A Streamer renders ANSI input written to it as HTML, written to an
+// underlying writer as soon as lines can no longer change, so that memory use
+// stays bounded however long the input is. Call Close to render the rest of
+// the input.
+//
+// Only lines within 100 lines of the cursor can be changed by cursor
+// movement, as in a terminal. Options that look at more than one line, such
+// as collapsible groups, only see the lines rendered together.
+type Streamer struct {
+	w        io.Writer
+	screen   *Screen
+	renderer htmlRenderer
+
+	// Input after the last newline, which may end part way through an escape
+	// sequence or character
+	pending []byte
+
+	// Whether any lines have been written yet
+	written bool
+
+	// Whether the HTML written so far ends in a newline that blank line
+	// filling hasn't paired with another
+	newline bool
+}
+
+// NewStreamer returns a Streamer that writes HTML rendered with the given
+// options to w.
+func NewStreamer(w io.Writer, opts Options) *Streamer {
+	s := &Streamer{w: w, screen: NewScreen(opts)}
+	s.renderer = htmlRenderer{opts: &s.screen.opts}
+	return s
+}
+
+// Write parses the input, and writes the HTML of any lines that can no longer
+// change.
+func (s *Streamer) Write(p []byte) (int, error) {
+	s.pending = append(s.pending, p...)
+	end := bytes.LastIndexByte(s.pending, '\n')
+	if end < 0 {
+		return len(p), nil
+	}
+	s.screen.Parse(s.pending[:end+1])
+	s.pending = append(s.pending[:0], s.pending[end+1:]...)
+
+	if err := s.flush(s.screen.y - streamWindow); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close parses any remaining input, and writes the HTML of all the remaining
+// lines. It doesn't close the underlying writer.
+func (s *Streamer) Close() error {
+	if len(s.pending) > 0 {
+		s.screen.Parse(s.pending)
+		s.pending = nil
+	}
+	// Along with any bytes the input decoder or tmux unescaper have kept
+	s.screen.Close()
+	if err := s.flush(len(s.screen.screen)); err != nil {
+		return err
+	}
+	if s.screen.opts.Attachments == AttachmentsListed {
+		if _, err := io.WriteString(s.w, attachmentsHTML(s.screen.attachments)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flush renders the first n lines of the screen, and drops them from it.
+func (s *Streamer) flush(n int) error {
+	screen := s.screen
+	if n > len(screen.screen) {
+		n = len(screen.screen)
+	}
+	if n <= 0 {
+		return nil
+	}
+
+	html := s.renderer.render(screen.screen[:n])
+	if s.written {
+		if screen.opts.BreakElements {
+			html = append([]byte("<br>"), html...)
+		} else {
+			html = append([]byte("\n"), html...)
+		}
+	}
+	s.written = true
+	html = s.fillBlankLines(html)
+
+	// Keep the nodes of the dropped lines for new lines to reuse
+	for _, line := range screen.screen[:n] {
+		screen.freeNodes = append(screen.freeNodes, line.nodes[:0])
+	}
+	kept := copy(screen.screen, screen.screen[n:])
+	clear(screen.screen[kept:])
+	screen.screen = screen.screen[:kept]
+	screen.y -= n
+	if screen.y < 0 {
+		screen.y = 0
+	}
+	screen.savePosition.y -= n
+	if screen.savePosition.y < 0 {
+		screen.savePosition.y = 0
+	}
+	s.renderer.offset += n
+	screen.dropped += n
+	_, err := s.w.Write(html)
+	return err
+}
+
+// fillBlankLines fills blank lines the same as Screen.fillBlankLines would if
+// all the HTML had been rendered at once, carrying a newline that could start
+// a blank line over from the HTML written before.
+func (s *Streamer) fillBlankLines(html []byte) []byte {
+	if s.screen.opts.BreakElements {
+		return html
+	}
+	var filled []byte
+	if s.newline && bytes.HasPrefix(html, []byte("\n")) {
+		filled = []byte("&nbsp;\n")
+		html = html[1:]
+	}
+	filled = append(filled, s.screen.fillBlankLines(html)...)
+
+	// A trailing run of newlines is filled in pairs from its start, leaving
+	// the last one unpaired if there's an odd number of them
+	run := len(html) - len(bytes.TrimRight(html, "\n"))
+	s.newline = run%2 == 1
+	return filled
+}
+
+// Tail renders the lines that haven't been written yet, which may still
+// change, without writing them. Input after the last newline isn't included
+// until another newline is written or the Streamer is closed.
+func (s *Streamer) Tail() []byte {
+	r := htmlRenderer{opts: &s.screen.opts, offset: s.renderer.offset}
+	return s.screen.renderPart(&r, s.screen.screen)
+}
@@ -0,0 +1,108 @@
+package terminal
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStreamerMatchesRender(t *testing.T) {
+	for _, base := range TestFiles {
+		t.Run(base, func(t *testing.T) {
+			raw, err := os.ReadFile(fmt.Sprintf("fixtures/%s.raw", base))
+			if err != nil {
+				t.Fatalf("could not read fixture: %v", err)
+			}
+
+			// Write in small chunks, to split escape sequences and characters
+			var buf bytes.Buffer
+			s := NewStreamer(&buf, Options{})
+			for len(raw) > 0 {
+				n := 7
+				if n > len(raw) {
+					n = len(raw)
+				}
+				if _, err := s.Write(raw[:n]); err != nil {
+					t.Fatalf("s.Write() error = %v", err)
+				}
+				raw = raw[n:]
+			}
+			if err := s.Close(); err != nil {
+				t.Fatalf("s.Close() error = %v", err)
+			}
+
+			raw, _ = os.ReadFile(fmt.Sprintf("fixtures/%s.raw", base))
+			if got, want := buf.String(), string(Render(raw)); got != want {
+				t.Errorf("streamed output differs from Render()\ngot:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestStreamerWritesFinishedLines(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewStreamer(&buf, Options{LineNumbers: LineNumberDataAttribute})
+	for i := 1; i <= streamWindow+2; i++ {
+		fmt.Fprintf(s, "line %d\n", i)
+	}
+
+	want := strings.Join([]string{
+		`<span class="term-line" data-line-number="1">line 1</span>`,
+		`<span class="term-line" data-line-number="2">line 2</span>`,
+	}, "\n")
+	if got := buf.String(); got != want {
+		t.Errorf("streamed output before Close = %q, want %q", got, want)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("s.Close() error = %v", err)
+	}
+	if got, want := strings.Count(buf.String(), "\n"), streamWindow+1; got != want {
+		t.Errorf("streamed output has %d newlines, want %d", got, want)
+	}
+}
+
+func TestStreamerBlankLines(t *testing.T) {
+	// Runs of blank lines of every length, which are flushed a line at a time
+	var input strings.Builder
+	for i := 0; i < streamWindow; i++ {
+		input.WriteString("line" + strings.Repeat("\n", i%4+1))
+	}
+
+	var buf bytes.Buffer
+	s := NewStreamer(&buf, Options{})
+	for _, line := range strings.SplitAfter(input.String(), "\n") {
+		if _, err := s.Write([]byte(line)); err != nil {
+			t.Fatalf("s.Write() error = %v", err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("s.Close() error = %v", err)
+	}
+
+	if got, want := buf.String(), string(Render([]byte(input.String()))); got != want {
+		t.Errorf("streamed output differs from Render()\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestStreamerTail(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewStreamer(&buf, Options{LineNumbers: LineNumberDataAttribute})
+	for i := 1; i <= streamWindow+1; i++ {
+		fmt.Fprintf(s, "line %d\n", i)
+	}
+	s.Write([]byte("partial"))
+
+	tail := string(s.Tail())
+	if want := `<span class="term-line" data-line-number="2">line 2</span>`; !strings.HasPrefix(tail, want) {
+		t.Errorf("s.Tail() = %q, want prefix %q", tail, want)
+	}
+	if want := "line 101</span>"; !strings.HasSuffix(tail, want) {
+		t.Errorf("s.Tail() = %q, want suffix %q", tail, want)
+	}
+	if got, want := buf.String(), `<span class="term-line" data-line-number="1">line 1</span>`; got != want {
+		t.Errorf("streamed output after Tail = %q, want %q", got, want)
+	}
+}
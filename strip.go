@@ -0,0 +1,70 @@
+package terminal
+
+// Strip returns the input with its escape sequences and control characters
+// other than newlines and tabs removed, leaving the text as it was written.
+// Unlike AsPlainText, it doesn't apply cursor movement, so text overwritten
+// after a carriage return, for example, is kept.
+func Strip(input []byte) []byte {
+	b := make([]byte, 0, len(input))
+	for i := 0; i < len(input); {
+		c := input[i]
+		switch {
+		case c == '\x1b':
+			i = escapeEnd(input, i)
+		case isControl(c):
+			i++
+		default:
+			start := i
+			for i < len(input) && input[i] != '\x1b' && !isControl(input[i]) {
+				i++
+			}
+			b = append(b, input[start:i]...)
+		}
+	}
+	return b
+}
+
+// escapeEnd returns the end of the escape sequence starting at input[start],
+// or of as much of it as there is.
+func escapeEnd(input []byte, start int) int {
+	i := start + 1
+	if i >= len(input) {
+		return i
+	}
+	switch input[i] {
+	case '[':
+		// CSI: parameters and intermediates, up to a final byte
+		for i++; i < len(input); i++ {
+			if c := input[i]; c >= 0x40 && c <= 0x7e {
+				return i + 1
+			} else if c < 0x20 || c > 0x7e {
+				// Malformed, so it ends before the unexpected byte
+				return i
+			}
+		}
+		return i
+	case ']', '_', 'P', '^', 'X':
+		// OSC, APC, DCS, PM and SOS strings end with BEL or ST (ESC \)
+		for i++; i < len(input); i++ {
+			switch input[i] {
+			case '\a':
+				return i + 1
+			case '\x1b':
+				if i+1 < len(input) && input[i+1] == '\\' {
+					return i + 2
+				}
+				return i
+			}
+		}
+		return i
+	default:
+		// Any intermediate bytes, e.g. ESC ( B, then a final byte
+		for i < len(input) && input[i] >= 0x20 && input[i] <= 0x2f {
+			i++
+		}
+		if i < len(input) && input[i] >= 0x30 && input[i] <= 0x7e {
+			i++
+		}
+		return i
+	}
+}
@@ -0,0 +1,29 @@
+package terminal
+
+import "testing"
+
+func TestStrip(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain text", "hello\tworld\n", "hello\tworld\n"},
+		{"styles", "\x1b[1;31merror:\x1b[0m failed", "error: failed"},
+		{"private and intermediate CSI", "\x1b[?25lhidden\x1b[?25h \x1b[2 qcursor", "hidden cursor"},
+		{"cursor movement isn't applied", "50%\r\x1b[K100%\x1b[2A\bdone", "50%100%done"},
+		{"OSC ended by BEL", "\x1b]0;title\alink \x1b]8;;https://example.com\x1b\\text\x1b]8;;\x1b\\", "link text"},
+		{"APC timestamps", "\x1b_bk;t=1684881360000\x07line", "line"},
+		{"images", "\x1b]1337;File=name=a.png;inline=1:AAAA\x07after", "after"},
+		{"two-byte escapes", "\x1b(Bcharset \x1b7saved\x1b8", "charset saved"},
+		{"malformed CSI", "\x1b[31\nnext", "\nnext"},
+		{"unterminated sequences", "text\x1b]0;no end", "text"},
+		{"lone escape at the end", "text\x1b", "text"},
+		{"multibyte text", "café \x1b[32m✓\x1b[0m", "café ✓"},
+	}
+	for _, tc := range testCases {
+		if got := string(Strip([]byte(tc.input))); got != tc.expected {
+			t.Errorf("%s: Strip(%q) = %q, want %q", tc.name, tc.input, got, tc.expected)
+		}
+	}
+}
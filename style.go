@@ -1,6 +1,11 @@
 package terminal
 
-import "strconv"
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
 
 var emptyStyle = style{}
 
@@ -30,52 +35,95 @@ func (s *style) isEqual(o *style) bool {
 	return s == o || *s == *o
 }
 
-// CSS classes that make up the style
-func (s *style) asClasses() []string {
-	var styles []string
+// The kinds of class a style's colours and attributes are rendered as, e.g.
+// term-fgx for an XTerm foreground colour
+const (
+	classFG = iota
+	classFGI
+	classFGX
+	classBG
+	classBGI
+	classBGX
+)
+
+// classNames returns every class a style can have, indexed by kind and
+// number, so that rendering doesn't build the same names over and over. The
+// table is built the first time it's needed.
+var classNames = sync.OnceValue(func() *[classBGX + 1][256]string {
+	var names [classBGX + 1][256]string
+	for kind, prefix := range []string{"term-fg", "term-fgi", "term-fgx", "term-bg", "term-bgi", "term-bgx"} {
+		for n := range names[kind] {
+			names[kind][n] = prefix + strconv.Itoa(n)
+		}
+	}
+	return &names
+})
+
+// CSS classes that make up the style, appended to b separated by spaces
+func (s *style) appendClasses(b []byte) []byte {
+	start := len(b)
+	names := classNames()
+	class := func(kind int, n uint8) {
+		if len(b) > start {
+			b = append(b, ' ')
+		}
+		b = append(b, names[kind][n]...)
+	}
 
 	if s.fgColor > 0 && s.fgColor < 38 && !s.fgColorX {
-		styles = append(styles, "term-fg"+strconv.Itoa(int(s.fgColor)))
+		class(classFG, s.fgColor)
 	}
 	if s.fgColor > 38 && !s.fgColorX {
-		styles = append(styles, "term-fgi"+strconv.Itoa(int(s.fgColor)))
-
+		class(classFGI, s.fgColor)
 	}
 	if s.fgColorX {
-		styles = append(styles, "term-fgx"+strconv.Itoa(int(s.fgColor)))
-
+		class(classFGX, s.fgColor)
 	}
 
 	if s.bgColor > 0 && s.bgColor < 48 && !s.bgColorX {
-		styles = append(styles, "term-bg"+strconv.Itoa(int(s.bgColor)))
+		class(classBG, s.bgColor)
 	}
 	if s.bgColor > 48 && !s.bgColorX {
-		styles = append(styles, "term-bgi"+strconv.Itoa(int(s.bgColor)))
+		class(classBGI, s.bgColor)
 	}
 	if s.bgColorX {
-		styles = append(styles, "term-bgx"+strconv.Itoa(int(s.bgColor)))
+		class(classBGX, s.bgColor)
 	}
 
 	if s.bold {
-		styles = append(styles, "term-fg1")
+		class(classFG, 1)
 	}
 	if s.faint {
-		styles = append(styles, "term-fg2")
+		class(classFG, 2)
 	}
 	if s.italic {
-		styles = append(styles, "term-fg3")
+		class(classFG, 3)
 	}
 	if s.underline {
-		styles = append(styles, "term-fg4")
+		class(classFG, 4)
 	}
 	if s.blink {
-		styles = append(styles, "term-fg5")
+		class(classFG, 5)
 	}
 	if s.strike {
-		styles = append(styles, "term-fg9")
+		class(classFG, 9)
 	}
 
-	return styles
+	return b
+}
+
+// looksSameBlank reports whether a space in the style looks the same as one
+// in style o. Only the background and attributes drawn across the space, and
+// the colour they are drawn in, can be seen.
+func (s *style) looksSameBlank(o *style) bool {
+	if s.bgColor != o.bgColor || s.bgColorX != o.bgColorX || s.blink != o.blink ||
+		s.underline != o.underline || s.strike != o.strike {
+		return false
+	}
+	if s.underline || s.strike {
+		return s.fgColor == o.fgColor && s.fgColorX == o.fgColorX && s.faint == o.faint
+	}
+	return true
 }
 
 // True if style is empty
@@ -83,23 +131,29 @@ func (s *style) isEmpty() bool {
 	return *s == style{}
 }
 
-// Add colours to an existing style, potentially returning
-// a new style.
-func (s *style) color(colors []string) *style {
-	if len(colors) == 1 && (colors[0] == "0" || colors[0] == "") {
+// Add colours to an existing style, returning the new style. With skip24Bit,
+// the red, green and blue of 24-bit colors, e.g. 38;2;255;0;0, are skipped
+// rather than read as codes of their own.
+func (s *style) color(colors [][]byte, skip24Bit bool) style {
+	if len(colors) == 1 && (string(colors[0]) == "0" || len(colors[0]) == 0) {
 		// Shortcut for full style reset
-		return &emptyStyle
+		return emptyStyle
 	}
 
-	newStyle := style(*s)
-	s = &newStyle
+	next := *s
 	color_mode := COLOR_NORMAL
+	// The red, green and blue left of a 24-bit color to skip
+	skip := 0
 
 	for _, ccs := range colors {
+		if skip > 0 {
+			skip--
+			continue
+		}
 		// If multiple colors are defined, i.e. \e[30;42m\e then loop through each
-		// one, and assign it to s.fgColor or s.bgColor
-		cc, err := strconv.ParseUint(ccs, 10, 8)
-		if err != nil {
+		// one, and assign it to next.fgColor or next.bgColor
+		cc, ok := parseUint(ccs)
+		if !ok || cc > math.MaxUint8 {
 			continue
 		}
 
@@ -110,6 +164,9 @@ func (s *style) color(colors []string) *style {
 				color_mode = COLOR_GOT_38
 			} else {
 				color_mode = COLOR_NORMAL
+				if cc == 2 && skip24Bit {
+					skip = 3
+				}
 			}
 			continue
 		case COLOR_GOT_48_NEED_5:
@@ -117,67 +174,195 @@ func (s *style) color(colors []string) *style {
 				color_mode = COLOR_GOT_48
 			} else {
 				color_mode = COLOR_NORMAL
+				if cc == 2 && skip24Bit {
+					skip = 3
+				}
 			}
 			continue
 		case COLOR_GOT_38:
-			s.fgColor = uint8(cc)
-			s.fgColorX = true
+			next.fgColor = uint8(cc)
+			next.fgColorX = true
 			color_mode = COLOR_NORMAL
 			continue
 		case COLOR_GOT_48:
-			s.bgColor = uint8(cc)
-			s.bgColorX = true
+			next.bgColor = uint8(cc)
+			next.bgColorX = true
 			color_mode = COLOR_NORMAL
 			continue
 		}
 
 		switch cc {
 		case 0:
-			// Reset all styles - don't use &emptyStyle here as we could end up adding colours
-			// in this same action.
-			s = &style{}
+			// Reset all styles, though colours could still be added in this
+			// same action.
+			next = style{}
 		case 1:
-			s.bold = true
-			s.faint = false
+			next.bold = true
+			next.faint = false
 		case 2:
-			s.faint = true
-			s.bold = false
+			next.faint = true
+			next.bold = false
 		case 3:
-			s.italic = true
+			next.italic = true
 		case 4:
-			s.underline = true
+			next.underline = true
 		case 5, 6:
-			s.blink = true
+			next.blink = true
 		case 9:
-			s.strike = true
+			next.strike = true
 		case 21, 22:
-			s.bold = false
-			s.faint = false
+			next.bold = false
+			next.faint = false
 		case 23:
-			s.italic = false
+			next.italic = false
 		case 24:
-			s.underline = false
+			next.underline = false
 		case 25:
-			s.blink = false
+			next.blink = false
 		case 29:
-			s.strike = false
+			next.strike = false
 		case 38:
 			color_mode = COLOR_GOT_38_NEED_5
 		case 39:
-			s.fgColor = 0
-			s.fgColorX = false
+			next.fgColor = 0
+			next.fgColorX = false
 		case 48:
 			color_mode = COLOR_GOT_48_NEED_5
 		case 49:
-			s.bgColor = 0
-			s.bgColorX = false
+			next.bgColor = 0
+			next.bgColorX = false
 		case 30, 31, 32, 33, 34, 35, 36, 37, 90, 91, 92, 93, 94, 95, 96, 97:
-			s.fgColor = uint8(cc)
-			s.fgColorX = false
+			next.fgColor = uint8(cc)
+			next.fgColorX = false
 		case 40, 41, 42, 43, 44, 45, 46, 47, 100, 101, 102, 103, 104, 105, 106, 107:
-			s.bgColor = uint8(cc)
-			s.bgColorX = false
+			next.bgColor = uint8(cc)
+			next.bgColorX = false
 		}
 	}
-	return s
+	return next
+}
+
+// SGR sequence that sets the style, starting from no style
+func (s *style) asSGR() string {
+	params := []string{"0"}
+
+	if s.fgColorX {
+		params = append(params, "38;5;"+strconv.Itoa(int(s.fgColor)))
+	} else if s.fgColor > 0 {
+		params = append(params, strconv.Itoa(int(s.fgColor)))
+	}
+	if s.bgColorX {
+		params = append(params, "48;5;"+strconv.Itoa(int(s.bgColor)))
+	} else if s.bgColor > 0 {
+		params = append(params, strconv.Itoa(int(s.bgColor)))
+	}
+
+	if s.bold {
+		params = append(params, "1")
+	}
+	if s.faint {
+		params = append(params, "2")
+	}
+	if s.italic {
+		params = append(params, "3")
+	}
+	if s.underline {
+		params = append(params, "4")
+	}
+	if s.blink {
+		params = append(params, "5")
+	}
+	if s.strike {
+		params = append(params, "9")
+	}
+
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+// A Style is how text looks in a terminal, as set by SGR escape sequences
+// such as \x1b[1;31m.
+type Style struct {
+	// Foreground and Background are the SGR codes of the colours, e.g. 31 for
+	// a red foreground or 104 for a bright blue background, or 0 for the
+	// default colours. If Foreground256 or Background256 is set, they're
+	// instead one of the 256 colours, as in \x1b[38;5;208m.
+	Foreground, Background       uint8
+	Foreground256, Background256 bool
+
+	Bold, Faint, Italic, Underline, Strike, Blink bool
+}
+
+// StyleTransition returns the shortest SGR escape sequence that changes text
+// in the style from to the style to, or "" if they're the same. It either
+// turns the attributes that differ on and off, or resets the style and sets
+// all of to, whichever is shorter.
+func StyleTransition(from, to Style) string {
+	if from == to {
+		return ""
+	}
+	reset := toStyle(to).asSGR()
+	if from == (Style{}) {
+		// Nothing to turn off, so there's no need to reset
+		return "\x1b[" + strings.TrimPrefix(strings.TrimPrefix(reset, "\x1b[0"), ";")
+	}
+	if changes := styleChanges(from, to); len(changes) < len(reset) {
+		return changes
+	}
+	return reset
+}
+
+// styleChanges returns an SGR escape sequence turning on and off the
+// attributes that differ between the styles.
+func styleChanges(from, to Style) string {
+	var params []string
+	color := func(code uint8, extended bool, reset, prefix string) {
+		switch {
+		case extended:
+			params = append(params, prefix+strconv.Itoa(int(code)))
+		case code == 0:
+			params = append(params, reset)
+		default:
+			params = append(params, strconv.Itoa(int(code)))
+		}
+	}
+	if from.Foreground != to.Foreground || from.Foreground256 != to.Foreground256 {
+		color(to.Foreground, to.Foreground256, "39", "38;5;")
+	}
+	if from.Background != to.Background || from.Background256 != to.Background256 {
+		color(to.Background, to.Background256, "49", "48;5;")
+	}
+
+	// Bold and faint are turned off together
+	if (from.Bold && !to.Bold) || (from.Faint && !to.Faint) {
+		params = append(params, "22")
+		from.Bold, from.Faint = false, false
+	}
+	for _, a := range [...]struct {
+		from, to bool
+		on, off  string
+	}{
+		{from.Bold, to.Bold, "1", ""},
+		{from.Faint, to.Faint, "2", ""},
+		{from.Italic, to.Italic, "3", "23"},
+		{from.Underline, to.Underline, "4", "24"},
+		{from.Blink, to.Blink, "5", "25"},
+		{from.Strike, to.Strike, "9", "29"},
+	} {
+		if a.to && !a.from {
+			params = append(params, a.on)
+		} else if a.from && !a.to {
+			params = append(params, a.off)
+		}
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+// toStyle converts a Style to the parser's style.
+func toStyle(s Style) *style {
+	return &style{
+		fgColor: s.Foreground, bgColor: s.Background,
+		fgColorX: s.Foreground256, bgColorX: s.Background256,
+		bold: s.Bold, faint: s.Faint, italic: s.Italic,
+		underline: s.Underline, strike: s.Strike, blink: s.Blink,
+	}
 }
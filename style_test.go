@@ -0,0 +1,30 @@
+package terminal
+
+import "testing"
+
+func TestStyleTransition(t *testing.T) {
+	red := Style{Foreground: 31}
+	boldRed := Style{Foreground: 31, Bold: true}
+	testCases := []struct {
+		name     string
+		from, to Style
+		expected string
+	}{
+		{"same style", boldRed, boldRed, ""},
+		{"from the default", Style{}, boldRed, "\x1b[31;1m"},
+		{"to the default", boldRed, Style{}, "\x1b[0m"},
+		{"adds an attribute", red, boldRed, "\x1b[1m"},
+		{"removes an attribute", boldRed, red, "\x1b[22m"},
+		{"turns bold off but keeps faint", Style{Bold: true, Faint: true, Foreground: 32}, Style{Faint: true, Foreground: 32}, "\x1b[22;2m"},
+		{"changes colour", red, Style{Foreground: 92}, "\x1b[92m"},
+		{"to the default colour", Style{Foreground: 31, Background: 44, Italic: true}, Style{Background: 44, Italic: true}, "\x1b[39m"},
+		{"256 colours", red, Style{Foreground: 208, Foreground256: true}, "\x1b[38;5;208m"},
+		{"256 colour black isn't the default", Style{Background: 1, Background256: true}, Style{Background: 0, Background256: true}, "\x1b[48;5;0m"},
+		{"resets when that's shorter", Style{Bold: true, Italic: true, Underline: true, Strike: true}, Style{Foreground: 33}, "\x1b[0;33m"},
+	}
+	for _, tc := range testCases {
+		if got := StyleTransition(tc.from, tc.to); got != tc.expected {
+			t.Errorf("%s: StyleTransition(%+v, %+v) = %q, want %q", tc.name, tc.from, tc.to, got, tc.expected)
+		}
+	}
+}
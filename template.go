@@ -0,0 +1,48 @@
+package terminal
+
+import (
+	"fmt"
+	"html/template"
+
+	"github.com/buildkite/terminal-to-html/v3/internal/assets"
+)
+
+// TemplateFuncs returns functions for rendering terminal output in
+// html/template templates:
+//
+//   - renderTerminal renders a string or []byte of ANSI input with opts, as
+//     template.HTML so that it isn't escaped again.
+//   - terminalCSS returns the stylesheet for the HTML, as template.CSS,
+//     optionally in one of the themes "dark" (the default), "light",
+//     "solarized-dark", "dracula", "colorblind", "colorblind-light" or "high-contrast".
+//
+// For example:
+//
+//	<style>{{terminalCSS}}</style>
+//	<div class="term-container">{{renderTerminal .Log}}</div>
+func TemplateFuncs(opts Options) template.FuncMap {
+	return template.FuncMap{
+		"renderTerminal": func(input any) (template.HTML, error) {
+			switch input := input.(type) {
+			case string:
+				return template.HTML(RenderWithOptions([]byte(input), opts)), nil
+			case []byte:
+				return template.HTML(RenderWithOptions(input, opts)), nil
+			default:
+				return "", fmt.Errorf("renderTerminal: can't render %T, only string or []byte", input)
+			}
+		},
+		"terminalCSS": func(theme ...string) (template.CSS, error) {
+			name := assets.Themes[0].Name
+			if len(theme) > 0 {
+				name = theme[0]
+			}
+			t, ok := assets.FindTheme(name)
+			if !ok {
+				return "", fmt.Errorf("terminalCSS: unknown theme %q", name)
+			}
+			css, err := assets.ThemeCSS(t)
+			return template.CSS(css), err
+		},
+	}
+}
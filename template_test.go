@@ -0,0 +1,46 @@
+package terminal
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+
+	"github.com/buildkite/terminal-to-html/v3/internal/assets"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	tmpl := template.Must(template.New("log").Funcs(TemplateFuncs(Options{})).Parse(
+		`<style>{{terminalCSS "light"}}</style><div class="term-container">{{renderTerminal .Log}}</div>{{renderTerminal .Bytes}}`,
+	))
+
+	var buf strings.Builder
+	err := tmpl.Execute(&buf, map[string]any{
+		"Log":   "\x1b[31m<red>\x1b[0m",
+		"Bytes": []byte("plain & simple"),
+	})
+	if err != nil {
+		t.Fatalf("tmpl.Execute() error = %v", err)
+	}
+
+	got := buf.String()
+	if want := `<div class="term-container"><span class="term-fg31">&lt;red&gt;</span></div>plain &amp; simple`; !strings.HasSuffix(got, want) {
+		t.Errorf("tmpl.Execute() = %q, want suffix %q", got, want)
+	}
+	if want := ".term-container { background: #ffffff;"; !strings.Contains(got, want) {
+		t.Errorf("tmpl.Execute() doesn't contain the light theme's CSS %q", want)
+	}
+
+	for _, theme := range assets.Themes {
+		tmpl := template.Must(template.New("theme").Funcs(TemplateFuncs(Options{})).Parse(`{{terminalCSS .}}`))
+		if err := tmpl.Execute(&buf, theme.Name); err != nil {
+			t.Errorf("terminalCSS %q error = %v", theme.Name, err)
+		}
+	}
+
+	for _, text := range []string{`{{renderTerminal 1}}`, `{{terminalCSS "nope"}}`} {
+		tmpl := template.Must(template.New("bad").Funcs(TemplateFuncs(Options{})).Parse(text))
+		if err := tmpl.Execute(&buf, nil); err == nil {
+			t.Errorf("%s executed without error", text)
+		}
+	}
+}
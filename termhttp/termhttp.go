@@ -0,0 +1,197 @@
+/*
+Package termhttp serves raw terminal logs as HTML.
+
+A Handler renders logs from an fs.FS, or loaded by a callback, and Middleware
+renders the responses of a handler that serves raw logs. Both support
+conditional requests with ETags, and Range requests. The HTML needs to be
+used with the stylesheet and wrapped in a term-container div, as described in
+package terminal.
+*/
+package termhttp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/buildkite/terminal-to-html/v3"
+)
+
+// A Handler renders raw logs as HTML.
+type Handler struct {
+	// FS contains the logs, served at their paths, e.g. a request for
+	// /builds/1.log serves builds/1.log. Use http.StripPrefix to serve them
+	// under a prefix.
+	FS fs.FS
+
+	// Load, if set, is used instead of FS to load the log for a request,
+	// along with when it was last modified, which may be zero if unknown.
+	// Errors wrapping fs.ErrNotExist or fs.ErrPermission result in a 404 or
+	// 403 response.
+	Load func(r *http.Request) (log []byte, modTime time.Time, err error)
+
+	// Options are the options to render the logs with.
+	Options terminal.Options
+
+	// MaxBytes, if more than 0, is the most bytes of a log, once
+	// decompressed, that are rendered. Larger logs, such as those
+	// decompressing to far more than they were, get a 413 response.
+	MaxBytes int64
+}
+
+// errTooLarge is the error for a log larger than Handler.MaxBytes.
+var errTooLarge = errors.New("log is too large")
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	load := h.Load
+	if load == nil {
+		load = h.loadFile
+	}
+	raw, modTime, err := load(r)
+	if err != nil {
+		serveError(w, err)
+		return
+	}
+	serveLog(w, r, raw, modTime, h.Options, h.MaxBytes)
+}
+
+// loadFile loads the log at the request's path from h.FS.
+func (h *Handler) loadFile(r *http.Request) ([]byte, time.Time, error) {
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" {
+		return nil, time.Time{}, fs.ErrNotExist
+	}
+	f, err := h.FS.Open(name)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if info.IsDir() {
+		return nil, time.Time{}, fs.ErrNotExist
+	}
+	raw, err := io.ReadAll(f)
+	return raw, info.ModTime(), err
+}
+
+// Middleware renders the successful responses of next, which serves raw
+// logs, as HTML. Other responses are passed through as they are. The logs
+// are decompressed without limit, so next should only serve compressed logs
+// that are trusted; use a Handler with MaxBytes otherwise.
+func Middleware(next http.Handler, opts terminal.Options) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The whole log is needed to render it, so leave ranges and
+		// conditions to be handled on the rendered HTML
+		inner := r.Clone(r.Context())
+		for _, header := range []string{"Range", "If-Range", "If-Match", "If-None-Match", "If-Modified-Since", "If-Unmodified-Since"} {
+			inner.Header.Del(header)
+		}
+		rec := &recorder{header: http.Header{}, status: http.StatusOK}
+		next.ServeHTTP(rec, inner)
+
+		if rec.status != http.StatusOK {
+			for key, values := range rec.header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(rec.status)
+			w.Write(rec.body.Bytes())
+			return
+		}
+
+		// Keep headers such as Cache-Control, but not those describing the
+		// raw log
+		for key, values := range rec.header {
+			switch key {
+			case "Content-Length", "Content-Type", "Content-Encoding", "Etag", "Last-Modified", "Accept-Ranges":
+			default:
+				w.Header()[key] = values
+			}
+		}
+		modTime, _ := http.ParseTime(rec.header.Get("Last-Modified"))
+		serveLog(w, r, rec.body.Bytes(), modTime, opts, 0)
+	})
+}
+
+// serveLog renders the log and serves the HTML, handling conditional and
+// Range requests. Logs of more than maxBytes, if it's more than 0, once
+// decompressed, aren't rendered.
+func serveLog(w http.ResponseWriter, r *http.Request, raw []byte, modTime time.Time, opts terminal.Options, maxBytes int64) {
+	decompressed, err := terminal.Decompress(bytes.NewReader(raw))
+	if err == nil {
+		var input io.Reader = decompressed
+		if maxBytes > 0 {
+			// Plus one to tell if there's more
+			input = io.LimitReader(decompressed, maxBytes+1)
+		}
+		raw, err = io.ReadAll(input)
+		decompressed.Close()
+		if err == nil && maxBytes > 0 && int64(len(raw)) > maxBytes {
+			err = errTooLarge
+		}
+	}
+	if err != nil {
+		serveError(w, err)
+		return
+	}
+
+	html := terminal.RenderWithOptions(raw, opts)
+	sum := sha256.Sum256(html)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	http.ServeContent(w, r, "", modTime, bytes.NewReader(html))
+}
+
+// serveError responds with the status suiting the error.
+func serveError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		http.Error(w, "Not found", http.StatusNotFound)
+	case errors.Is(err, fs.ErrPermission):
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	case errors.Is(err, errTooLarge):
+		http.Error(w, "Log too large", http.StatusRequestEntityTooLarge)
+	default:
+		http.Error(w, "Error loading log", http.StatusInternalServerError)
+	}
+}
+
+// recorder records a response, for Middleware to render.
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+	wrote  bool
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if !r.wrote {
+		r.status = status
+		r.wrote = true
+	}
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(p)
+}
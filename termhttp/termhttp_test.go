@@ -0,0 +1,162 @@
+package termhttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/buildkite/terminal-to-html/v3"
+)
+
+var (
+	testLog     = "\x1b[31mbuilding\x1b[0m\ndone"
+	testHTML    = string(terminal.Render([]byte(testLog)))
+	testModTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+)
+
+func testFS() fs.FS {
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte(testLog))
+	w.Close()
+
+	return fstest.MapFS{
+		"builds/1.log":    {Data: []byte(testLog), ModTime: testModTime},
+		"builds/2.log.gz": {Data: gz.Bytes(), ModTime: testModTime},
+	}
+}
+
+func get(t *testing.T, h http.Handler, path string, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	for key, value := range headers {
+		r.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestHandler(t *testing.T) {
+	h := &Handler{FS: testFS()}
+
+	testCases := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{path: "/builds/1.log", status: http.StatusOK, body: testHTML},
+		{path: "/builds/2.log.gz", status: http.StatusOK, body: testHTML},
+		{path: "/builds/../builds/1.log", status: http.StatusOK, body: testHTML},
+		{path: "/builds/3.log", status: http.StatusNotFound, body: "Not found\n"},
+		{path: "/builds", status: http.StatusNotFound, body: "Not found\n"},
+		{path: "/", status: http.StatusNotFound, body: "Not found\n"},
+	}
+	for _, tc := range testCases {
+		w := get(t, h, tc.path, nil)
+		if w.Code != tc.status || w.Body.String() != tc.body {
+			t.Errorf("GET %s = %d %q, want %d %q", tc.path, w.Code, w.Body.String(), tc.status, tc.body)
+		}
+	}
+}
+
+func TestHandlerMaxBytes(t *testing.T) {
+	h := &Handler{FS: testFS(), MaxBytes: int64(len(testLog))}
+	for _, path := range []string{"/builds/1.log", "/builds/2.log.gz"} {
+		if w := get(t, h, path, nil); w.Code != http.StatusOK || w.Body.String() != testHTML {
+			t.Errorf("GET %s = %d %q, want %d %q", path, w.Code, w.Body.String(), http.StatusOK, testHTML)
+		}
+	}
+
+	// With a byte less, both are too large, the compressed one once
+	// decompressed
+	h.MaxBytes--
+	for _, path := range []string{"/builds/1.log", "/builds/2.log.gz"} {
+		if w := get(t, h, path, nil); w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("GET %s with MaxBytes = %d, want %d", path, w.Code, http.StatusRequestEntityTooLarge)
+		}
+	}
+}
+
+func TestHandlerConditionalAndRange(t *testing.T) {
+	h := &Handler{FS: testFS()}
+
+	w := get(t, h, "/builds/1.log", nil)
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatalf("GET /builds/1.log has no ETag")
+	}
+	if got, want := w.Header().Get("Content-Type"), "text/html; charset=utf-8"; got != want {
+		t.Errorf("GET /builds/1.log Content-Type = %q, want %q", got, want)
+	}
+	if got, want := w.Header().Get("Last-Modified"), testModTime.Format(http.TimeFormat); got != want {
+		t.Errorf("GET /builds/1.log Last-Modified = %q, want %q", got, want)
+	}
+
+	w = get(t, h, "/builds/1.log", map[string]string{"If-None-Match": etag})
+	if w.Code != http.StatusNotModified {
+		t.Errorf("GET /builds/1.log with If-None-Match = %d, want %d", w.Code, http.StatusNotModified)
+	}
+
+	w = get(t, h, "/builds/1.log", map[string]string{"Range": "bytes=0-9"})
+	if w.Code != http.StatusPartialContent || w.Body.String() != testHTML[:10] {
+		t.Errorf("GET /builds/1.log with Range = %d %q, want %d %q", w.Code, w.Body.String(), http.StatusPartialContent, testHTML[:10])
+	}
+}
+
+func TestHandlerLoad(t *testing.T) {
+	h := &Handler{
+		Load: func(r *http.Request) ([]byte, time.Time, error) {
+			switch r.URL.Query().Get("job") {
+			case "1":
+				return []byte(testLog), time.Time{}, nil
+			case "2":
+				return nil, time.Time{}, fmt.Errorf("job 2: %w", fs.ErrPermission)
+			default:
+				return nil, time.Time{}, fmt.Errorf("backend unavailable")
+			}
+		},
+		Options: terminal.Options{LineNumbers: terminal.LineNumberDataAttribute},
+	}
+
+	want := string(terminal.RenderWithOptions([]byte(testLog), h.Options))
+	if w := get(t, h, "/?job=1", nil); w.Code != http.StatusOK || w.Body.String() != want {
+		t.Errorf("GET /?job=1 = %d %q, want %d %q", w.Code, w.Body.String(), http.StatusOK, want)
+	}
+	if w := get(t, h, "/?job=2", nil); w.Code != http.StatusForbidden {
+		t.Errorf("GET /?job=2 = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if w := get(t, h, "/?job=3", nil); w.Code != http.StatusInternalServerError {
+		t.Errorf("GET /?job=3 = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	h := Middleware(http.FileServer(http.FS(testFS())), terminal.Options{})
+
+	w := get(t, h, "/builds/1.log", nil)
+	if w.Code != http.StatusOK || w.Body.String() != testHTML {
+		t.Errorf("GET /builds/1.log = %d %q, want %d %q", w.Code, w.Body.String(), http.StatusOK, testHTML)
+	}
+	etag := w.Header().Get("ETag")
+
+	// Conditions and ranges apply to the rendered HTML, not the raw log
+	w = get(t, h, "/builds/1.log", map[string]string{"If-None-Match": etag})
+	if w.Code != http.StatusNotModified {
+		t.Errorf("GET /builds/1.log with If-None-Match = %d, want %d", w.Code, http.StatusNotModified)
+	}
+	w = get(t, h, "/builds/1.log", map[string]string{"Range": "bytes=5-"})
+	if w.Code != http.StatusPartialContent || w.Body.String() != testHTML[5:] {
+		t.Errorf("GET /builds/1.log with Range = %d %q, want %d %q", w.Code, w.Body.String(), http.StatusPartialContent, testHTML[5:])
+	}
+
+	if w := get(t, h, "/builds/3.log", nil); w.Code != http.StatusNotFound {
+		t.Errorf("GET /builds/3.log = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
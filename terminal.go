@@ -10,12 +10,52 @@ GO111MODULE=on go install github.com/buildkite/terminal-to-html/v3/cmd/terminal-
 */
 package terminal
 
-import "bytes"
-
 // Render converts ANSI to HTML and returns the result.
 func Render(input []byte) []byte {
-	screen := screen{}
-	screen.parse(input)
-	output := bytes.Replace(screen.asHTML(), []byte("\n\n"), []byte("\n&nbsp;\n"), -1)
-	return output
+	return RenderWithOptions(input, Options{})
+}
+
+// RenderWithOptions converts ANSI to HTML using the given options and returns
+// the result.
+func RenderWithOptions(input []byte, opts Options) []byte {
+	if opts.InputEncoding != nil || opts.DetectInputEncoding {
+		// Transcoded in one go, so that the rest only sees UTF-8
+		input = decodeInput(input, opts)
+		opts.InputEncoding, opts.DetectInputEncoding = nil, false
+	}
+	if opts.isZero() {
+		if html, ok := renderPlain(input); ok {
+			return html
+		}
+	}
+	// appendOnly can't see the escapes of a tmux capture taken with -C, and
+	// doesn't know that ConPTY moves the cursor up with \x1b[row;colH
+	if len(input) > appendOnlyChunk && !opts.spansLines() && !opts.TmuxCapture && !opts.ConPTY && appendOnly(input) {
+		return renderAppendOnly(input, opts)
+	}
+	screen := NewScreen(opts)
+	screen.parseAll(input)
+	return screen.AsHTML()
+}
+
+// RenderPages converts ANSI to HTML, split into pages of linesPerPage lines,
+// and returns the pages along with the number of pages. To render pages
+// lazily, or with options, use Screen.Page.
+func RenderPages(input []byte, linesPerPage int) ([][]byte, int) {
+	screen := NewScreen(Options{})
+	screen.parseAll(input)
+	count := screen.PageCount(linesPerPage)
+	pages := make([][]byte, count)
+	for i := range pages {
+		pages[i] = screen.Page(i, linesPerPage)
+	}
+	return pages, count
+}
+
+// RenderLines converts ANSI to HTML using the given options, and returns the
+// HTML of each line separately, as Screen.HTMLLines does.
+func RenderLines(input []byte, opts Options) []HTMLLine {
+	screen := NewScreen(opts)
+	screen.parseAll(input)
+	return screen.HTMLLines()
 }
@@ -1,11 +1,17 @@
 package terminal
 
 import (
+	"bytes"
 	"encoding/base64"
 	"fmt"
+	"math"
 	"os"
+	"regexp"
 	"strings"
 	"testing"
+	"unicode/utf8"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 var TestFiles = []string{
@@ -150,9 +156,9 @@ var rendererTestCases = []struct {
 		"this is a big long bit of terminal output\nplease pay it no mind, we will clear it soon\nokay, get ready for a disappearing act...\nand...and...\n\n\x1b[2Jhey presto",
 		"hey presto",
 	}, {
-		`allows clearing the entire scrollback buffer with escape 3J also`,
-		"this is a big long bit of terminal output\nplease pay it no mind, we will clear it soon\nokay, get ready for a disappearing act...\nand...and...\n\n\x1b[2Jhey presto",
-		"hey presto",
+		`doesn't clear anything with escape 3J, as there's no scrollback`,
+		"this is a big long bit of terminal output\nplease pay it no mind\n\x1b[3Jhey presto",
+		"this is a big long bit of terminal output\nplease pay it no mind\nhey presto",
 	}, {
 		`allows erasing the current line up to a point`,
 		"hello friend\x1b[1K!",
@@ -181,6 +187,10 @@ var rendererTestCases = []struct {
 		`escapes HTML`,
 		"hello <strong>friend</strong>",
 		"hello &lt;strong&gt;friend&lt;&#47;strong&gt;",
+	}, {
+		`escapes every HTML special character`,
+		"a & b's \"c\" <d/> é",
+		"a &amp; b&#39;s &quot;c&quot; &lt;d&#47;&gt; é",
 	}, {
 		`escapes HTML in color codes`,
 		"hello \x1b[\"hellomfriend",
@@ -193,6 +203,18 @@ var rendererTestCases = []struct {
 		`does not attempt to incorrectly nest CSS in HTML (https://github.com/buildkite/terminal-to-html/issues/36)`,
 		"Some plain text\x1b[0;30;42m yay a green background \x1b[0m\x1b[0;33;49mnow this has no background but is yellow \x1b[0m",
 		"Some plain text<span class=\"term-fg30 term-bg42\"> yay a green background </span><span class=\"term-fg33\">now this has no background but is yellow </span>",
+	}, {
+		`keeps text in the same span across spaces left by erasing`,
+		"\x1b[32mone two three\r\x1b[4C\x1b[0m   ",
+		"<span class=\"term-fg32\">one     three</span>",
+	}, {
+		`renders coloured spaces between unstyled text without a span`,
+		"a\x1b[31m \x1b[0mb",
+		"a b",
+	}, {
+		`keeps spans apart across spaces that would look different`,
+		"\x1b[42mone\x1b[0m \x1b[42mtwo\x1b[0m \x1b[4;31mthree\x1b[0;4m \x1b[31mfour",
+		"<span class=\"term-bg42\">one</span> <span class=\"term-bg42\">two</span> <span class=\"term-fg31 term-fg4\">three</span><span class=\"term-fg4\"> </span><span class=\"term-fg31 term-fg4\">four</span>",
 	}, {
 		`handles xterm colors`,
 		"\x1b[38;5;169;48;5;50mhello\x1b[0m \x1b[38;5;179mgoodbye",
@@ -240,11 +262,11 @@ var rendererTestCases = []struct {
 	}, {
 		`renders simple images on their own line`, // http://iterm2.com/images.html
 		"hi\x1b]1337;File=name=MS5naWY=;inline=1:AA==\ahello",
-		"hi\n" + `<img alt="1.gif" src="data:image/gif;base64,AA==">` + "\nhello",
+		"hi\n" + `<img alt="1.gif" src="data:image/gif;base64,AA==" loading="lazy" decoding="async">` + "\nhello",
 	}, {
 		`does not start a new line for iterm images if we're already at the start of a line`,
 		"\x1b]1337;File=name=MS5naWY=;inline=1:AA==\a",
-		`<img alt="1.gif" src="data:image/gif;base64,AA==">`,
+		`<img alt="1.gif" src="data:image/gif;base64,AA==" loading="lazy" decoding="async">`,
 	}, {
 		`silently ignores unsupported ANSI escape sequences`,
 		"abc\x1b]9999\aghi",
@@ -256,7 +278,7 @@ var rendererTestCases = []struct {
 	}, {
 		`renders external images`,
 		"\x1b]1338;url=http://foo.com/foobar.gif;alt=foo bar\a",
-		`<img alt="foo bar" src="http://foo.com/foobar.gif">`,
+		`<img alt="foo bar" src="http://foo.com/foobar.gif" loading="lazy" decoding="async">`,
 	}, {
 		`disallows non-allow-listed schemes for images`,
 		"before\x1b]1338;url=javascript:alert(1);alt=hello\x07after",
@@ -272,11 +294,11 @@ var rendererTestCases = []struct {
 	}, {
 		`protects inline images against XSS by escaping HTML during rendering`,
 		"hi\x1b]1337;File=name=" + base64Encode("<script>.pdf") + ";inline=1:AA==\ahello",
-		"hi\n" + `<img alt="&lt;script&gt;.pdf" src="data:application/pdf;base64,AA==">` + "\nhello",
+		"hi\n" + `<img alt="&lt;script&gt;.pdf" src="data:application/pdf;base64,AA==" loading="lazy" decoding="async">` + "\nhello",
 	}, {
 		`protects external images against XSS by escaping HTML during rendering`,
 		"\x1b]1338;url=\"https://example.com/a.gif&a=<b>&c='d'\";alt=foo&bar;width=\"<wat>\";height=2px\a",
-		`<img alt="foo&amp;bar" src="https://example.com/a.gif&amp;a=%3Cb%3E&amp;c=%27d%27" width="&lt;wat&gt;em" height="2px">`,
+		`<img alt="foo&amp;bar" src="https://example.com/a.gif&amp;a=%3Cb%3E&amp;c=%27d%27" width="&lt;wat&gt;em" height="2px" loading="lazy" decoding="async">`,
 	}, {
 		`protects links against XSS by escaping HTML during rendering`,
 		"\x1b]1339;url=\"https://example.com/a.gif&a=<b>&c='d'\";content=<h1>hello</h1>\a",
@@ -333,6 +355,663 @@ func TestRendererAgainstCases(t *testing.T) {
 	}
 }
 
+var rendererOptionsTestCases = []struct {
+	name     string
+	opts     Options
+	input    string
+	expected string
+}{
+	{
+		`renders inline images within the per-image size limit`,
+		Options{MaxInlineImageBytes: 1},
+		"\x1b]1337;File=name=MS5naWY=;inline=1:AA==\a",
+		`<img alt="1.gif" src="data:image/gif;base64,AA==" loading="lazy" decoding="async">`,
+	}, {
+		`replaces inline images over the per-image size limit with a placeholder`,
+		Options{MaxInlineImageBytes: 1},
+		"hi\x1b]1337;File=name=MS5naWY=;inline=1:AAAA\ahello",
+		"hi\n" + `<span class="term-image-placeholder">[image omitted: 1.gif]</span>` + "\nhello",
+	}, {
+		`replaces inline images with a placeholder once the total size limit is reached`,
+		Options{MaxTotalInlineImageBytes: 2},
+		"\x1b]1337;File=name=MS5naWY=;inline=1:AAA=\a\x1b]1337;File=name=Mi5naWY=;inline=1:AA==\a",
+		`<img alt="1.gif" src="data:image/gif;base64,AAA=" loading="lazy" decoding="async">` + "\n" + `<span class="term-image-placeholder">[image omitted: 2.gif]</span>`,
+	}, {
+		`routes external images through the image proxy`,
+		Options{ImageProxyURL: "https://proxy.example.com/i?url={url}"},
+		"\x1b]1338;url=http://foo.com/foo bar.gif?a=b&c=d;alt=foo\a",
+		`<img alt="foo" src="https://proxy.example.com/i?url=http%3A%2F%2Ffoo.com%2Ffoo%2520bar.gif%3Fa%3Db%26c%3Dd" loading="lazy" decoding="async">`,
+	}, {
+		`does not route relative external image URLs through the image proxy`,
+		Options{ImageProxyURL: "https://proxy.example.com/i?url={url}"},
+		"\x1b]1338;url=artifacts/foo.gif\a",
+		`<img alt="artifacts/foo.gif" src="artifacts/foo.gif" loading="lazy" decoding="async">`,
+	}, {
+		`allows links with allow-listed schemes`,
+		Options{AllowedURLSchemes: []string{"https"}},
+		"\x1b]1339;url=https://example.com;content=hello\x07",
+		`<a href="https://example.com">hello</a>`,
+	}, {
+		`disallows links with schemes that aren't allow-listed`,
+		Options{AllowedURLSchemes: []string{"https"}},
+		"\x1b]1339;url=http://example.com;content=hello\x07",
+		`<a href="#">hello</a>`,
+	}, {
+		`does not render external images with schemes that aren't allow-listed`,
+		Options{AllowedURLSchemes: []string{"https"}},
+		"before\x1b]1338;url=artifact://foo.gif\x07after",
+		"before\n&nbsp;\nafter",
+	}, {
+		`adds extra attributes to links in alphabetical order`,
+		Options{LinkAttributes: map[string]string{"target": "_blank", "rel": "noopener noreferrer nofollow", "href": "ignored"}},
+		"\x1b]1339;url=https://example.com;content=hello\x07",
+		`<a href="https://example.com" rel="noopener noreferrer nofollow" target="_blank">hello</a>`,
+	}, {
+		`escapes extra link attributes`,
+		Options{LinkAttributes: map[string]string{"title": `"><script>`}},
+		"\x1b]1339;url=https://example.com;content=hello\x07",
+		`<a href="https://example.com" title="&#34;&gt;&lt;script&gt;">hello</a>`,
+	}, {
+		`drops invalid names, event handlers and hrefs from extra link attributes`,
+		Options{LinkAttributes: map[string]string{
+			"data-x": "kept", `x"y`: "bad", "a b": "bad", "onclick": "alert(1)", "OnMouseOver": "alert(1)",
+			"HREF": "javascript:alert(1)", "xlink:href": "javascript:alert(1)", "": "empty",
+		}},
+		"\x1b]1339;url=https://example.com;content=hello\x07",
+		`<a href="https://example.com" data-x="kept">hello</a>`,
+	}, {
+		`replaces inline images with a placeholder in CSP-safe mode`,
+		Options{CSPSafe: true},
+		"\x1b]1337;File=name=MS5naWY=;inline=1:AA==\a",
+		`<span class="term-image-placeholder">[image omitted: 1.gif]</span>`,
+	}, {
+		`disallows data: links in CSP-safe mode`,
+		Options{CSPSafe: true},
+		"\x1b]1339;url=data:text/html,hello;content=hello\x07",
+		`<a href="#">hello</a>`,
+	}, {
+		`does not render data: external images in CSP-safe mode`,
+		Options{CSPSafe: true},
+		"before\x1b]1338;url=data:image/gif;base64,AA==\x07after",
+		"before\n&nbsp;\nafter",
+	}, {
+		`does not linkify URLs by default`,
+		Options{},
+		"see https://example.com/",
+		"see https:&#47;&#47;example.com&#47;",
+	}, {
+		`linkifies bare URLs`,
+		Options{Linkify: true},
+		"see https://example.com/a?b=c&d=e for details",
+		`see <a href="https://example.com/a?b=c&amp;d=e">https:&#47;&#47;example.com&#47;a?b=c&amp;d=e</a> for details`,
+	}, {
+		`does not include trailing punctuation in linkified URLs`,
+		Options{Linkify: true},
+		"(see http://example.com/wiki/Foo_(bar)).",
+		`(see <a href="http://example.com/wiki/Foo_(bar)">http:&#47;&#47;example.com&#47;wiki&#47;Foo_(bar)</a>).`,
+	}, {
+		`linkifies URLs across style changes`,
+		Options{Linkify: true},
+		"\x1b[32mgo to http://\x1b[1mexample.com\x1b[0m now",
+		`<span class="term-fg32">go to </span><a href="http://example.com"><span class="term-fg32">http:&#47;&#47;</span><span class="term-fg32 term-fg1">example.com</span></a> now`,
+	}, {
+		`adds link attributes to linkified URLs`,
+		Options{Linkify: true, LinkAttributes: map[string]string{"target": "_blank"}},
+		"http://example.com",
+		`<a href="http://example.com" target="_blank">http:&#47;&#47;example.com</a>`,
+	}, {
+		`does not linkify URLs with schemes that aren't allow-listed`,
+		Options{Linkify: true, AllowedURLSchemes: []string{"https"}},
+		"http://example.com",
+		`http:&#47;&#47;example.com`,
+	}, {
+		`links path:line:column references using the path linker`,
+		Options{PathLinker: testPathLinker},
+		"\x1b[31msrc/main.go:42:7\x1b[0m: undefined: foo",
+		`<a href="https://code.example.com/src/main.go#L42C7"><span class="term-fg31">src&#47;main.go:42:7</span></a>: undefined: foo`,
+	}, {
+		`links path:line references using the path linker`,
+		Options{PathLinker: testPathLinker},
+		"FAIL ./pkg/thing_test.go:12 and /abs/file.rb:3",
+		`FAIL <a href="https://code.example.com/./pkg/thing_test.go#L12C0">.&#47;pkg&#47;thing_test.go:12</a> and <a href="https://code.example.com//abs/file.rb#L3C0">&#47;abs&#47;file.rb:3</a>`,
+	}, {
+		`does not link paths the path linker declines`,
+		Options{PathLinker: testPathLinker},
+		"vendor/lib.go:1:2",
+		`vendor&#47;lib.go:1:2`,
+	}, {
+		`does not link times or paths without extensions`,
+		Options{PathLinker: testPathLinker},
+		"at 12:34:56 in Makefile:3",
+		`at 12:34:56 in Makefile:3`,
+	}, {
+		`does not link paths inside linkified URLs`,
+		Options{Linkify: true, PathLinker: testPathLinker},
+		"https://example.com/main.go:12",
+		`<a href="https://example.com/main.go:12">https:&#47;&#47;example.com&#47;main.go:12</a>`,
+	}, {
+		`ignores APCs in namespaces that aren't registered`,
+		Options{},
+		"\x1b_ci;job=123\x07hello",
+		`hello`,
+	}, {
+		`renders APCs in registered namespaces as processing instructions`,
+		Options{APCNamespaces: []string{"gl", "ci"}},
+		"\x1b_ci;job=123\x07\x1b_bk;t=1\x07hello\x1b_gl;x=a\\;b;y=<c>\x07",
+		`<?bk t="1"?><?gl x="a;b" y="&lt;c&gt;"?><?ci job="123"?>hello`,
+	}, {
+		`reports errors in APCs in registered namespaces`,
+		Options{APCNamespaces: []string{"ci"}},
+		"\x1b_ci;job\x07",
+		`*** Error parsing ci APC ANSI escape sequence: Failed to read key=value from token &quot;job&quot;`,
+	}, {
+		`renders timestamps as <time> elements`,
+		Options{TimestampFormat: TimestampTimeElement},
+		"\x1b_bk;t=1684881360000\x07hello\nworld\n\x1b_bk;t=1684881360123;x=y\x07!",
+		strings.Join([]string{
+			`<time datetime="2023-05-23T22:36:00.000Z"></time>hello`,
+			`world`,
+			`<?bk x="y"?><time datetime="2023-05-23T22:36:00.123Z"></time>!`,
+		}, "\n"),
+	}, {
+		`does not render non-numeric timestamps as <time> elements`,
+		Options{TimestampFormat: TimestampTimeElement},
+		"\x1b_bk;t=yesterday\x07hello",
+		`hello`,
+	}, {
+		`renders timestamps as data attributes on line wrappers`,
+		Options{TimestampFormat: TimestampDataAttribute},
+		"\x1b_bk;t=1684881360000\x07\x1b[31mhello\n\nworld\x1b_bk;t=\"<1>\"\x07",
+		strings.Join([]string{
+			`<span class="term-line" data-timestamp="1684881360000"><span class="term-fg31">hello</span></span>`,
+			`<span class="term-line"></span>`,
+			`<span class="term-line" data-timestamp="&lt;1&gt;"><span class="term-fg31">world</span></span>`,
+		}, "\n"),
+	}, {
+		`renders the last timestamp seen on a line as its data attribute`,
+		Options{TimestampFormat: TimestampDataAttribute},
+		"\x1b_bk;t=1000\x07one, \x1b_bk;t=2000\x07two\nthree",
+		strings.Join([]string{
+			`<span class="term-line" data-timestamp="2000">one, two</span>`,
+			`<span class="term-line">three</span>`,
+		}, "\n"),
+	}, {
+		`renders elapsed and delta times as data attributes on line wrappers`,
+		Options{ElapsedTime: true},
+		"\x1b_bk;t=1000\x07one\ntwo\n\x1b_bk;t=1500\x07three\n\x1b_bk;t=4000\x07four",
+		strings.Join([]string{
+			`<span class="term-line" data-elapsed-ms="0" data-delta-ms="0"><?bk t="1000"?>one</span>`,
+			`<span class="term-line">two</span>`,
+			`<span class="term-line" data-elapsed-ms="500" data-delta-ms="500"><?bk t="1500"?>three</span>`,
+			`<span class="term-line" data-elapsed-ms="3000" data-delta-ms="2500"><?bk t="4000"?>four</span>`,
+		}, "\n"),
+	}, {
+		`renders elapsed times alongside timestamp data attributes`,
+		Options{ElapsedTime: true, TimestampFormat: TimestampDataAttribute},
+		"\x1b_bk;t=1000\x07one\n\x1b_bk;t=1250\x07two",
+		strings.Join([]string{
+			`<span class="term-line" data-timestamp="1000" data-elapsed-ms="0" data-delta-ms="0">one</span>`,
+			`<span class="term-line" data-timestamp="1250" data-elapsed-ms="250" data-delta-ms="250">two</span>`,
+		}, "\n"),
+	}, {
+		`clamps timestamps that go backwards to the one before`,
+		Options{ElapsedTime: true, TimestampFormat: TimestampDataAttribute, TimestampNormalization: TimestampsClamped},
+		"\x1b_bk;t=1000\x07one\n\x1b_bk;t=5000\x07two\n\x1b_bk;t=2000\x07three\n\x1b_bk;t=6000\x07four",
+		strings.Join([]string{
+			`<span class="term-line" data-timestamp="1000" data-elapsed-ms="0" data-delta-ms="0">one</span>`,
+			`<span class="term-line" data-timestamp="5000" data-elapsed-ms="4000" data-delta-ms="4000">two</span>`,
+			`<span class="term-line" data-timestamp="5000" data-elapsed-ms="4000" data-delta-ms="0">three</span>`,
+			`<span class="term-line" data-timestamp="6000" data-elapsed-ms="5000" data-delta-ms="1000">four</span>`,
+		}, "\n"),
+	}, {
+		`offsets timestamps from where they went backwards`,
+		Options{ElapsedTime: true, TimestampNormalization: TimestampsOffset},
+		"\x1b_bk;t=1000\x07one\n\x1b_bk;t=5000\x07two\n\x1b_bk;t=2000\x07three\n\x1b_bk;t=2500;x=y\x07four\n\x1b_bk;t=soon\x07five",
+		strings.Join([]string{
+			`<span class="term-line" data-elapsed-ms="0" data-delta-ms="0"><?bk t="1000"?>one</span>`,
+			`<span class="term-line" data-elapsed-ms="4000" data-delta-ms="4000"><?bk t="5000"?>two</span>`,
+			`<span class="term-line" data-elapsed-ms="4000" data-delta-ms="0"><?bk t="5000"?>three</span>`,
+			`<span class="term-line" data-elapsed-ms="4500" data-delta-ms="500"><?bk t="5500" x="y"?>four</span>`,
+			`<span class="term-line"><?bk t="soon"?>five</span>`,
+		}, "\n"),
+	}, {
+		`truncates long lines with an ellipsis and the full text in a title`,
+		Options{TruncateColumns: 10},
+		"short\nexactly10!\n\x1b[31mthis line is long\x1b[0m & more\nten chars      ",
+		strings.Join([]string{
+			`<span class="term-line">short</span>`,
+			`<span class="term-line">exactly10!</span>`,
+			`<span class="term-line term-truncated" title="this line is long &amp; more"><span class="term-fg31">this line</span><span class="term-ellipsis">…</span></span>`,
+			`<span class="term-line">ten chars</span>`,
+		}, "\n"),
+	}, {
+		`truncates links and search matches with the line, but links to the whole URL`,
+		Options{TruncateColumns: 12, Linkify: true, Search: regexp.MustCompile(`path`)},
+		"see https://example.com/path",
+		`<span class="term-line term-truncated" title="see https://example.com/path">see <a href="https://example.com/path">https:&#47;</a><span class="term-ellipsis">…</span></span>`,
+	}, {
+		`omits processing instructions`,
+		Options{OmitProcessingInstructions: true, APCNamespaces: []string{"ci"}},
+		"\x1b_bk;t=123\x07hello \x1b_ci;job=1\x07world",
+		`hello world`,
+	}, {
+		`omits processing instructions but still renders timestamps`,
+		Options{OmitProcessingInstructions: true, TimestampFormat: TimestampTimeElement},
+		"\x1b_bk;t=0;x=y\x07hello",
+		`<time datetime="1970-01-01T00:00:00.000Z"></time>hello`,
+	}, {
+		`does not fold Buildkite groups by default`,
+		Options{},
+		"--- one\nhello",
+		"--- one\nhello",
+	}, {
+		`folds Buildkite groups into collapsible sections`,
+		Options{BuildkiteGroups: true},
+		"before\n--- \x1b[1mone\x1b[0m\nhello\n\nworld\n+++ two\nthree\n~~~ four\n",
+		"before\n" +
+			`<details class="term-group"><summary><span class="term-fg1">one</span></summary>hello` + "\n&nbsp;\nworld\n</details>" +
+			`<details class="term-group" open><summary>two</summary>three` + "\n</details>" +
+			`<details class="term-group"><summary>four</summary></details>`,
+	}, {
+		`expands the current Buildkite group with ^^^ +++`,
+		Options{BuildkiteGroups: true},
+		"\x1b_bk;t=1\x07--- one\nhello\n^^^ +++\n--- two\nworld",
+		`<details class="term-group" open><summary><?bk t="1"?>one</summary>hello` + "\n</details>" +
+			`<details class="term-group"><summary>two</summary>world</details>`,
+	}, {
+		`ignores ^^^ +++ before any Buildkite group`,
+		Options{BuildkiteGroups: true},
+		"^^^ +++\nhello",
+		"hello",
+	}, {
+		`folds GitHub Actions groups into collapsible sections`,
+		Options{GitHubActionsGroups: true},
+		"before\n::group::Install \x1b[32mdeps\x1b[0m\nnpm install\n::endgroup::\nafter\n##[group]Build\nmake\n##[endgroup]",
+		"before\n" +
+			`<details class="term-group"><summary>Install <span class="term-fg32">deps</span></summary>npm install` + "\n</details>" +
+			"after\n" +
+			`<details class="term-group"><summary>Build</summary>make</details>`,
+	}, {
+		`ends the current GitHub Actions group when another starts`,
+		Options{GitHubActionsGroups: true},
+		"::group::one\na\n::group::two\nb",
+		`<details class="term-group"><summary>one</summary>a` + "\n</details>" +
+			`<details class="term-group"><summary>two</summary>b</details>`,
+	}, {
+		`nests sections from different syntaxes`,
+		Options{BuildkiteGroups: true, GitHubActionsGroups: true},
+		"--- one\n::group::two\na\n--- three\nb\n::endgroup::\nc",
+		`<details class="term-group"><summary>one</summary><details class="term-group"><summary>two</summary>a` + "\n</details></details>" +
+			`<details class="term-group"><summary>three</summary>b` + "\nc</details>",
+	}, {
+		`folds GitLab sections into collapsible sections`,
+		Options{GitLabSections: true},
+		"before\r\n" +
+			"section_start:1560896352:first_section\r\x1b[0K\x1b[1mHeader\x1b[0m\r\n" +
+			"one\r\n" +
+			"section_start:1560896353:second_section[collapsed=true]\r\x1b[0K\r\n" +
+			"two\r\n" +
+			"section_end:1560896355:second_section\r\x1b[0K\r\n" +
+			"section_end:1560896425:first_section\r\x1b[0Ksection_start:1560896425:third_section\r\x1b[0KThird\r\n" +
+			"three\r\n",
+		"before\n" +
+			`<details class="term-group" open><summary><span class="term-fg1">Header</span><span class="term-group-duration">01:13</span></summary>one` + "\n" +
+			`<details class="term-group"><summary>second_section<span class="term-group-duration">00:02</span></summary>two` + "\n</details></details>" +
+			`<details class="term-group" open><summary>Third</summary>three</details>`,
+	}, {
+		`leaves GitLab section markers alone by default`,
+		Options{},
+		"section_start:1560896352:first_section\r\x1b[0KHeader",
+		"Header",
+	}, {
+		`erases GitLab section markers left on their own line`,
+		Options{GitLabSections: true},
+		"section_start:1560896352:first\none\nsection_end:1560896353:first\ntwo",
+		`<details class="term-group" open><summary>first<span class="term-group-duration">00:01</span></summary>one` + "\n</details>two",
+	}, {
+		`folds travis_fold markers into collapsible sections`,
+		Options{TravisFolds: true},
+		"before\ntravis_fold:start:install\r\x1b[0K$ npm install\nadded 1 package\ntravis_fold:end:install\r\x1b[0K\nafter",
+		"before\n" +
+			`<details class="term-group"><summary>$ npm install</summary>added 1 package` + "\n</details>after",
+	}, {
+		`folds travis_fold markers left on their own line`,
+		Options{TravisFolds: true},
+		"travis_fold:start:install\nnpm install\ntravis_fold:end:install\nafter",
+		`<details class="term-group"><summary>install</summary>npm install` + "\n</details>after",
+	}, {
+		`leaves travis_fold markers alone by default`,
+		Options{},
+		"travis_fold:start:install\nnpm install",
+		"travis_fold:start:install\nnpm install",
+	}, {
+		`loads images eagerly`,
+		Options{EagerImages: true},
+		"\x1b]1338;url=a.gif;width=2\a\x1b]1337;File=name=MS5naWY=;inline=1:AA==\a",
+		`<img alt="a.gif" src="a.gif" width="2em">` + "\n" + `<img alt="1.gif" src="data:image/gif;base64,AA==">`,
+	}, {
+		`keeps unknown APCs as comments`,
+		Options{UnknownAPCComments: true, APCNamespaces: []string{"ci"}},
+		"\x1b_bk;t=1000\x07\x1b_ci;job=1\x07\x1b_cov;file=a-->b<!%\x1b\x07\x1b_x\x07line\nnext",
+		`<?bk t="1000"?><?ci job="1"?><!-- apc: cov;file=a%2D%2D%3Eb%3C!%25%1B --><!-- apc: x -->line` + "\nnext",
+	}, {
+		`drops unknown APCs by default`,
+		Options{},
+		"\x1b_cov;file=a\x07line",
+		"line",
+	}, {
+		`shows changes of the terminal title as headers`,
+		Options{TitleHeaders: true},
+		"start\n\x1b]0;Compiling\abuilding <app>\n\x1b]2;Compiling\amore\nhalf\x1b]2;Testing \x1b[31m\x07ok\n\x1b]2;\a\x1b]2;Testing\adone",
+		"start\n" +
+			`<span class="term-title" role="heading">Compiling</span>` + "\nbuilding &lt;app&gt;\nmore\nhalf\n" +
+			`<span class="term-title" role="heading">Testing</span>` + "\nok\n" +
+			`<span class="term-title" role="heading">Testing</span>` + "\ndone",
+	}, {
+		`ignores terminal titles by default`,
+		Options{},
+		"\x1b]2;Compiling\abuilding",
+		"building",
+	}, {
+		`renders GitHub Actions annotations`,
+		Options{GitHubActionsAnnotations: true},
+		"before\n::error file=app.js,line=1,col=5,title=Syntax%3A <bad>::Missing semicolon\n::warning::Deprecated\n##[notice]Hello%25\n::notice::",
+		"before\n" +
+			`<span class="term-annotation term-annotation-error" data-file="app.js" data-line="1" data-column="5"><span class="term-annotation-title">Syntax: &lt;bad&gt;</span> <span class="term-annotation-location">app.js:1:5</span> Missing semicolon</span>` + "\n" +
+			`<span class="term-annotation term-annotation-warning"><span class="term-annotation-title">Warning</span> Deprecated</span>` + "\n" +
+			`<span class="term-annotation term-annotation-notice"><span class="term-annotation-title">Notice</span> Hello%</span>` + "\n" +
+			`<span class="term-annotation term-annotation-notice"><span class="term-annotation-title">Notice</span> </span>`,
+	}, {
+		`keeps the styles and links of GitHub Actions annotation messages`,
+		Options{GitHubActionsAnnotations: true},
+		"::error::\x1b[31mfailed\x1b[0m, see \x1b]1339;url=http://example.com;content=docs\x07 for 100%25",
+		`<span class="term-annotation term-annotation-error"><span class="term-annotation-title">Error</span> <span class="term-fg31">failed</span>, see <a href="http://example.com">docs</a> for 100%</span>`,
+	}, {
+		`leaves GitHub Actions annotations alone by default`,
+		Options{},
+		"::error::oops",
+		"::error::oops",
+	}, {
+		`classifies lines using the default line classes`,
+		Options{LineClasses: DefaultLineClasses},
+		"ok\nmain.go:1: \x1b[31merror:\x1b[0m oops\nWarning: careful, error: too\n--- FAIL: TestThing\npanic: boom\nfailed",
+		strings.Join([]string{
+			`<span class="term-line">ok</span>`,
+			`<span class="term-line term-error">main.go:1: <span class="term-fg31">error:</span> oops</span>`,
+			`<span class="term-line term-error term-warning">Warning: careful, error: too</span>`,
+			`<span class="term-line term-error">--- FAIL: TestThing</span>`,
+			`<span class="term-line term-error">panic: boom</span>`,
+			`<span class="term-line">failed</span>`,
+		}, "\n"),
+	}, {
+		`classifies lines using custom line classes`,
+		Options{LineClasses: []LineClass{{regexp.MustCompile(`^\$ `), "command \"x"}}},
+		"$ make\nok",
+		strings.Join([]string{
+			`<span class="term-line command &#34;x">$ make</span>`,
+			`<span class="term-line">ok</span>`,
+		}, "\n"),
+	}, {
+		`highlights search matches`,
+		Options{Search: regexp.MustCompile(`(?i)err\w*`)},
+		"no Errors here\nerror: \x1b[31mbad <error>\x1b[0m",
+		"no <mark class=\"term-search-match\">Errors</mark> here\n" +
+			`<mark class="term-search-match">error</mark>: <span class="term-fg31">bad &lt;</span><mark class="term-search-match"><span class="term-fg31">error</span></mark><span class="term-fg31">&gt;</span>`,
+	}, {
+		`highlights search matches spanning style changes`,
+		Options{Search: regexp.MustCompile(regexp.QuoteMeta("lo wo"))},
+		"hel\x1b[1mlo\x1b[0m world",
+		`hel<mark class="term-search-match"><span class="term-fg1">lo</span> wo</mark>rld`,
+	}, {
+		`splits search matches that cross links`,
+		Options{Linkify: true, Search: regexp.MustCompile(`see http`)},
+		"see http://example.com",
+		`<mark class="term-search-match">see </mark><a href="http://example.com"><mark class="term-search-match">http</mark>:&#47;&#47;example.com</a>`,
+	}, {
+		`ignores empty search matches`,
+		Options{Search: regexp.MustCompile(`x*`)},
+		"abc",
+		`abc`,
+	}, {
+		`highlights line ranges`,
+		Options{HighlightLines: []LineRange{{Start: 2, End: 3}, {Start: 5, End: 5}}},
+		"one\ntwo\nthree\nfour\nfive\nsix",
+		strings.Join([]string{
+			`<span class="term-line">one</span>`,
+			`<span class="term-line term-highlight">two</span>`,
+			`<span class="term-line term-highlight">three</span>`,
+			`<span class="term-line">four</span>`,
+			`<span class="term-line term-highlight">five</span>`,
+			`<span class="term-line">six</span>`,
+		}, "\n"),
+	}, {
+		`numbers lines in a gutter`,
+		Options{LineNumbers: LineNumberGutter},
+		"\x1b_bk;t=1\x07one\n\nthree",
+		strings.Join([]string{
+			`<span class="term-line-number" data-line-number="1"></span><?bk t="1"?>one`,
+			`<span class="term-line-number" data-line-number="2"></span>`,
+			`<span class="term-line-number" data-line-number="3"></span>three`,
+		}, "\n"),
+	}, {
+		`numbers lines in data attributes`,
+		Options{LineNumbers: LineNumberDataAttribute, HighlightLines: []LineRange{{Start: 2, End: 2}}},
+		"one\ntwo",
+		strings.Join([]string{
+			`<span class="term-line" data-line-number="1">one</span>`,
+			`<span class="term-line term-highlight" data-line-number="2">two</span>`,
+		}, "\n"),
+	}, {
+		`numbers lines after cursor movement`,
+		Options{LineNumbers: LineNumberDataAttribute},
+		"one\ntwo\x1b[1A\rONE\x1b[2Bthree",
+		strings.Join([]string{
+			`<span class="term-line" data-line-number="1">ONE</span>`,
+			`<span class="term-line" data-line-number="2">two</span>`,
+			`<span class="term-line" data-line-number="3">   three</span>`,
+		}, "\n"),
+	}, {
+		`redacts secrets from text and links`,
+		Options{Redact: RedactStrings("hunter2")},
+		"\x1b[31mpassword: hunter2\x1b[0m\n\x1b]1339;url=https://example.com/?token=hunter2\x07",
+		strings.Join([]string{
+			`<span class="term-fg31">password: *****</span>`,
+			`<a href="https://example.com/?token=*****">https://example.com/?token=*****</a>`,
+		}, "\n"),
+	}, {
+		`filters lines`,
+		Options{LineFilter: testLineFilter, LineNumbers: LineNumberDataAttribute},
+		"\x1b[32mfetching\x1b[0m\ndownloading a\ndownloading b\n\x1b[32mdone\x1b[0m\n",
+		strings.Join([]string{
+			`<span class="term-line" data-line-number="1"><span class="term-fg32">fetching</span></span>`,
+			`<span class="term-line" data-line-number="3">downloaded b</span>`,
+			`<span class="term-line" data-line-number="4"><span class="term-fg32">done</span></span>`,
+		}, "\n"),
+	}, {
+		`ungroups the contents of a filtered out group header`,
+		Options{LineFilter: testLineFilter, BuildkiteGroups: true},
+		"--- downloading a\none\n--- two\nthree",
+		strings.Join([]string{
+			`one`,
+			`<details class="term-group"><summary>two</summary>three</details>`,
+		}, "\n"),
+	}, {
+		`collapses repeated lines`,
+		Options{CollapseRepeatedLines: true},
+		"\x1b_bk;t=1\x07retrying\n\x1b_bk;t=2\x07retrying\n\x1b_bk;t=3\x07retrying\n\x1b[31mretrying\x1b[0m\n\n\ndone\ndone",
+		strings.Join([]string{
+			`<?bk t="1"?>retrying <span class="term-repeated">(repeated 3 times)</span>`,
+			`<span class="term-fg31">retrying</span>`,
+			`&nbsp;`,
+			``,
+			`done <span class="term-repeated">(repeated 2 times)</span>`,
+		}, "\n"),
+	}, {
+		`doesn't collapse repeated lines across groups`,
+		Options{CollapseRepeatedLines: true, BuildkiteGroups: true},
+		"--- a\n--- a\nx\nx\n--- b\nx",
+		strings.Join([]string{
+			`<details class="term-group"><summary>a</summary></details><details class="term-group"><summary>a</summary>x <span class="term-repeated">(repeated 2 times)</span>`,
+			`</details><details class="term-group"><summary>b</summary>x</details>`,
+		}, "\n"),
+	}, {
+		`keeps progress frames`,
+		Options{ProgressFrames: 2},
+		"start\r\n[    ] 0%\r[=   ] 25%\r\x1b[K[==  ] 50%\r[=== ] 75%\r[====] \"100%\"\r\nend",
+		strings.Join([]string{
+			`<span class="term-line">start</span>`,
+			`<span class="term-line" data-overwrites="4" data-frames="[{&#34;text&#34;:&#34;[=   ] 25%&#34;},{&#34;text&#34;:&#34;[=== ] 75%&#34;}]">[====] &quot;100%&quot;</span>`,
+			`<span class="term-line">end</span>`,
+		}, "\n"),
+	}, {
+		`inserts HTML after lines`,
+		Options{
+			InsertHTML: map[int]string{
+				0: `<div class="banner">Known issue</div>`,
+				2: `<div class="banner">Matched #123</div>`,
+				3: `<div class="banner">End</div>`,
+			},
+			BuildkiteGroups: true,
+		},
+		"--- Tests\n\nthree\x1b[1A\rFAIL\n",
+		strings.Join([]string{
+			`<div class="banner">Known issue</div><details class="term-group"><summary>Tests</summary>FAIL<div class="banner">Matched #123</div>three<div class="banner">End</div></details>`,
+		}, "\n"),
+	}, {
+		`highlights code in fenced blocks`,
+		Options{Highlighter: testHighlighter, Linkify: true},
+		"```go\nfunc main() { println(\"\x1b[31mhi\x1b[0m\", \"https://example.com\") }\n```\nfunc outside\n```\nfunc plain\n```",
+		strings.Join([]string{
+			"```go",
+			`<span class="hl-keyword">func</span> main() { println(<span class="hl-string">&quot;<span class="term-fg31">hi</span>&quot;</span>, <span class="hl-string">&quot;</span><a href="https://example.com"><span class="hl-string">https:&#47;&#47;example.com</span></a><span class="hl-string">&quot;</span>) }`,
+			"```",
+			`func outside`,
+			"```",
+			`func plain`,
+			"```",
+		}, "\n"),
+	}, {
+		`highlights code in heredocs`,
+		Options{Highlighter: testHighlighter},
+		"$ cat > main.go <<'EOF'\nfunc \"a\nb\"\nEOF\nfunc outside",
+		strings.Join([]string{
+			`$ cat &gt; main.go &lt;&lt;&#39;EOF&#39;`,
+			`<span class="hl-keyword">func</span> <span class="hl-string">&quot;a</span>`,
+			`<span class="hl-string">b&quot;</span>`,
+			`EOF`,
+			`func outside`,
+		}, "\n"),
+	}, {
+		`renders accessible output`,
+		Options{Accessible: true, MaxInlineImageBytes: 1},
+		"\x1b[1;31mbold\x1b[0m \x1b[3mitalic\x1b[0m \x1b[5mblink\x1b[0m \x1b[1;5mboth\x1b[0m\n\x1b]1338;url=tiny.gif;alt=Tiny\x07\x1b]1337;File=name=MS5naWY=;inline=1:AAAA\x07",
+		strings.Join([]string{
+			`<div role="log"><strong class="term-fg31 term-fg1">bold</strong> <em class="term-fg3">italic</em> blink <strong class="term-fg1">both</strong>`,
+			`<img alt="Tiny" aria-label="Image: Tiny" src="tiny.gif" loading="lazy" decoding="async">`,
+			`<span class="term-image-placeholder" role="img" aria-label="Image omitted: 1.gif">[image omitted: 1.gif]</span></div>`,
+		}, "\n"),
+	}, {
+		`renders semantic elements`,
+		Options{SemanticElements: true},
+		"\x1b[1mbold\x1b[3m italic\x1b[9m struck\x1b[0m \x1b[1;31mred\x1b[0m \x1b[4munderlined\x1b[0m",
+		`<strong>bold</strong><strong><em> italic</em></strong><strong><em><del> struck</del></em></strong> <strong><span class="term-fg31">red</span></strong> <span class="term-fg4">underlined</span>`,
+	}, {
+		`separates lines with br elements`,
+		Options{BreakElements: true, BuildkiteGroups: true},
+		"one\n\n\x1b[31mthree\x1b[0m\n--- group\nfour\nfive",
+		`one<br><br><span class="term-fg31">three</span><br><details class="term-group"><summary>group</summary>four<br>five</details>`,
+	}, {
+		`ignores input past the line limit`,
+		Options{MaxLines: 2},
+		"one\ntwo\x1b[1Aline\nthree\nfour",
+		"oneline\nthree",
+	}, {
+		`ignores cursor movement past the line limit`,
+		Options{MaxLines: 2},
+		"one\x1b[999999999Btwo",
+		"one",
+	}, {
+		`clears the display but not the scrollback with escape 2J`,
+		Options{Height: 2},
+		"one\ntwo\nthree\nfour\x1b[2Jfive",
+		"one\ntwo\nfive",
+	}, {
+		`clears the scrollback but not the display with escape 3J`,
+		Options{Height: 2},
+		"one\ntwo\nthree\nfour\x1b[3Jfive",
+		"three\nfourfive",
+	}, {
+		`counts the display from the cursor when it's below the last line`,
+		Options{Height: 2},
+		"one\ntwo\x1b[3B\x1b[3Jthree",
+		"\n   three",
+	}, {
+		`keeps the cursor on the display when clearing the scrollback above it`,
+		Options{Height: 1},
+		"a\nb\nc\x1b[3A\x1b[3Jx\rd",
+		"dx",
+	}, {
+		`clears everything with escape 3J in legacy mode`,
+		Options{LegacyEraseDisplay: true},
+		"one\ntwo\x1b[3Jthree",
+		"three",
+	}, {
+		`clears everything with escape 2J in legacy mode despite the height`,
+		Options{Height: 2, LegacyEraseDisplay: true},
+		"one\ntwo\nthree\x1b[2Jfour",
+		"four",
+	}, {
+		`wraps lines at the terminal width`,
+		Options{Width: 4},
+		"abcdefghi\njkl\x1b[31mm\x1b[0m\rJ\x1b[9CZ",
+		"abcd\nefgh\ni\nJklZ",
+	},
+}
+
+// testLineFilter drops lines downloading "a", and rewrites the rest.
+func testLineFilter(line PlainLine) (bool, string) {
+	if strings.HasSuffix(line.Text, "downloading a") {
+		return false, ""
+	}
+	return true, strings.Replace(line.Text, "downloading", "downloaded", 1)
+}
+
+var testHighlightRegexp = regexp.MustCompile(`\bfunc\b|"[^"]*"`)
+
+// testHighlighter highlights Go keywords and strings.
+func testHighlighter(language, code string) []HighlightRange {
+	if language != "go" {
+		return nil
+	}
+	var ranges []HighlightRange
+	for _, loc := range testHighlightRegexp.FindAllStringIndex(code, -1) {
+		class := "hl-keyword"
+		if code[loc[0]] == '"' {
+			class = "hl-string"
+		}
+		ranges = append(ranges, HighlightRange{Start: loc[0], End: loc[1], Class: class})
+	}
+	return ranges
+}
+
+func testPathLinker(path string, line, column int) string {
+	if strings.HasPrefix(path, "vendor/") {
+		return ""
+	}
+	return fmt.Sprintf("https://code.example.com/%s#L%dC%d", path, line, column)
+}
+
+func TestRendererWithOptions(t *testing.T) {
+	for _, c := range rendererOptionsTestCases {
+		t.Run(c.name, func(t *testing.T) {
+			output := string(RenderWithOptions([]byte(c.input), c.opts))
+			if output != c.expected {
+				t.Errorf("%s\ninput\t\t%q\nexpected\t%q\nreceived\t%q", c.name, c.input, c.expected, output)
+			}
+		})
+	}
+}
+
 func TestRendererAgainstFixtures(t *testing.T) {
 	for _, base := range TestFiles {
 		t.Run(fmt.Sprintf("for fixture %q", base), func(t *testing.T) {
@@ -349,7 +1028,7 @@ func TestRendererAgainstFixtures(t *testing.T) {
 }
 
 func TestScreenWriteToXY(t *testing.T) {
-	s := screen{style: &emptyStyle}
+	s := Screen{style: &emptyStyle}
 	s.write('a')
 
 	s.x = 1
@@ -367,6 +1046,322 @@ func TestScreenWriteToXY(t *testing.T) {
 	}
 }
 
+func TestScreenSkippedLines(t *testing.T) {
+	s := NewScreen(Options{})
+	s.Parse([]byte("top" + strings.Repeat("\x1b[100B", 100) + "bottom\x1b[100Amiddle"))
+
+	lines := strings.Split(s.asPlainText(), "\n")
+	if got, want := len(lines), 10001; got != want {
+		t.Fatalf("got %d lines, want %d", got, want)
+	}
+	for i, want := range map[int]string{0: "top", 9900: "         middle", 10000: "   bottom"} {
+		if got := lines[i]; got != want {
+			t.Errorf("line %d = %q, want %q", i+1, got, want)
+		}
+	}
+
+	// Only the lines written to have space for nodes
+	for i, line := range s.screen {
+		if written := i == 0 || i == 9900 || i == 10000; written != (line.nodes != nil) {
+			t.Errorf("line %d has nodes %v, want them allocated = %t", i+1, line.nodes, written)
+		}
+	}
+}
+
+func TestScreenCursorLimits(t *testing.T) {
+	cases := []struct {
+		name    string
+		opts    Options
+		input   string
+		columns int
+		lines   int
+	}{{
+		name:    "huge parameter",
+		input:   "\x1b[2147483647C\x1b[999999999999999999999Bx",
+		columns: math.MaxInt8 + 1,
+		lines:   math.MaxInt8 + 1,
+	}, {
+		name:    "repeated cursor forward",
+		input:   strings.Repeat("\x1b[127C", 10000) + "x",
+		columns: maxCursorColumn + 1,
+		lines:   1,
+	}, {
+		name:    "cursor forward past the width",
+		opts:    Options{Width: 80},
+		input:   strings.Repeat("\x1b[127C", 10000) + "x",
+		columns: 80,
+		lines:   1,
+	}, {
+		name:    "cursor forward from past the column limit",
+		input:   strings.Repeat("a", 2000) + "\x1b[5Cx",
+		columns: 2001,
+		lines:   1,
+	}, {
+		name:    "repeated cursor down",
+		input:   strings.Repeat("x\x1b[127B", 1000) + "x",
+		columns: 1001,
+		lines:   1000*127 + 1,
+	}, {
+		name:    "cursor down past the line limit",
+		opts:    Options{MaxLines: 1000},
+		input:   strings.Repeat("x\x1b[127B", 10000) + "x",
+		columns: 8,
+		lines:   7*127 + 1,
+	}, {
+		name:    "newlines after skipping lines",
+		input:   strings.Repeat("\x1b[127B", 1000) + strings.Repeat("\n", 1000) + "x",
+		columns: 1,
+		lines:   1000*127 + 1001,
+	}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := NewScreen(c.opts)
+			s.Parse([]byte(c.input))
+			if got := len(s.screen); got != c.lines {
+				t.Errorf("got %d lines, want %d", got, c.lines)
+			}
+			columns := 0
+			for _, line := range s.screen {
+				columns = max(columns, len(line.nodes))
+			}
+			if columns != c.columns {
+				t.Errorf("got %d columns, want %d", columns, c.columns)
+			}
+		})
+	}
+}
+
+func TestCursorDownInLongLogs(t *testing.T) {
+	// Logs that move down a line at a time, rather than with newlines, can
+	// do so any number of times
+	var b strings.Builder
+	const n = 100_005
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "line %d\r\x1b[1B", i)
+	}
+	s := NewScreen(Options{})
+	s.Parse([]byte(b.String()))
+	if got := len(s.screen); got != n {
+		t.Fatalf("got %d lines, want %d", got, n)
+	}
+	for _, y := range []int{0, 99_999, n - 1} {
+		got, _ := lineText(s.screen[y])
+		if want := fmt.Sprintf("line %d", y); got != want {
+			t.Errorf("line %d = %q, want %q", y+1, got, want)
+		}
+	}
+
+	// Each cursor position can only add so many lines past the end
+	s = NewScreen(Options{ConPTY: true})
+	s.Parse([]byte(strings.Repeat("\x1b[30000;1Hx", 3)))
+	if got, want := len(s.screen), 3*maxSkippedLines+1; got != want {
+		t.Errorf("with ConPTY, got %d lines, want %d", got, want)
+	}
+}
+
+func TestControlSequenceParameterLimit(t *testing.T) {
+	// Parameters after the first maxInstructions, such as the red here, are
+	// ignored
+	input := "\x1b[" + strings.Repeat("1;", 100000) + "31mbold"
+	want := `<span class="term-fg1">bold</span>`
+	if got := string(Render([]byte(input))); got != want {
+		t.Errorf("Render(%q...) = %q, want %q", input[:20], got, want)
+	}
+}
+
+func TestScreenAppendText(t *testing.T) {
+	// Part way along a line that's already there, after a carriage return
+	start := []byte("previous line\r\x1b[4C")
+	text := "héllo wörld, 日本語\xffand\tmore"
+	for name, opts := range map[string]Options{
+		"source map": {SourceMap: true},
+		"width":      {Width: 4, SourceMap: true},
+		"max lines":  {Width: 3, MaxLines: 7},
+	} {
+		got := NewScreen(opts)
+		got.Parse(start)
+		got.Parse([]byte(text))
+
+		// Parsing one character at a time doesn't append any runs of text
+		want := NewScreen(opts)
+		want.Parse(start)
+		for rest := text; rest != ""; {
+			_, n := utf8.DecodeRuneInString(rest)
+			want.Parse([]byte(rest[:n]))
+			rest = rest[n:]
+		}
+
+		if diff := cmp.Diff(got.asPlainText(), want.asPlainText()); diff != "" {
+			t.Errorf("%s: text diff (-got +want):\n%s", name, diff)
+		}
+		if diff := cmp.Diff(got.SourceMap(), want.SourceMap()); diff != "" {
+			t.Errorf("%s: SourceMap() diff (-got +want):\n%s", name, diff)
+		}
+		if got, want := got.Overwrites(), want.Overwrites(); got != want {
+			t.Errorf("%s: Overwrites() = %d, want %d", name, got, want)
+		}
+	}
+}
+
+func TestScreenOverwrites(t *testing.T) {
+	s := NewScreen(Options{})
+	s.Parse([]byte("a\r\n1%\r2%\r\x1b[K3%\x1b[1A\rb\n\r\n"))
+	if got, want := s.Overwrites(), 3; got != want {
+		t.Errorf("s.Overwrites() = %d, want %d", got, want)
+	}
+}
+
+func TestScreenFrames(t *testing.T) {
+	s := NewScreen(Options{ProgressFrames: 1})
+	s.Parse([]byte("build\n\x1b_bk;t=1000\x071%\r\x1b_bk;t=2000\x0750%\r\x1b[K\x1b_bk;t=3000\x07100%\nno timestamp\roverwritten\n"))
+	got := s.Frames()
+	want := []LineFrames{
+		{Line: 2, Frames: []Frame{{Text: "1%", Timestamp: 1000}, {Text: "50%", Timestamp: 2000}}},
+		{Line: 3, Frames: []Frame{{Text: "no timestamp"}}},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Frames() diff (-got +want):\n%s", diff)
+	}
+}
+
+func TestScreenSourceMap(t *testing.T) {
+	s := NewScreen(Options{SourceMap: true})
+	s.Parse([]byte("one\n\x1b[31mtwo\x1b[0m\n"))
+	s.Parse([]byte("\nfour\x1b[3A\rONE"))
+	got := s.SourceMap()
+	want := [][]ByteRange{
+		{{Start: 0, End: 3}, {Start: 27, End: 30}},
+		{{Start: 9, End: 12}},
+		nil,
+		{{Start: 18, End: 22}},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("SourceMap() diff (-got +want):\n%s", diff)
+	}
+}
+
+func TestScreenRedrawStorm(t *testing.T) {
+	s := NewScreen(Options{SourceMap: true})
+	s.Parse(redrawStorm(1000))
+	if got, want := s.LineCount(), 10; got != want {
+		t.Fatalf("s.LineCount() = %d, want %d", got, want)
+	}
+	// Erasing a line drops the ranges that wrote it before
+	for i, ranges := range s.SourceMap() {
+		if len(ranges) != 1 {
+			t.Errorf("line %d has %d source ranges, want 1", i+1, len(ranges))
+		}
+	}
+
+	// Lines returned to without being erased merge their oldest ranges
+	s = NewScreen(Options{SourceMap: true})
+	s.Parse([]byte("first\n" + strings.Repeat("\x1b[1A\rx\ny", 1000)))
+	ranges := s.SourceMap()[0]
+	if got, want := len(ranges), maxSourceRanges; got != want {
+		t.Errorf("line 1 has %d source ranges, want %d", got, want)
+	}
+	if got, want := ranges[0].Start, 0; got != want {
+		t.Errorf("line 1 first source range starts at %d, want %d", got, want)
+	}
+
+	// Parsing a thousand times as many redraws allocates no more
+	parseAllocs := func(cycles int) float64 {
+		input := redrawStorm(cycles)
+		return testing.AllocsPerRun(5, func() {
+			NewScreen(Options{SourceMap: true}).Parse(input)
+		})
+	}
+	if few, many := parseAllocs(10), parseAllocs(10000); many > few {
+		t.Errorf("parsing 10000 redraws made %v allocations, want no more than the %v for 10", many, few)
+	}
+}
+
+func TestEstimateHTMLSize(t *testing.T) {
+	for _, base := range TestFiles {
+		s := NewScreen(Options{})
+		s.Parse(loadFixture(t, base, "raw"))
+		html := s.asHTML()
+		// Enough to render without growing the buffer, without wasting much
+		if got := estimateHTMLSize(s.screen); got < len(html) || got > 2*len(html) {
+			t.Errorf("%s: estimateHTMLSize() = %d, want between %d and %d", base, got, len(html), 2*len(html))
+		}
+	}
+}
+
+func TestRenderPages(t *testing.T) {
+	pages, count := RenderPages([]byte("one\n\x1b[31mtwo\n\nfour\x1b[0m\nfive"), 2)
+	want := []string{
+		"one\n<span class=\"term-fg31\">two</span>",
+		"&nbsp;\n<span class=\"term-fg31\">four</span>",
+		"five",
+	}
+	if count != len(want) {
+		t.Errorf("RenderPages() count = %d, want %d", count, len(want))
+	}
+	var got []string
+	for _, page := range pages {
+		got = append(got, string(page))
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("RenderPages() diff (-got +want):\n%s", diff)
+	}
+}
+
+func TestScreenPage(t *testing.T) {
+	s := NewScreen(Options{
+		LineNumbers:    LineNumberGutter,
+		HighlightLines: []LineRange{{Start: 3, End: 3}},
+		InsertHTML:     map[int]string{2: "<hr>", 4: "<hr>"},
+	})
+	s.Parse([]byte("one\ntwo\nthree\nfour"))
+	if got, want := s.PageCount(2), 2; got != want {
+		t.Errorf("s.PageCount(2) = %d, want %d", got, want)
+	}
+	got := string(s.Page(1, 2))
+	want := strings.Join([]string{
+		`<span class="term-line term-highlight"><span class="term-line-number" data-line-number="3"></span>three</span>`,
+		`<span class="term-line"><span class="term-line-number" data-line-number="4"></span>four</span><hr>`,
+	}, "\n")
+	if got != want {
+		t.Errorf("s.Page(1, 2) = %q, want %q", got, want)
+	}
+	if got := s.Page(2, 2); got != nil {
+		t.Errorf("s.Page(2, 2) = %q, want nil", got)
+	}
+}
+
+func TestScreenRangeAsHTML(t *testing.T) {
+	s := NewScreen(Options{LineNumbers: LineNumberDataAttribute})
+	s.Parse([]byte("one\ntwo\n\nfour\nfive"))
+	if got, want := s.LineCount(), 5; got != want {
+		t.Errorf("s.LineCount() = %d, want %d", got, want)
+	}
+
+	testCases := []struct {
+		lines LineRange
+		want  string
+	}{
+		{LineRange{Start: 2, End: 3}, "<span class=\"term-line\" data-line-number=\"2\">two</span>\n<span class=\"term-line\" data-line-number=\"3\"></span>"},
+		{LineRange{Start: 5, End: 100}, "<span class=\"term-line\" data-line-number=\"5\">five</span>"},
+		{LineRange{Start: -1, End: 1}, "<span class=\"term-line\" data-line-number=\"1\">one</span>"},
+		{LineRange{Start: 6, End: 10}, ""},
+		{LineRange{Start: 3, End: 2}, ""},
+	}
+	for _, tc := range testCases {
+		if got := string(s.RangeAsHTML(tc.lines)); got != tc.want {
+			t.Errorf("s.RangeAsHTML(%v) = %q, want %q", tc.lines, got, tc.want)
+		}
+	}
+}
+
+func TestScreenSearchMatchCount(t *testing.T) {
+	s := NewScreen(Options{Search: regexp.MustCompile(`(?i)error`)})
+	s.Parse([]byte("Error: one error\nnone\n\x1b[31merr\x1b[0mor\n"))
+	if got, want := s.SearchMatchCount(), 3; got != want {
+		t.Errorf("SearchMatchCount() = %d, want %d", got, want)
+	}
+}
+
 func BenchmarkRendererControl(b *testing.B) {
 	benchmark("control.sh", b)
 }
@@ -391,6 +1386,50 @@ func BenchmarkRendererNpm(b *testing.B) {
 	benchmark("npm.sh", b)
 }
 
+func BenchmarkRendererRedrawStorm(b *testing.B) {
+	raw := redrawStorm(10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = Render(raw)
+	}
+}
+
+func BenchmarkHTMLEscaping(b *testing.B) {
+	for name, line := range map[string]string{
+		"plain":   "Compiling terminal-to-html v3.0.0 with some options\n",
+		"paths":   "/usr/local/go/src/runtime/proc.go:250 +0x1d4 /tmp/build/main.go\n",
+		"markup":  `<div class="a" data-b='c'>&nbsp;</div>` + "\n",
+		"unicode": "✔ Übersetzung abgeschlossen — 日本語のテキスト\n",
+	} {
+		b.Run(name, func(b *testing.B) {
+			s := NewScreen(Options{})
+			s.Parse([]byte(strings.Repeat(line, 1000)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = s.AsHTML()
+			}
+		})
+	}
+}
+
+// redrawStorm returns docker pull style output, redrawing the progress of
+// ten layers the given number of times, which should take no more memory to
+// render than drawing them once.
+func redrawStorm(cycles int) []byte {
+	var buf bytes.Buffer
+	for layer := 0; layer < 10; layer++ {
+		fmt.Fprintf(&buf, "%x: Waiting\n", layer)
+	}
+	for i := 0; i < cycles; i++ {
+		buf.WriteString("\x1b[10A")
+		for layer := 0; layer < 10; layer++ {
+			fmt.Fprintf(&buf, "\r\x1b[2K%x: Downloading [%-20s] %d%%\n", layer, strings.Repeat("=", i%20), i%100)
+		}
+	}
+	return buf.Bytes()
+}
+
 func benchmark(filename string, b *testing.B) {
 	raw := loadFixture(b, filename, "raw")
 	b.ResetTimer()
@@ -398,3 +1437,12 @@ func benchmark(filename string, b *testing.B) {
 		_ = Render(raw)
 	}
 }
+
+func TestLineClassesDontAllocate(t *testing.T) {
+	// The line's text is built once by the caller, so matching it against
+	// the patterns needn't allocate unless a class matches
+	opts := Options{LineClasses: DefaultLineClasses}
+	if allocs := testing.AllocsPerRun(100, func() { opts.lineClasses("ok  \tgithub.com/buildkite/terminal-to-html\t0.123s") }); allocs != 0 {
+		t.Errorf("lineClasses() of a line without classes made %v allocations, want none", allocs)
+	}
+}
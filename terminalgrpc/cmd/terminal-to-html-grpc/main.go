@@ -0,0 +1,81 @@
+// terminal-to-html-grpc serves the Renderer gRPC service defined in
+// terminalgrpc/terminal.proto, for platforms that render logs centrally:
+//
+//	terminal-to-html-grpc --listen :50051
+//
+// Clients set deadlines on calls as usual, and RenderStream applies
+// backpressure through gRPC flow control. The server supports reflection, so
+// tools such as grpcurl can call it without the proto file.
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/buildkite/terminal-to-html/v3"
+	"github.com/buildkite/terminal-to-html/v3/terminalgrpc"
+	"github.com/urfave/cli/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+func main() {
+	app := cli.NewApp()
+
+	app.Name = "terminal-to-html-grpc"
+	app.Version = terminal.Version()
+	app.Usage = "serve ANSI to HTML rendering over gRPC"
+	app.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:  "listen",
+			Value: ":50051",
+			Usage: "the address to listen on",
+		},
+		&cli.IntFlag{
+			Name:  "max-message-bytes",
+			Value: 64 << 20,
+			Usage: "the largest request to accept, which limits the input to Render",
+		},
+		&cli.StringFlag{
+			Name:  "input-encoding",
+			Usage: "the encoding of the input of requests that don't set one, e.g. windows-1252, or auto to detect it",
+		},
+	}
+	app.Action = func(c *cli.Context) error {
+		lis, err := net.Listen("tcp", c.String("listen"))
+		if err != nil {
+			return err
+		}
+
+		srv := grpc.NewServer(
+			grpc.MaxRecvMsgSize(c.Int("max-message-bytes")),
+			grpc.MaxSendMsgSize(c.Int("max-message-bytes")),
+		)
+		if name := c.String("input-encoding"); name != "" && name != "auto" {
+			if _, err := terminal.LookupInputEncoding(name); err != nil {
+				return err
+			}
+		}
+		terminalgrpc.RegisterRendererServer(srv, &terminalgrpc.Server{InputEncoding: c.String("input-encoding")})
+		reflection.Register(srv)
+
+		// Finish in-flight calls when asked to stop
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			srv.GracefulStop()
+		}()
+
+		log.Printf("terminal-to-html-grpc listening on %s", lis.Addr())
+		return srv.Serve(lis)
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
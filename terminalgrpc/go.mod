@@ -0,0 +1,24 @@
+module github.com/buildkite/terminal-to-html/v3/terminalgrpc
+
+go 1.21
+
+require (
+	github.com/buildkite/terminal-to-html/v3 v3.0.0
+	github.com/urfave/cli/v2 v2.25.7
+	google.golang.org/grpc v1.65.0
+	google.golang.org/protobuf v1.34.1
+)
+
+require (
+	github.com/cpuguy83/go-md2man/v2 v2.0.2 // indirect
+	github.com/klauspost/compress v1.17.11 // indirect
+	github.com/russross/blackfriday/v2 v2.1.0 // indirect
+	github.com/xrash/smetrics v0.0.0-20201216005158-039620a65673 // indirect
+	golang.org/x/net v0.25.0 // indirect
+	golang.org/x/sys v0.20.0 // indirect
+	golang.org/x/text v0.15.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240528184218-531527333157 // indirect
+)
+
+// The renderer is developed alongside this module
+replace github.com/buildkite/terminal-to-html/v3 => ../
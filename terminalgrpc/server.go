@@ -0,0 +1,210 @@
+/*
+Package terminalgrpc serves rendering as a gRPC service, Renderer, defined in
+terminal.proto. Server implements it with package terminal, and the generated
+RendererClient calls it.
+
+To regenerate the Go code after changing terminal.proto, run buf generate in
+this directory, with protoc-gen-go and protoc-gen-go-grpc installed.
+*/
+package terminalgrpc
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/buildkite/terminal-to-html/v3"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server renders with package terminal. Register it with RegisterRendererServer.
+type Server struct {
+	UnimplementedRendererServer
+
+	// InputEncoding is the input_encoding of requests that don't set one,
+	// e.g. "auto" to detect the encoding of each, so that clients don't
+	// need to know it.
+	InputEncoding string
+}
+
+// Render renders the whole input at once.
+func (s *Server) Render(ctx context.Context, req *RenderRequest) (*RenderResponse, error) {
+	opts, err := terminalOptions(req.GetOptions(), s.InputEncoding)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &RenderResponse{Html: terminal.RenderWithOptions(req.GetInput(), opts)}, nil
+}
+
+// RenderStream renders input as it's received with a terminal.Streamer, and
+// sends the HTML written after each request. Sending blocks while the client
+// isn't receiving, which stops the stream from receiving more input.
+func (s *Server) RenderStream(stream Renderer_RenderStreamServer) error {
+	req, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	opts, err := terminalOptions(req.GetOptions(), s.InputEncoding)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// Buffer the Streamer's many small writes into fewer responses
+	w := bufio.NewWriterSize(responseWriter{stream}, 32*1024)
+	streamer := terminal.NewStreamer(w, opts)
+	for {
+		if _, err := streamer.Write(req.GetInput()); err != nil {
+			return err
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		req, err = stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := streamer.Close(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// responseWriter sends what's written to it as responses.
+type responseWriter struct {
+	stream Renderer_RenderStreamServer
+}
+
+func (w responseWriter) Write(p []byte) (int, error) {
+	if err := w.stream.Send(&RenderResponse{Html: p}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// terminalOptions converts Options to terminal.Options, with inputEncoding
+// if they don't set one.
+func terminalOptions(o *Options, inputEncoding string) (terminal.Options, error) {
+	opts := terminal.Options{
+		MaxInlineImageBytes:        int(o.GetMaxInlineImageBytes()),
+		MaxTotalInlineImageBytes:   int(o.GetMaxTotalInlineImageBytes()),
+		MaxLines:                   int(o.GetMaxLines()),
+		Width:                      int(o.GetWidth()),
+		Height:                     int(o.GetHeight()),
+		LegacyEraseDisplay:         o.GetLegacyEraseDisplay(),
+		TmuxCapture:                o.GetTmuxCapture(),
+		ConPTY:                     o.GetConpty(),
+		TruncateColumns:            int(o.GetTruncateColumns()),
+		ImageProxyURL:              o.GetImageProxyUrl(),
+		AllowedURLSchemes:          o.GetAllowedUrlSchemes(),
+		LinkAttributes:             o.GetLinkAttributes(),
+		CSPSafe:                    o.GetCspSafe(),
+		CollapseRepeatedLines:      o.GetCollapseRepeatedLines(),
+		ProgressFrames:             int(o.GetProgressFrames()),
+		Accessible:                 o.GetAccessible(),
+		SemanticElements:           o.GetSemanticElements(),
+		BreakElements:              o.GetBreakElements(),
+		Linkify:                    o.GetLinkify(),
+		APCNamespaces:              o.GetApcNamespaces(),
+		OmitProcessingInstructions: o.GetOmitProcessingInstructions(),
+		UnknownAPCComments:         o.GetUnknownApcComments(),
+		BuildkiteGroups:            o.GetBuildkiteGroups(),
+		GitHubActionsGroups:        o.GetGithubActionsGroups(),
+		GitLabSections:             o.GetGitlabSections(),
+		TravisFolds:                o.GetTravisFolds(),
+		TitleHeaders:               o.GetTitleHeaders(),
+		EagerImages:                o.GetEagerImages(),
+		GitHubActionsAnnotations:   o.GetGithubActionsAnnotations(),
+		ElapsedTime:                o.GetElapsedTime(),
+	}
+
+	switch o.GetTimestampFormat() {
+	case Options_TIMESTAMP_PROCESSING_INSTRUCTION:
+	case Options_TIMESTAMP_TIME_ELEMENT:
+		opts.TimestampFormat = terminal.TimestampTimeElement
+	case Options_TIMESTAMP_DATA_ATTRIBUTE:
+		opts.TimestampFormat = terminal.TimestampDataAttribute
+	default:
+		return opts, fmt.Errorf("unknown timestamp_format %v", o.GetTimestampFormat())
+	}
+
+	switch o.GetTimestampNormalization() {
+	case Options_TIMESTAMPS_AS_IS:
+	case Options_TIMESTAMPS_CLAMPED:
+		opts.TimestampNormalization = terminal.TimestampsClamped
+	case Options_TIMESTAMPS_OFFSET:
+		opts.TimestampNormalization = terminal.TimestampsOffset
+	default:
+		return opts, fmt.Errorf("unknown timestamp_normalization %v", o.GetTimestampNormalization())
+	}
+
+	switch o.GetAttachments() {
+	case Options_ATTACHMENTS_DROPPED:
+	case Options_ATTACHMENTS_COLLECTED:
+		opts.Attachments = terminal.AttachmentsCollected
+	case Options_ATTACHMENTS_LISTED:
+		opts.Attachments = terminal.AttachmentsListed
+	default:
+		return opts, fmt.Errorf("unknown attachments %v", o.GetAttachments())
+	}
+
+	switch o.GetLineNumbers() {
+	case Options_NO_LINE_NUMBERS:
+	case Options_LINE_NUMBER_GUTTER:
+		opts.LineNumbers = terminal.LineNumberGutter
+	case Options_LINE_NUMBER_DATA_ATTRIBUTE:
+		opts.LineNumbers = terminal.LineNumberDataAttribute
+	default:
+		return opts, fmt.Errorf("unknown line_numbers %v", o.GetLineNumbers())
+	}
+
+	name := o.GetInputEncoding()
+	if name == "" {
+		name = inputEncoding
+	}
+	switch name {
+	case "":
+	case "auto":
+		opts.DetectInputEncoding = true
+	default:
+		enc, err := terminal.LookupInputEncoding(name)
+		if err != nil {
+			return opts, fmt.Errorf("invalid input_encoding: %w", err)
+		}
+		opts.InputEncoding = enc
+	}
+
+	for _, r := range o.GetHighlightLines() {
+		opts.HighlightLines = append(opts.HighlightLines, terminal.LineRange{Start: int(r.GetStart()), End: int(r.GetEnd())})
+	}
+	for _, lc := range o.GetLineClasses() {
+		re, err := regexp.Compile(lc.GetPattern())
+		if err != nil {
+			return opts, fmt.Errorf("invalid line_classes pattern: %w", err)
+		}
+		opts.LineClasses = append(opts.LineClasses, terminal.LineClass{Pattern: re, Class: lc.GetClass()})
+	}
+	if secrets := o.GetRedactStrings(); len(secrets) > 0 {
+		opts.Redact = terminal.RedactStrings(secrets...)
+	}
+
+	if search := o.GetSearch(); search != "" {
+		re, err := regexp.Compile(search)
+		if err != nil {
+			return opts, fmt.Errorf("invalid search: %w", err)
+		}
+		opts.Search = re
+	}
+	return opts, nil
+}
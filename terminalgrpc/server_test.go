@@ -0,0 +1,149 @@
+package terminalgrpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/buildkite/terminal-to-html/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newClient(t *testing.T, server *Server) RendererClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	RegisterRendererServer(srv, server)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc.NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewRendererClient(conn)
+}
+
+func TestRender(t *testing.T) {
+	client := newClient(t, &Server{})
+	input := []byte("\x1b[31mred\x1b[0m\n--- group\ndone")
+
+	resp, err := client.Render(context.Background(), &RenderRequest{
+		Input:   input,
+		Options: &Options{BuildkiteGroups: true, LineNumbers: Options_LINE_NUMBER_GUTTER},
+	})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	want := terminal.RenderWithOptions(input, terminal.Options{BuildkiteGroups: true, LineNumbers: terminal.LineNumberGutter})
+	if !bytes.Equal(resp.GetHtml(), want) {
+		t.Errorf("Render() = %q, want %q", resp.GetHtml(), want)
+	}
+
+	_, err = client.Render(context.Background(), &RenderRequest{Input: input, Options: &Options{Search: "("}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Render(invalid search) error = %v, want InvalidArgument", err)
+	}
+}
+
+func TestRenderInputEncoding(t *testing.T) {
+	client := newClient(t, &Server{InputEncoding: "auto"})
+	want := terminal.Render([]byte("caf\u00e9"))
+
+	for _, opts := range []*Options{nil, {InputEncoding: "windows-1252"}} {
+		resp, err := client.Render(context.Background(), &RenderRequest{Input: []byte("caf\xe9"), Options: opts})
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		if !bytes.Equal(resp.GetHtml(), want) {
+			t.Errorf("Render(%v) = %q, want %q", opts, resp.GetHtml(), want)
+		}
+	}
+
+	_, err := client.Render(context.Background(), &RenderRequest{Options: &Options{InputEncoding: "ebcdic"}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Render(unknown input_encoding) error = %v, want InvalidArgument", err)
+	}
+}
+
+func TestRenderLineOptions(t *testing.T) {
+	client := newClient(t, &Server{})
+	input := []byte("token abc123\nerror: failed\ndone")
+
+	resp, err := client.Render(context.Background(), &RenderRequest{
+		Input: input,
+		Options: &Options{
+			HighlightLines: []*Options_LineRange{{Start: 3, End: 3}},
+			LineClasses:    []*Options_LineClass{{Pattern: `^error:`, Class: "term-error"}},
+			RedactStrings:  []string{"abc123"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	want := terminal.RenderWithOptions(input, terminal.Options{
+		HighlightLines: []terminal.LineRange{{Start: 3, End: 3}},
+		LineClasses:    []terminal.LineClass{{Pattern: regexp.MustCompile(`^error:`), Class: "term-error"}},
+		Redact:         terminal.RedactStrings("abc123"),
+	})
+	if !bytes.Equal(resp.GetHtml(), want) || bytes.Contains(want, []byte("abc123")) {
+		t.Errorf("Render() = %q, want %q", resp.GetHtml(), want)
+	}
+
+	_, err = client.Render(context.Background(), &RenderRequest{Options: &Options{LineClasses: []*Options_LineClass{{Pattern: "("}}}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Render(invalid line_classes) error = %v, want InvalidArgument", err)
+	}
+}
+
+func TestRenderStream(t *testing.T) {
+	client := newClient(t, &Server{})
+	input, err := os.ReadFile("../fixtures/docker-pull.sh.raw")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream, err := client.RenderStream(context.Background())
+	if err != nil {
+		t.Fatalf("RenderStream() error = %v", err)
+	}
+	go func() {
+		opts := &Options{LineNumbers: Options_LINE_NUMBER_DATA_ATTRIBUTE}
+		for i := 0; i < len(input); i += 1000 {
+			end := min(i+1000, len(input))
+			if err := stream.Send(&RenderRequest{Input: input[i:end], Options: opts}); err != nil {
+				return
+			}
+			opts = nil
+		}
+		stream.CloseSend()
+	}()
+
+	var got []byte
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		got = append(got, resp.GetHtml()...)
+	}
+	want := terminal.RenderWithOptions(input, terminal.Options{LineNumbers: terminal.LineNumberDataAttribute})
+	if !bytes.Equal(got, want) {
+		t.Errorf("RenderStream() = %q, want %q", got, want)
+	}
+}
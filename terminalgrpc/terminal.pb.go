@@ -0,0 +1,1127 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.1
+// 	protoc        (unknown)
+// source: terminal.proto
+
+package terminalgrpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Options_TimestampFormat int32
+
+const (
+	Options_TIMESTAMP_PROCESSING_INSTRUCTION Options_TimestampFormat = 0
+	Options_TIMESTAMP_TIME_ELEMENT           Options_TimestampFormat = 1
+	Options_TIMESTAMP_DATA_ATTRIBUTE         Options_TimestampFormat = 2
+)
+
+// Enum value maps for Options_TimestampFormat.
+var (
+	Options_TimestampFormat_name = map[int32]string{
+		0: "TIMESTAMP_PROCESSING_INSTRUCTION",
+		1: "TIMESTAMP_TIME_ELEMENT",
+		2: "TIMESTAMP_DATA_ATTRIBUTE",
+	}
+	Options_TimestampFormat_value = map[string]int32{
+		"TIMESTAMP_PROCESSING_INSTRUCTION": 0,
+		"TIMESTAMP_TIME_ELEMENT":           1,
+		"TIMESTAMP_DATA_ATTRIBUTE":         2,
+	}
+)
+
+func (x Options_TimestampFormat) Enum() *Options_TimestampFormat {
+	p := new(Options_TimestampFormat)
+	*p = x
+	return p
+}
+
+func (x Options_TimestampFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Options_TimestampFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_terminal_proto_enumTypes[0].Descriptor()
+}
+
+func (Options_TimestampFormat) Type() protoreflect.EnumType {
+	return &file_terminal_proto_enumTypes[0]
+}
+
+func (x Options_TimestampFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Options_TimestampFormat.Descriptor instead.
+func (Options_TimestampFormat) EnumDescriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{2, 0}
+}
+
+type Options_TimestampNormalization int32
+
+const (
+	Options_TIMESTAMPS_AS_IS   Options_TimestampNormalization = 0
+	Options_TIMESTAMPS_CLAMPED Options_TimestampNormalization = 1
+	Options_TIMESTAMPS_OFFSET  Options_TimestampNormalization = 2
+)
+
+// Enum value maps for Options_TimestampNormalization.
+var (
+	Options_TimestampNormalization_name = map[int32]string{
+		0: "TIMESTAMPS_AS_IS",
+		1: "TIMESTAMPS_CLAMPED",
+		2: "TIMESTAMPS_OFFSET",
+	}
+	Options_TimestampNormalization_value = map[string]int32{
+		"TIMESTAMPS_AS_IS":   0,
+		"TIMESTAMPS_CLAMPED": 1,
+		"TIMESTAMPS_OFFSET":  2,
+	}
+)
+
+func (x Options_TimestampNormalization) Enum() *Options_TimestampNormalization {
+	p := new(Options_TimestampNormalization)
+	*p = x
+	return p
+}
+
+func (x Options_TimestampNormalization) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Options_TimestampNormalization) Descriptor() protoreflect.EnumDescriptor {
+	return file_terminal_proto_enumTypes[1].Descriptor()
+}
+
+func (Options_TimestampNormalization) Type() protoreflect.EnumType {
+	return &file_terminal_proto_enumTypes[1]
+}
+
+func (x Options_TimestampNormalization) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Options_TimestampNormalization.Descriptor instead.
+func (Options_TimestampNormalization) EnumDescriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{2, 1}
+}
+
+type Options_AttachmentMode int32
+
+const (
+	Options_ATTACHMENTS_DROPPED   Options_AttachmentMode = 0
+	Options_ATTACHMENTS_COLLECTED Options_AttachmentMode = 1
+	Options_ATTACHMENTS_LISTED    Options_AttachmentMode = 2
+)
+
+// Enum value maps for Options_AttachmentMode.
+var (
+	Options_AttachmentMode_name = map[int32]string{
+		0: "ATTACHMENTS_DROPPED",
+		1: "ATTACHMENTS_COLLECTED",
+		2: "ATTACHMENTS_LISTED",
+	}
+	Options_AttachmentMode_value = map[string]int32{
+		"ATTACHMENTS_DROPPED":   0,
+		"ATTACHMENTS_COLLECTED": 1,
+		"ATTACHMENTS_LISTED":    2,
+	}
+)
+
+func (x Options_AttachmentMode) Enum() *Options_AttachmentMode {
+	p := new(Options_AttachmentMode)
+	*p = x
+	return p
+}
+
+func (x Options_AttachmentMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Options_AttachmentMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_terminal_proto_enumTypes[2].Descriptor()
+}
+
+func (Options_AttachmentMode) Type() protoreflect.EnumType {
+	return &file_terminal_proto_enumTypes[2]
+}
+
+func (x Options_AttachmentMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Options_AttachmentMode.Descriptor instead.
+func (Options_AttachmentMode) EnumDescriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{2, 2}
+}
+
+type Options_LineNumberFormat int32
+
+const (
+	Options_NO_LINE_NUMBERS            Options_LineNumberFormat = 0
+	Options_LINE_NUMBER_GUTTER         Options_LineNumberFormat = 1
+	Options_LINE_NUMBER_DATA_ATTRIBUTE Options_LineNumberFormat = 2
+)
+
+// Enum value maps for Options_LineNumberFormat.
+var (
+	Options_LineNumberFormat_name = map[int32]string{
+		0: "NO_LINE_NUMBERS",
+		1: "LINE_NUMBER_GUTTER",
+		2: "LINE_NUMBER_DATA_ATTRIBUTE",
+	}
+	Options_LineNumberFormat_value = map[string]int32{
+		"NO_LINE_NUMBERS":            0,
+		"LINE_NUMBER_GUTTER":         1,
+		"LINE_NUMBER_DATA_ATTRIBUTE": 2,
+	}
+)
+
+func (x Options_LineNumberFormat) Enum() *Options_LineNumberFormat {
+	p := new(Options_LineNumberFormat)
+	*p = x
+	return p
+}
+
+func (x Options_LineNumberFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Options_LineNumberFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_terminal_proto_enumTypes[3].Descriptor()
+}
+
+func (Options_LineNumberFormat) Type() protoreflect.EnumType {
+	return &file_terminal_proto_enumTypes[3]
+}
+
+func (x Options_LineNumberFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Options_LineNumberFormat.Descriptor instead.
+func (Options_LineNumberFormat) EnumDescriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{2, 3}
+}
+
+type RenderRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The raw terminal output, or for RenderStream the next chunk of it, which
+	// may end part way through a line or escape sequence.
+	Input []byte `protobuf:"bytes,1,opt,name=input,proto3" json:"input,omitempty"`
+	// How to render the input. For RenderStream, only the options in the first
+	// request are used.
+	Options *Options `protobuf:"bytes,2,opt,name=options,proto3" json:"options,omitempty"`
+}
+
+func (x *RenderRequest) Reset() {
+	*x = RenderRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_terminal_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RenderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenderRequest) ProtoMessage() {}
+
+func (x *RenderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenderRequest.ProtoReflect.Descriptor instead.
+func (*RenderRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RenderRequest) GetInput() []byte {
+	if x != nil {
+		return x.Input
+	}
+	return nil
+}
+
+func (x *RenderRequest) GetOptions() *Options {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type RenderResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The HTML, or for RenderStream the next part of it.
+	Html []byte `protobuf:"bytes,1,opt,name=html,proto3" json:"html,omitempty"`
+}
+
+func (x *RenderResponse) Reset() {
+	*x = RenderResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_terminal_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RenderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenderResponse) ProtoMessage() {}
+
+func (x *RenderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenderResponse.ProtoReflect.Descriptor instead.
+func (*RenderResponse) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RenderResponse) GetHtml() []byte {
+	if x != nil {
+		return x.Html
+	}
+	return nil
+}
+
+// Options are the terminal.Options that can be sent as data. Of those that
+// are Go functions, Redact is supported as redact_strings, but not LineFilter
+// or Highlighter. InsertHTML isn't supported, as the HTML would be trusted,
+// nor SourceMap, as responses only hold HTML.
+type Options struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MaxInlineImageBytes        int64                   `protobuf:"varint,1,opt,name=max_inline_image_bytes,json=maxInlineImageBytes,proto3" json:"max_inline_image_bytes,omitempty"`
+	MaxTotalInlineImageBytes   int64                   `protobuf:"varint,2,opt,name=max_total_inline_image_bytes,json=maxTotalInlineImageBytes,proto3" json:"max_total_inline_image_bytes,omitempty"`
+	MaxLines                   int64                   `protobuf:"varint,3,opt,name=max_lines,json=maxLines,proto3" json:"max_lines,omitempty"`
+	Width                      int64                   `protobuf:"varint,4,opt,name=width,proto3" json:"width,omitempty"`
+	ImageProxyUrl              string                  `protobuf:"bytes,5,opt,name=image_proxy_url,json=imageProxyUrl,proto3" json:"image_proxy_url,omitempty"`
+	AllowedUrlSchemes          []string                `protobuf:"bytes,6,rep,name=allowed_url_schemes,json=allowedUrlSchemes,proto3" json:"allowed_url_schemes,omitempty"`
+	LinkAttributes             map[string]string       `protobuf:"bytes,7,rep,name=link_attributes,json=linkAttributes,proto3" json:"link_attributes,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	CspSafe                    bool                    `protobuf:"varint,8,opt,name=csp_safe,json=cspSafe,proto3" json:"csp_safe,omitempty"`
+	CollapseRepeatedLines      bool                    `protobuf:"varint,9,opt,name=collapse_repeated_lines,json=collapseRepeatedLines,proto3" json:"collapse_repeated_lines,omitempty"`
+	ProgressFrames             int64                   `protobuf:"varint,10,opt,name=progress_frames,json=progressFrames,proto3" json:"progress_frames,omitempty"`
+	Accessible                 bool                    `protobuf:"varint,11,opt,name=accessible,proto3" json:"accessible,omitempty"`
+	SemanticElements           bool                    `protobuf:"varint,12,opt,name=semantic_elements,json=semanticElements,proto3" json:"semantic_elements,omitempty"`
+	BreakElements              bool                    `protobuf:"varint,13,opt,name=break_elements,json=breakElements,proto3" json:"break_elements,omitempty"`
+	Linkify                    bool                    `protobuf:"varint,14,opt,name=linkify,proto3" json:"linkify,omitempty"`
+	ApcNamespaces              []string                `protobuf:"bytes,15,rep,name=apc_namespaces,json=apcNamespaces,proto3" json:"apc_namespaces,omitempty"`
+	TimestampFormat            Options_TimestampFormat `protobuf:"varint,16,opt,name=timestamp_format,json=timestampFormat,proto3,enum=terminal_to_html.v1.Options_TimestampFormat" json:"timestamp_format,omitempty"`
+	OmitProcessingInstructions bool                    `protobuf:"varint,17,opt,name=omit_processing_instructions,json=omitProcessingInstructions,proto3" json:"omit_processing_instructions,omitempty"`
+	BuildkiteGroups            bool                    `protobuf:"varint,18,opt,name=buildkite_groups,json=buildkiteGroups,proto3" json:"buildkite_groups,omitempty"`
+	GithubActionsGroups        bool                    `protobuf:"varint,19,opt,name=github_actions_groups,json=githubActionsGroups,proto3" json:"github_actions_groups,omitempty"`
+	GitlabSections             bool                    `protobuf:"varint,20,opt,name=gitlab_sections,json=gitlabSections,proto3" json:"gitlab_sections,omitempty"`
+	TravisFolds                bool                    `protobuf:"varint,21,opt,name=travis_folds,json=travisFolds,proto3" json:"travis_folds,omitempty"`
+	GithubActionsAnnotations   bool                    `protobuf:"varint,22,opt,name=github_actions_annotations,json=githubActionsAnnotations,proto3" json:"github_actions_annotations,omitempty"`
+	// A regular expression to highlight matches of.
+	Search             string                   `protobuf:"bytes,23,opt,name=search,proto3" json:"search,omitempty"`
+	LineNumbers        Options_LineNumberFormat `protobuf:"varint,24,opt,name=line_numbers,json=lineNumbers,proto3,enum=terminal_to_html.v1.Options_LineNumberFormat" json:"line_numbers,omitempty"`
+	ElapsedTime        bool                     `protobuf:"varint,25,opt,name=elapsed_time,json=elapsedTime,proto3" json:"elapsed_time,omitempty"`
+	Height             int64                    `protobuf:"varint,26,opt,name=height,proto3" json:"height,omitempty"`
+	LegacyEraseDisplay bool                     `protobuf:"varint,27,opt,name=legacy_erase_display,json=legacyEraseDisplay,proto3" json:"legacy_erase_display,omitempty"`
+	// The name of the encoding of the input, e.g. "windows-1252" or
+	// "utf-16le", or "auto" to detect it. Empty for UTF-8.
+	InputEncoding string `protobuf:"bytes,28,opt,name=input_encoding,json=inputEncoding,proto3" json:"input_encoding,omitempty"`
+	// Render logs captured with tmux capture-pane -e as tmux writes them.
+	TmuxCapture bool `protobuf:"varint,29,opt,name=tmux_capture,json=tmuxCapture,proto3" json:"tmux_capture,omitempty"`
+	// Render output from Windows' pseudo console, with its cursor positioning.
+	Conpty bool `protobuf:"varint,30,opt,name=conpty,proto3" json:"conpty,omitempty"`
+	// Cut lines longer than this many columns short, ending with an ellipsis.
+	TruncateColumns int64 `protobuf:"varint,31,opt,name=truncate_columns,json=truncateColumns,proto3" json:"truncate_columns,omitempty"`
+	// Correct timestamps that go backwards, so elapsed times are never negative.
+	TimestampNormalization Options_TimestampNormalization `protobuf:"varint,32,opt,name=timestamp_normalization,json=timestampNormalization,proto3,enum=terminal_to_html.v1.Options_TimestampNormalization" json:"timestamp_normalization,omitempty"`
+	// Show each change of the terminal title as a header.
+	TitleHeaders bool `protobuf:"varint,33,opt,name=title_headers,json=titleHeaders,proto3" json:"title_headers,omitempty"`
+	// Keep APCs in namespaces that aren't handled as HTML comments.
+	UnknownApcComments bool `protobuf:"varint,34,opt,name=unknown_apc_comments,json=unknownApcComments,proto3" json:"unknown_apc_comments,omitempty"`
+	// Keep files sent with 1337;File that aren't shown as images.
+	Attachments Options_AttachmentMode `protobuf:"varint,35,opt,name=attachments,proto3,enum=terminal_to_html.v1.Options_AttachmentMode" json:"attachments,omitempty"`
+	// Leave loading="lazy" and decoding="async" off images.
+	EagerImages bool `protobuf:"varint,36,opt,name=eager_images,json=eagerImages,proto3" json:"eager_images,omitempty"`
+	// Lines to wrap in spans with the term-highlight class.
+	HighlightLines []*Options_LineRange `protobuf:"bytes,37,rep,name=highlight_lines,json=highlightLines,proto3" json:"highlight_lines,omitempty"`
+	// Classes to add to the spans wrapping lines that match their patterns.
+	LineClasses []*Options_LineClass `protobuf:"bytes,38,rep,name=line_classes,json=lineClasses,proto3" json:"line_classes,omitempty"`
+	// Secrets to replace with ***** wherever they appear in the input.
+	RedactStrings []string `protobuf:"bytes,39,rep,name=redact_strings,json=redactStrings,proto3" json:"redact_strings,omitempty"`
+}
+
+func (x *Options) Reset() {
+	*x = Options{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_terminal_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Options) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Options) ProtoMessage() {}
+
+func (x *Options) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Options.ProtoReflect.Descriptor instead.
+func (*Options) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Options) GetMaxInlineImageBytes() int64 {
+	if x != nil {
+		return x.MaxInlineImageBytes
+	}
+	return 0
+}
+
+func (x *Options) GetMaxTotalInlineImageBytes() int64 {
+	if x != nil {
+		return x.MaxTotalInlineImageBytes
+	}
+	return 0
+}
+
+func (x *Options) GetMaxLines() int64 {
+	if x != nil {
+		return x.MaxLines
+	}
+	return 0
+}
+
+func (x *Options) GetWidth() int64 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *Options) GetImageProxyUrl() string {
+	if x != nil {
+		return x.ImageProxyUrl
+	}
+	return ""
+}
+
+func (x *Options) GetAllowedUrlSchemes() []string {
+	if x != nil {
+		return x.AllowedUrlSchemes
+	}
+	return nil
+}
+
+func (x *Options) GetLinkAttributes() map[string]string {
+	if x != nil {
+		return x.LinkAttributes
+	}
+	return nil
+}
+
+func (x *Options) GetCspSafe() bool {
+	if x != nil {
+		return x.CspSafe
+	}
+	return false
+}
+
+func (x *Options) GetCollapseRepeatedLines() bool {
+	if x != nil {
+		return x.CollapseRepeatedLines
+	}
+	return false
+}
+
+func (x *Options) GetProgressFrames() int64 {
+	if x != nil {
+		return x.ProgressFrames
+	}
+	return 0
+}
+
+func (x *Options) GetAccessible() bool {
+	if x != nil {
+		return x.Accessible
+	}
+	return false
+}
+
+func (x *Options) GetSemanticElements() bool {
+	if x != nil {
+		return x.SemanticElements
+	}
+	return false
+}
+
+func (x *Options) GetBreakElements() bool {
+	if x != nil {
+		return x.BreakElements
+	}
+	return false
+}
+
+func (x *Options) GetLinkify() bool {
+	if x != nil {
+		return x.Linkify
+	}
+	return false
+}
+
+func (x *Options) GetApcNamespaces() []string {
+	if x != nil {
+		return x.ApcNamespaces
+	}
+	return nil
+}
+
+func (x *Options) GetTimestampFormat() Options_TimestampFormat {
+	if x != nil {
+		return x.TimestampFormat
+	}
+	return Options_TIMESTAMP_PROCESSING_INSTRUCTION
+}
+
+func (x *Options) GetOmitProcessingInstructions() bool {
+	if x != nil {
+		return x.OmitProcessingInstructions
+	}
+	return false
+}
+
+func (x *Options) GetBuildkiteGroups() bool {
+	if x != nil {
+		return x.BuildkiteGroups
+	}
+	return false
+}
+
+func (x *Options) GetGithubActionsGroups() bool {
+	if x != nil {
+		return x.GithubActionsGroups
+	}
+	return false
+}
+
+func (x *Options) GetGitlabSections() bool {
+	if x != nil {
+		return x.GitlabSections
+	}
+	return false
+}
+
+func (x *Options) GetTravisFolds() bool {
+	if x != nil {
+		return x.TravisFolds
+	}
+	return false
+}
+
+func (x *Options) GetGithubActionsAnnotations() bool {
+	if x != nil {
+		return x.GithubActionsAnnotations
+	}
+	return false
+}
+
+func (x *Options) GetSearch() string {
+	if x != nil {
+		return x.Search
+	}
+	return ""
+}
+
+func (x *Options) GetLineNumbers() Options_LineNumberFormat {
+	if x != nil {
+		return x.LineNumbers
+	}
+	return Options_NO_LINE_NUMBERS
+}
+
+func (x *Options) GetElapsedTime() bool {
+	if x != nil {
+		return x.ElapsedTime
+	}
+	return false
+}
+
+func (x *Options) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *Options) GetLegacyEraseDisplay() bool {
+	if x != nil {
+		return x.LegacyEraseDisplay
+	}
+	return false
+}
+
+func (x *Options) GetInputEncoding() string {
+	if x != nil {
+		return x.InputEncoding
+	}
+	return ""
+}
+
+func (x *Options) GetTmuxCapture() bool {
+	if x != nil {
+		return x.TmuxCapture
+	}
+	return false
+}
+
+func (x *Options) GetConpty() bool {
+	if x != nil {
+		return x.Conpty
+	}
+	return false
+}
+
+func (x *Options) GetTruncateColumns() int64 {
+	if x != nil {
+		return x.TruncateColumns
+	}
+	return 0
+}
+
+func (x *Options) GetTimestampNormalization() Options_TimestampNormalization {
+	if x != nil {
+		return x.TimestampNormalization
+	}
+	return Options_TIMESTAMPS_AS_IS
+}
+
+func (x *Options) GetTitleHeaders() bool {
+	if x != nil {
+		return x.TitleHeaders
+	}
+	return false
+}
+
+func (x *Options) GetUnknownApcComments() bool {
+	if x != nil {
+		return x.UnknownApcComments
+	}
+	return false
+}
+
+func (x *Options) GetAttachments() Options_AttachmentMode {
+	if x != nil {
+		return x.Attachments
+	}
+	return Options_ATTACHMENTS_DROPPED
+}
+
+func (x *Options) GetEagerImages() bool {
+	if x != nil {
+		return x.EagerImages
+	}
+	return false
+}
+
+func (x *Options) GetHighlightLines() []*Options_LineRange {
+	if x != nil {
+		return x.HighlightLines
+	}
+	return nil
+}
+
+func (x *Options) GetLineClasses() []*Options_LineClass {
+	if x != nil {
+		return x.LineClasses
+	}
+	return nil
+}
+
+func (x *Options) GetRedactStrings() []string {
+	if x != nil {
+		return x.RedactStrings
+	}
+	return nil
+}
+
+// A range of line numbers, counting from 1, including both ends.
+type Options_LineRange struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Start int64 `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End   int64 `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+}
+
+func (x *Options_LineRange) Reset() {
+	*x = Options_LineRange{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_terminal_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Options_LineRange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Options_LineRange) ProtoMessage() {}
+
+func (x *Options_LineRange) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Options_LineRange.ProtoReflect.Descriptor instead.
+func (*Options_LineRange) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{2, 1}
+}
+
+func (x *Options_LineRange) GetStart() int64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *Options_LineRange) GetEnd() int64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+// A class for lines matching a regular expression.
+type Options_LineClass struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Pattern string `protobuf:"bytes,1,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	Class   string `protobuf:"bytes,2,opt,name=class,proto3" json:"class,omitempty"`
+}
+
+func (x *Options_LineClass) Reset() {
+	*x = Options_LineClass{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_terminal_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Options_LineClass) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Options_LineClass) ProtoMessage() {}
+
+func (x *Options_LineClass) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Options_LineClass.ProtoReflect.Descriptor instead.
+func (*Options_LineClass) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{2, 2}
+}
+
+func (x *Options_LineClass) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+func (x *Options_LineClass) GetClass() string {
+	if x != nil {
+		return x.Class
+	}
+	return ""
+}
+
+var File_terminal_proto protoreflect.FileDescriptor
+
+var file_terminal_proto_rawDesc = []byte{
+	0x0a, 0x0e, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x12, 0x13, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x74, 0x6f, 0x5f, 0x68, 0x74,
+	0x6d, 0x6c, 0x2e, 0x76, 0x31, 0x22, 0x5d, 0x0a, 0x0d, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x12, 0x36, 0x0a, 0x07,
+	0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e,
+	0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x74, 0x6f, 0x5f, 0x68, 0x74, 0x6d, 0x6c,
+	0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x07, 0x6f, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x22, 0x24, 0x0a, 0x0e, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x74, 0x6d, 0x6c, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x68, 0x74, 0x6d, 0x6c, 0x22, 0xbd, 0x13, 0x0a, 0x07, 0x4f,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x33, 0x0a, 0x16, 0x6d, 0x61, 0x78, 0x5f, 0x69, 0x6e,
+	0x6c, 0x69, 0x6e, 0x65, 0x5f, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x13, 0x6d, 0x61, 0x78, 0x49, 0x6e, 0x6c, 0x69, 0x6e,
+	0x65, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x3e, 0x0a, 0x1c, 0x6d,
+	0x61, 0x78, 0x5f, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x69, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x5f,
+	0x69, 0x6d, 0x61, 0x67, 0x65, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x18, 0x6d, 0x61, 0x78, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x49, 0x6e, 0x6c, 0x69, 0x6e,
+	0x65, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x6d,
+	0x61, 0x78, 0x5f, 0x6c, 0x69, 0x6e, 0x65, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08,
+	0x6d, 0x61, 0x78, 0x4c, 0x69, 0x6e, 0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x69, 0x64, 0x74,
+	0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x12, 0x26,
+	0x0a, 0x0f, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x5f, 0x70, 0x72, 0x6f, 0x78, 0x79, 0x5f, 0x75, 0x72,
+	0x6c, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x50, 0x72,
+	0x6f, 0x78, 0x79, 0x55, 0x72, 0x6c, 0x12, 0x2e, 0x0a, 0x13, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x65,
+	0x64, 0x5f, 0x75, 0x72, 0x6c, 0x5f, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x65, 0x73, 0x18, 0x06, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x11, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x55, 0x72, 0x6c, 0x53,
+	0x63, 0x68, 0x65, 0x6d, 0x65, 0x73, 0x12, 0x59, 0x0a, 0x0f, 0x6c, 0x69, 0x6e, 0x6b, 0x5f, 0x61,
+	0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x30, 0x2e, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x74, 0x6f, 0x5f, 0x68, 0x74,
+	0x6d, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x4c, 0x69,
+	0x6e, 0x6b, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x52, 0x0e, 0x6c, 0x69, 0x6e, 0x6b, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65,
+	0x73, 0x12, 0x19, 0x0a, 0x08, 0x63, 0x73, 0x70, 0x5f, 0x73, 0x61, 0x66, 0x65, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x07, 0x63, 0x73, 0x70, 0x53, 0x61, 0x66, 0x65, 0x12, 0x36, 0x0a, 0x17,
+	0x63, 0x6f, 0x6c, 0x6c, 0x61, 0x70, 0x73, 0x65, 0x5f, 0x72, 0x65, 0x70, 0x65, 0x61, 0x74, 0x65,
+	0x64, 0x5f, 0x6c, 0x69, 0x6e, 0x65, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52, 0x15, 0x63,
+	0x6f, 0x6c, 0x6c, 0x61, 0x70, 0x73, 0x65, 0x52, 0x65, 0x70, 0x65, 0x61, 0x74, 0x65, 0x64, 0x4c,
+	0x69, 0x6e, 0x65, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73,
+	0x5f, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x70,
+	0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x73, 0x12, 0x1e, 0x0a,
+	0x0a, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x69, 0x62, 0x6c, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x0a, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x69, 0x62, 0x6c, 0x65, 0x12, 0x2b, 0x0a,
+	0x11, 0x73, 0x65, 0x6d, 0x61, 0x6e, 0x74, 0x69, 0x63, 0x5f, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x6e,
+	0x74, 0x73, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x08, 0x52, 0x10, 0x73, 0x65, 0x6d, 0x61, 0x6e, 0x74,
+	0x69, 0x63, 0x45, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x62, 0x72,
+	0x65, 0x61, 0x6b, 0x5f, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x0d, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x0d, 0x62, 0x72, 0x65, 0x61, 0x6b, 0x45, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74,
+	0x73, 0x12, 0x18, 0x0a, 0x07, 0x6c, 0x69, 0x6e, 0x6b, 0x69, 0x66, 0x79, 0x18, 0x0e, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x07, 0x6c, 0x69, 0x6e, 0x6b, 0x69, 0x66, 0x79, 0x12, 0x25, 0x0a, 0x0e, 0x61,
+	0x70, 0x63, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x73, 0x18, 0x0f, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x0d, 0x61, 0x70, 0x63, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63,
+	0x65, 0x73, 0x12, 0x57, 0x0a, 0x10, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x5f,
+	0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x10, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x2c, 0x2e, 0x74,
+	0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x74, 0x6f, 0x5f, 0x68, 0x74, 0x6d, 0x6c, 0x2e,
+	0x76, 0x31, 0x2e, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x52, 0x0f, 0x74, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x40, 0x0a, 0x1c, 0x6f,
+	0x6d, 0x69, 0x74, 0x5f, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x5f, 0x69,
+	0x6e, 0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x11, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x1a, 0x6f, 0x6d, 0x69, 0x74, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x69, 0x6e,
+	0x67, 0x49, 0x6e, 0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x29, 0x0a,
+	0x10, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x6b, 0x69, 0x74, 0x65, 0x5f, 0x67, 0x72, 0x6f, 0x75, 0x70,
+	0x73, 0x18, 0x12, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x6b, 0x69,
+	0x74, 0x65, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x73, 0x12, 0x32, 0x0a, 0x15, 0x67, 0x69, 0x74, 0x68,
+	0x75, 0x62, 0x5f, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x5f, 0x67, 0x72, 0x6f, 0x75, 0x70,
+	0x73, 0x18, 0x13, 0x20, 0x01, 0x28, 0x08, 0x52, 0x13, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x41,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x73, 0x12, 0x27, 0x0a, 0x0f,
+	0x67, 0x69, 0x74, 0x6c, 0x61, 0x62, 0x5f, 0x73, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18,
+	0x14, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0e, 0x67, 0x69, 0x74, 0x6c, 0x61, 0x62, 0x53, 0x65, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x74, 0x72, 0x61, 0x76, 0x69, 0x73, 0x5f,
+	0x66, 0x6f, 0x6c, 0x64, 0x73, 0x18, 0x15, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x74, 0x72, 0x61,
+	0x76, 0x69, 0x73, 0x46, 0x6f, 0x6c, 0x64, 0x73, 0x12, 0x3c, 0x0a, 0x1a, 0x67, 0x69, 0x74, 0x68,
+	0x75, 0x62, 0x5f, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x5f, 0x61, 0x6e, 0x6e, 0x6f, 0x74,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x16, 0x20, 0x01, 0x28, 0x08, 0x52, 0x18, 0x67, 0x69,
+	0x74, 0x68, 0x75, 0x62, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x41, 0x6e, 0x6e, 0x6f, 0x74,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x61, 0x72, 0x63, 0x68,
+	0x18, 0x17, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x65, 0x61, 0x72, 0x63, 0x68, 0x12, 0x50,
+	0x0a, 0x0c, 0x6c, 0x69, 0x6e, 0x65, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x18, 0x18,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x2d, 0x2e, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f,
+	0x74, 0x6f, 0x5f, 0x68, 0x74, 0x6d, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x2e, 0x4c, 0x69, 0x6e, 0x65, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x46, 0x6f, 0x72,
+	0x6d, 0x61, 0x74, 0x52, 0x0b, 0x6c, 0x69, 0x6e, 0x65, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x73,
+	0x12, 0x21, 0x0a, 0x0c, 0x65, 0x6c, 0x61, 0x70, 0x73, 0x65, 0x64, 0x5f, 0x74, 0x69, 0x6d, 0x65,
+	0x18, 0x19, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x65, 0x6c, 0x61, 0x70, 0x73, 0x65, 0x64, 0x54,
+	0x69, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x1a, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x30, 0x0a, 0x14, 0x6c,
+	0x65, 0x67, 0x61, 0x63, 0x79, 0x5f, 0x65, 0x72, 0x61, 0x73, 0x65, 0x5f, 0x64, 0x69, 0x73, 0x70,
+	0x6c, 0x61, 0x79, 0x18, 0x1b, 0x20, 0x01, 0x28, 0x08, 0x52, 0x12, 0x6c, 0x65, 0x67, 0x61, 0x63,
+	0x79, 0x45, 0x72, 0x61, 0x73, 0x65, 0x44, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x12, 0x25, 0x0a,
+	0x0e, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x5f, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x18,
+	0x1c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x45, 0x6e, 0x63, 0x6f,
+	0x64, 0x69, 0x6e, 0x67, 0x12, 0x21, 0x0a, 0x0c, 0x74, 0x6d, 0x75, 0x78, 0x5f, 0x63, 0x61, 0x70,
+	0x74, 0x75, 0x72, 0x65, 0x18, 0x1d, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x74, 0x6d, 0x75, 0x78,
+	0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x6f, 0x6e, 0x70, 0x74,
+	0x79, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x63, 0x6f, 0x6e, 0x70, 0x74, 0x79, 0x12,
+	0x29, 0x0a, 0x10, 0x74, 0x72, 0x75, 0x6e, 0x63, 0x61, 0x74, 0x65, 0x5f, 0x63, 0x6f, 0x6c, 0x75,
+	0x6d, 0x6e, 0x73, 0x18, 0x1f, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0f, 0x74, 0x72, 0x75, 0x6e, 0x63,
+	0x61, 0x74, 0x65, 0x43, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x73, 0x12, 0x6c, 0x0a, 0x17, 0x74, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x5f, 0x6e, 0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x69, 0x7a,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x20, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x33, 0x2e, 0x74, 0x65,
+	0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x74, 0x6f, 0x5f, 0x68, 0x74, 0x6d, 0x6c, 0x2e, 0x76,
+	0x31, 0x2e, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x4e, 0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x52, 0x16, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x4e, 0x6f, 0x72, 0x6d, 0x61,
+	0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x23, 0x0a, 0x0d, 0x74, 0x69, 0x74, 0x6c,
+	0x65, 0x5f, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x18, 0x21, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0c, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x12, 0x30, 0x0a,
+	0x14, 0x75, 0x6e, 0x6b, 0x6e, 0x6f, 0x77, 0x6e, 0x5f, 0x61, 0x70, 0x63, 0x5f, 0x63, 0x6f, 0x6d,
+	0x6d, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x22, 0x20, 0x01, 0x28, 0x08, 0x52, 0x12, 0x75, 0x6e, 0x6b,
+	0x6e, 0x6f, 0x77, 0x6e, 0x41, 0x70, 0x63, 0x43, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x12,
+	0x4d, 0x0a, 0x0b, 0x61, 0x74, 0x74, 0x61, 0x63, 0x68, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x23,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x2b, 0x2e, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f,
+	0x74, 0x6f, 0x5f, 0x68, 0x74, 0x6d, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x2e, 0x41, 0x74, 0x74, 0x61, 0x63, 0x68, 0x6d, 0x65, 0x6e, 0x74, 0x4d, 0x6f, 0x64,
+	0x65, 0x52, 0x0b, 0x61, 0x74, 0x74, 0x61, 0x63, 0x68, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x21,
+	0x0a, 0x0c, 0x65, 0x61, 0x67, 0x65, 0x72, 0x5f, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x73, 0x18, 0x24,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x65, 0x61, 0x67, 0x65, 0x72, 0x49, 0x6d, 0x61, 0x67, 0x65,
+	0x73, 0x12, 0x4f, 0x0a, 0x0f, 0x68, 0x69, 0x67, 0x68, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x5f, 0x6c,
+	0x69, 0x6e, 0x65, 0x73, 0x18, 0x25, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x26, 0x2e, 0x74, 0x65, 0x72,
+	0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x74, 0x6f, 0x5f, 0x68, 0x74, 0x6d, 0x6c, 0x2e, 0x76, 0x31,
+	0x2e, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x4c, 0x69, 0x6e, 0x65, 0x52, 0x61, 0x6e,
+	0x67, 0x65, 0x52, 0x0e, 0x68, 0x69, 0x67, 0x68, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x4c, 0x69, 0x6e,
+	0x65, 0x73, 0x12, 0x49, 0x0a, 0x0c, 0x6c, 0x69, 0x6e, 0x65, 0x5f, 0x63, 0x6c, 0x61, 0x73, 0x73,
+	0x65, 0x73, 0x18, 0x26, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x26, 0x2e, 0x74, 0x65, 0x72, 0x6d, 0x69,
+	0x6e, 0x61, 0x6c, 0x5f, 0x74, 0x6f, 0x5f, 0x68, 0x74, 0x6d, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x4f,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x4c, 0x69, 0x6e, 0x65, 0x43, 0x6c, 0x61, 0x73, 0x73,
+	0x52, 0x0b, 0x6c, 0x69, 0x6e, 0x65, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x65, 0x73, 0x12, 0x25, 0x0a,
+	0x0e, 0x72, 0x65, 0x64, 0x61, 0x63, 0x74, 0x5f, 0x73, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x73, 0x18,
+	0x27, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0d, 0x72, 0x65, 0x64, 0x61, 0x63, 0x74, 0x53, 0x74, 0x72,
+	0x69, 0x6e, 0x67, 0x73, 0x1a, 0x41, 0x0a, 0x13, 0x4c, 0x69, 0x6e, 0x6b, 0x41, 0x74, 0x74, 0x72,
+	0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b,
+	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x33, 0x0a, 0x09, 0x4c, 0x69, 0x6e, 0x65, 0x52,
+	0x61, 0x6e, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x65, 0x6e,
+	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x65, 0x6e, 0x64, 0x1a, 0x3b, 0x0a, 0x09,
+	0x4c, 0x69, 0x6e, 0x65, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61, 0x74,
+	0x74, 0x65, 0x72, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x70, 0x61, 0x74, 0x74,
+	0x65, 0x72, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x22, 0x71, 0x0a, 0x0f, 0x54, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x24, 0x0a, 0x20,
+	0x54, 0x49, 0x4d, 0x45, 0x53, 0x54, 0x41, 0x4d, 0x50, 0x5f, 0x50, 0x52, 0x4f, 0x43, 0x45, 0x53,
+	0x53, 0x49, 0x4e, 0x47, 0x5f, 0x49, 0x4e, 0x53, 0x54, 0x52, 0x55, 0x43, 0x54, 0x49, 0x4f, 0x4e,
+	0x10, 0x00, 0x12, 0x1a, 0x0a, 0x16, 0x54, 0x49, 0x4d, 0x45, 0x53, 0x54, 0x41, 0x4d, 0x50, 0x5f,
+	0x54, 0x49, 0x4d, 0x45, 0x5f, 0x45, 0x4c, 0x45, 0x4d, 0x45, 0x4e, 0x54, 0x10, 0x01, 0x12, 0x1c,
+	0x0a, 0x18, 0x54, 0x49, 0x4d, 0x45, 0x53, 0x54, 0x41, 0x4d, 0x50, 0x5f, 0x44, 0x41, 0x54, 0x41,
+	0x5f, 0x41, 0x54, 0x54, 0x52, 0x49, 0x42, 0x55, 0x54, 0x45, 0x10, 0x02, 0x22, 0x5d, 0x0a, 0x16,
+	0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x4e, 0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x69,
+	0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x10, 0x54, 0x49, 0x4d, 0x45, 0x53, 0x54,
+	0x41, 0x4d, 0x50, 0x53, 0x5f, 0x41, 0x53, 0x5f, 0x49, 0x53, 0x10, 0x00, 0x12, 0x16, 0x0a, 0x12,
+	0x54, 0x49, 0x4d, 0x45, 0x53, 0x54, 0x41, 0x4d, 0x50, 0x53, 0x5f, 0x43, 0x4c, 0x41, 0x4d, 0x50,
+	0x45, 0x44, 0x10, 0x01, 0x12, 0x15, 0x0a, 0x11, 0x54, 0x49, 0x4d, 0x45, 0x53, 0x54, 0x41, 0x4d,
+	0x50, 0x53, 0x5f, 0x4f, 0x46, 0x46, 0x53, 0x45, 0x54, 0x10, 0x02, 0x22, 0x5c, 0x0a, 0x0e, 0x41,
+	0x74, 0x74, 0x61, 0x63, 0x68, 0x6d, 0x65, 0x6e, 0x74, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x17, 0x0a,
+	0x13, 0x41, 0x54, 0x54, 0x41, 0x43, 0x48, 0x4d, 0x45, 0x4e, 0x54, 0x53, 0x5f, 0x44, 0x52, 0x4f,
+	0x50, 0x50, 0x45, 0x44, 0x10, 0x00, 0x12, 0x19, 0x0a, 0x15, 0x41, 0x54, 0x54, 0x41, 0x43, 0x48,
+	0x4d, 0x45, 0x4e, 0x54, 0x53, 0x5f, 0x43, 0x4f, 0x4c, 0x4c, 0x45, 0x43, 0x54, 0x45, 0x44, 0x10,
+	0x01, 0x12, 0x16, 0x0a, 0x12, 0x41, 0x54, 0x54, 0x41, 0x43, 0x48, 0x4d, 0x45, 0x4e, 0x54, 0x53,
+	0x5f, 0x4c, 0x49, 0x53, 0x54, 0x45, 0x44, 0x10, 0x02, 0x22, 0x5f, 0x0a, 0x10, 0x4c, 0x69, 0x6e,
+	0x65, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x13, 0x0a,
+	0x0f, 0x4e, 0x4f, 0x5f, 0x4c, 0x49, 0x4e, 0x45, 0x5f, 0x4e, 0x55, 0x4d, 0x42, 0x45, 0x52, 0x53,
+	0x10, 0x00, 0x12, 0x16, 0x0a, 0x12, 0x4c, 0x49, 0x4e, 0x45, 0x5f, 0x4e, 0x55, 0x4d, 0x42, 0x45,
+	0x52, 0x5f, 0x47, 0x55, 0x54, 0x54, 0x45, 0x52, 0x10, 0x01, 0x12, 0x1e, 0x0a, 0x1a, 0x4c, 0x49,
+	0x4e, 0x45, 0x5f, 0x4e, 0x55, 0x4d, 0x42, 0x45, 0x52, 0x5f, 0x44, 0x41, 0x54, 0x41, 0x5f, 0x41,
+	0x54, 0x54, 0x52, 0x49, 0x42, 0x55, 0x54, 0x45, 0x10, 0x02, 0x32, 0xba, 0x01, 0x0a, 0x08, 0x52,
+	0x65, 0x6e, 0x64, 0x65, 0x72, 0x65, 0x72, 0x12, 0x51, 0x0a, 0x06, 0x52, 0x65, 0x6e, 0x64, 0x65,
+	0x72, 0x12, 0x22, 0x2e, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x74, 0x6f, 0x5f,
+	0x68, 0x74, 0x6d, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x23, 0x2e, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c,
+	0x5f, 0x74, 0x6f, 0x5f, 0x68, 0x74, 0x6d, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x6e, 0x64,
+	0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x5b, 0x0a, 0x0c, 0x52, 0x65,
+	0x6e, 0x64, 0x65, 0x72, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x22, 0x2e, 0x74, 0x65, 0x72,
+	0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x74, 0x6f, 0x5f, 0x68, 0x74, 0x6d, 0x6c, 0x2e, 0x76, 0x31,
+	0x2e, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x23,
+	0x2e, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x74, 0x6f, 0x5f, 0x68, 0x74, 0x6d,
+	0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x28, 0x01, 0x30, 0x01, 0x42, 0x37, 0x5a, 0x35, 0x67, 0x69, 0x74, 0x68, 0x75,
+	0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x6b, 0x69, 0x74, 0x65, 0x2f,
+	0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x2d, 0x74, 0x6f, 0x2d, 0x68, 0x74, 0x6d, 0x6c,
+	0x2f, 0x76, 0x33, 0x2f, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x67, 0x72, 0x70, 0x63,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_terminal_proto_rawDescOnce sync.Once
+	file_terminal_proto_rawDescData = file_terminal_proto_rawDesc
+)
+
+func file_terminal_proto_rawDescGZIP() []byte {
+	file_terminal_proto_rawDescOnce.Do(func() {
+		file_terminal_proto_rawDescData = protoimpl.X.CompressGZIP(file_terminal_proto_rawDescData)
+	})
+	return file_terminal_proto_rawDescData
+}
+
+var file_terminal_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
+var file_terminal_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_terminal_proto_goTypes = []interface{}{
+	(Options_TimestampFormat)(0),        // 0: terminal_to_html.v1.Options.TimestampFormat
+	(Options_TimestampNormalization)(0), // 1: terminal_to_html.v1.Options.TimestampNormalization
+	(Options_AttachmentMode)(0),         // 2: terminal_to_html.v1.Options.AttachmentMode
+	(Options_LineNumberFormat)(0),       // 3: terminal_to_html.v1.Options.LineNumberFormat
+	(*RenderRequest)(nil),               // 4: terminal_to_html.v1.RenderRequest
+	(*RenderResponse)(nil),              // 5: terminal_to_html.v1.RenderResponse
+	(*Options)(nil),                     // 6: terminal_to_html.v1.Options
+	nil,                                 // 7: terminal_to_html.v1.Options.LinkAttributesEntry
+	(*Options_LineRange)(nil),           // 8: terminal_to_html.v1.Options.LineRange
+	(*Options_LineClass)(nil),           // 9: terminal_to_html.v1.Options.LineClass
+}
+var file_terminal_proto_depIdxs = []int32{
+	6,  // 0: terminal_to_html.v1.RenderRequest.options:type_name -> terminal_to_html.v1.Options
+	7,  // 1: terminal_to_html.v1.Options.link_attributes:type_name -> terminal_to_html.v1.Options.LinkAttributesEntry
+	0,  // 2: terminal_to_html.v1.Options.timestamp_format:type_name -> terminal_to_html.v1.Options.TimestampFormat
+	3,  // 3: terminal_to_html.v1.Options.line_numbers:type_name -> terminal_to_html.v1.Options.LineNumberFormat
+	1,  // 4: terminal_to_html.v1.Options.timestamp_normalization:type_name -> terminal_to_html.v1.Options.TimestampNormalization
+	2,  // 5: terminal_to_html.v1.Options.attachments:type_name -> terminal_to_html.v1.Options.AttachmentMode
+	8,  // 6: terminal_to_html.v1.Options.highlight_lines:type_name -> terminal_to_html.v1.Options.LineRange
+	9,  // 7: terminal_to_html.v1.Options.line_classes:type_name -> terminal_to_html.v1.Options.LineClass
+	4,  // 8: terminal_to_html.v1.Renderer.Render:input_type -> terminal_to_html.v1.RenderRequest
+	4,  // 9: terminal_to_html.v1.Renderer.RenderStream:input_type -> terminal_to_html.v1.RenderRequest
+	5,  // 10: terminal_to_html.v1.Renderer.Render:output_type -> terminal_to_html.v1.RenderResponse
+	5,  // 11: terminal_to_html.v1.Renderer.RenderStream:output_type -> terminal_to_html.v1.RenderResponse
+	10, // [10:12] is the sub-list for method output_type
+	8,  // [8:10] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_terminal_proto_init() }
+func file_terminal_proto_init() {
+	if File_terminal_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_terminal_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RenderRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_terminal_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RenderResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_terminal_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Options); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_terminal_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Options_LineRange); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_terminal_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Options_LineClass); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_terminal_proto_rawDesc,
+			NumEnums:      4,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_terminal_proto_goTypes,
+		DependencyIndexes: file_terminal_proto_depIdxs,
+		EnumInfos:         file_terminal_proto_enumTypes,
+		MessageInfos:      file_terminal_proto_msgTypes,
+	}.Build()
+	File_terminal_proto = out.File
+	file_terminal_proto_rawDesc = nil
+	file_terminal_proto_goTypes = nil
+	file_terminal_proto_depIdxs = nil
+}
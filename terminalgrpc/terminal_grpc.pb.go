@@ -0,0 +1,195 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: terminal.proto
+
+package terminalgrpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	Renderer_Render_FullMethodName       = "/terminal_to_html.v1.Renderer/Render"
+	Renderer_RenderStream_FullMethodName = "/terminal_to_html.v1.Renderer/RenderStream"
+)
+
+// RendererClient is the client API for Renderer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Renderer renders terminal output as HTML. The HTML needs to be used with
+// the terminal-to-html stylesheet and wrapped in a term-container div.
+type RendererClient interface {
+	// Render renders the whole input at once.
+	Render(ctx context.Context, in *RenderRequest, opts ...grpc.CallOption) (*RenderResponse, error)
+	// RenderStream renders input sent in chunks, and streams back the HTML of
+	// lines as soon as they can no longer change. Only lines within 100 lines
+	// of the cursor can be changed by cursor movement.
+	RenderStream(ctx context.Context, opts ...grpc.CallOption) (Renderer_RenderStreamClient, error)
+}
+
+type rendererClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRendererClient(cc grpc.ClientConnInterface) RendererClient {
+	return &rendererClient{cc}
+}
+
+func (c *rendererClient) Render(ctx context.Context, in *RenderRequest, opts ...grpc.CallOption) (*RenderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RenderResponse)
+	err := c.cc.Invoke(ctx, Renderer_Render_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rendererClient) RenderStream(ctx context.Context, opts ...grpc.CallOption) (Renderer_RenderStreamClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Renderer_ServiceDesc.Streams[0], Renderer_RenderStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &rendererRenderStreamClient{ClientStream: stream}
+	return x, nil
+}
+
+type Renderer_RenderStreamClient interface {
+	Send(*RenderRequest) error
+	Recv() (*RenderResponse, error)
+	grpc.ClientStream
+}
+
+type rendererRenderStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *rendererRenderStreamClient) Send(m *RenderRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *rendererRenderStreamClient) Recv() (*RenderResponse, error) {
+	m := new(RenderResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// RendererServer is the server API for Renderer service.
+// All implementations must embed UnimplementedRendererServer
+// for forward compatibility
+//
+// Renderer renders terminal output as HTML. The HTML needs to be used with
+// the terminal-to-html stylesheet and wrapped in a term-container div.
+type RendererServer interface {
+	// Render renders the whole input at once.
+	Render(context.Context, *RenderRequest) (*RenderResponse, error)
+	// RenderStream renders input sent in chunks, and streams back the HTML of
+	// lines as soon as they can no longer change. Only lines within 100 lines
+	// of the cursor can be changed by cursor movement.
+	RenderStream(Renderer_RenderStreamServer) error
+	mustEmbedUnimplementedRendererServer()
+}
+
+// UnimplementedRendererServer must be embedded to have forward compatible implementations.
+type UnimplementedRendererServer struct {
+}
+
+func (UnimplementedRendererServer) Render(context.Context, *RenderRequest) (*RenderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Render not implemented")
+}
+func (UnimplementedRendererServer) RenderStream(Renderer_RenderStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method RenderStream not implemented")
+}
+func (UnimplementedRendererServer) mustEmbedUnimplementedRendererServer() {}
+
+// UnsafeRendererServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RendererServer will
+// result in compilation errors.
+type UnsafeRendererServer interface {
+	mustEmbedUnimplementedRendererServer()
+}
+
+func RegisterRendererServer(s grpc.ServiceRegistrar, srv RendererServer) {
+	s.RegisterService(&Renderer_ServiceDesc, srv)
+}
+
+func _Renderer_Render_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RendererServer).Render(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Renderer_Render_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RendererServer).Render(ctx, req.(*RenderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Renderer_RenderStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(RendererServer).RenderStream(&rendererRenderStreamServer{ServerStream: stream})
+}
+
+type Renderer_RenderStreamServer interface {
+	Send(*RenderResponse) error
+	Recv() (*RenderRequest, error)
+	grpc.ServerStream
+}
+
+type rendererRenderStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *rendererRenderStreamServer) Send(m *RenderResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *rendererRenderStreamServer) Recv() (*RenderRequest, error) {
+	m := new(RenderRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Renderer_ServiceDesc is the grpc.ServiceDesc for Renderer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Renderer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "terminal_to_html.v1.Renderer",
+	HandlerType: (*RendererServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Render",
+			Handler:    _Renderer_Render_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "RenderStream",
+			Handler:       _Renderer_RenderStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "terminal.proto",
+}
@@ -0,0 +1,94 @@
+package terminal
+
+import "bytes"
+
+// unescapeTmux replaces the octal escapes that tmux capture-pane -C writes
+// for non-printable characters, e.g. \033 for an escape character, and \\ for
+// a backslash, with the characters they stand for. As tmux doesn't escape
+// newlines, an escape is never cut off by the end of a line.
+func unescapeTmux(input []byte) []byte {
+	i := bytes.IndexByte(input, '\\')
+	if i < 0 {
+		return input
+	}
+	out := make([]byte, 0, len(input))
+	for i >= 0 {
+		out = append(out, input[:i]...)
+		input = input[i:]
+		switch {
+		case len(input) >= 2 && input[1] == '\\':
+			out = append(out, '\\')
+			input = input[2:]
+		case len(input) >= 4 && isOctal(input[1]) && isOctal(input[2]) && isOctal(input[3]) && input[1] <= '3':
+			out = append(out, (input[1]-'0')<<6|(input[2]-'0')<<3|(input[3]-'0'))
+			input = input[4:]
+		default:
+			// Not an escape, so left as it is
+			out = append(out, '\\')
+			input = input[1:]
+		}
+		i = bytes.IndexByte(input, '\\')
+	}
+	return append(out, input...)
+}
+
+// tmuxUnescaper unescapes input for TmuxCapture a chunk at a time, keeping an
+// escape cut off at the end of a chunk, e.g. \03, for the next.
+type tmuxUnescaper struct {
+	pending []byte
+}
+
+// unescape returns the input unescaped, as unescapeTmux does, after any escape
+// kept from the last call. Unless atEOF is set, an escape cut off at the end
+// of the input is kept back.
+func (u *tmuxUnescaper) unescape(input []byte, atEOF bool) []byte {
+	if len(u.pending) > 0 {
+		input = append(u.pending, input...)
+		u.pending = nil
+	}
+	if !atEOF {
+		if n := cutOffTmuxEscape(input); n > 0 {
+			// Copied, as the caller can reuse input
+			u.pending = append([]byte(nil), input[len(input)-n:]...)
+			input = input[:len(input)-n]
+		}
+	}
+	return unescapeTmux(input)
+}
+
+// cutOffTmuxEscape returns the length of the start of an escape at the end of
+// input, e.g. 2 for input ending in \0, or 0 if it doesn't end part way
+// through one.
+func cutOffTmuxEscape(input []byte) int {
+	i := bytes.IndexByte(input, '\\')
+	for i >= 0 {
+		rest := input[i:]
+		if len(rest) >= 2 && rest[1] == '\\' {
+			i += 2
+		} else if len(rest) < 4 && octalPrefix(rest[1:]) {
+			return len(rest)
+		} else {
+			i++
+		}
+		j := bytes.IndexByte(input[i:], '\\')
+		if j < 0 {
+			return 0
+		}
+		i += j
+	}
+	return 0
+}
+
+// octalPrefix reports whether b could start the digits of an octal escape.
+func octalPrefix(b []byte) bool {
+	for i, c := range b {
+		if !isOctal(c) || (i == 0 && c > '3') {
+			return false
+		}
+	}
+	return true
+}
+
+func isOctal(c byte) bool {
+	return c >= '0' && c <= '7'
+}
@@ -0,0 +1,83 @@
+package terminal
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTmuxCaptureFixtures(t *testing.T) {
+	for _, base := range []string{"tmux-capture.sh", "tmux-capture-escaped.sh"} {
+		raw := loadFixture(t, base, "raw")
+		want := string(loadFixture(t, base, "rendered"))
+		if got := string(RenderWithOptions(raw, Options{TmuxCapture: true})); got != want {
+			t.Errorf("%s: got\n%s\nwant\n%s", base, got, want)
+		}
+	}
+}
+
+func TestTmuxCapture(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"curly underline", "\x1b[4:3mcurly\x1b[4:0m plain", `<span class="term-fg4">curly</span> plain`},
+		{"xterm color subparameters", "\x1b[38:5:208;48:5:17mhi", `<span class="term-fgx208 term-bgx17">hi</span>`},
+		{"24-bit color subparameters", "\x1b[1;38:2::255:0:0mhi", `<span class="term-fg1">hi</span>`},
+		{"24-bit colors", "\x1b[1;38;2;255;0;4;48;2;0;0;0;3mhello", `<span class="term-fg1 term-fg3">hello</span>`},
+		{"underline color", "\x1b[58:2::255:0:0;4mhi", `<span class="term-fg4">hi</span>`},
+		{"hyperlink ended with ST", "\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\ after", "link after"},
+		{"hyperlink ended with BEL", "\x1b]8;;https://example.com\alink\x1b]8;;\a after", "link after"},
+		{"octal escapes", `\033[31mred\033[0m C:\\temp \d \9`, `<span class="term-fg31">red</span> C:\temp \d \9`},
+	}
+	for _, tc := range testCases {
+		if got := string(RenderWithOptions([]byte(tc.input), Options{TmuxCapture: true})); got != tc.want {
+			t.Errorf("%s: RenderWithOptions(%q) = %q, want %q", tc.name, tc.input, got, tc.want)
+		}
+	}
+
+	// Without the option, these are left as they are
+	if got, want := string(Render([]byte(`\033[31m`))), `\033[31m`; got != want {
+		t.Errorf(`Render(\033[31m) = %q, want %q`, got, want)
+	}
+	if got, want := string(Render([]byte("\x1b[38;2;1;2;3mhi"))), `<span class="term-fg2 term-fg3">hi</span>`; got != want {
+		t.Errorf("Render(24-bit color) = %q, want %q", got, want)
+	}
+}
+
+func TestTmuxCaptureClose(t *testing.T) {
+	screen := NewScreen(Options{TmuxCapture: true})
+	screen.Parse([]byte(`abc\03`))
+	screen.Close()
+	if got, want := string(screen.AsHTML()), `abc\03`; got != want {
+		t.Errorf("AsHTML() after Close() = %q, want %q", got, want)
+	}
+}
+
+func TestTmuxCaptureSplitEscapes(t *testing.T) {
+	input := `\033[31mred\033[0m C:\\temp\\ \0`
+	want := `<span class="term-fg31">red</span> C:\temp\ \0`
+	// Split at each byte, so that every escape is cut off by some split
+	for i := 1; i < len(input); i++ {
+		// Parse doesn't keep escape sequences split between calls, only
+		// tmux's escapes
+		if cutOffTmuxEscape([]byte(input[:i])) > 0 {
+			screen := NewScreen(Options{TmuxCapture: true})
+			screen.Parse([]byte(input[:i]))
+			screen.Parse([]byte(input[i:]))
+			screen.Close()
+			if got := string(screen.AsHTML()); got != want {
+				t.Errorf("split at %d: got %q, want %q", i, got, want)
+			}
+		}
+
+		var buf bytes.Buffer
+		s := NewStreamer(&buf, Options{TmuxCapture: true})
+		s.Write([]byte(input[:i]))
+		s.Write([]byte(input[i:]))
+		s.Close()
+		if got := buf.String(); got != want {
+			t.Errorf("streamed split at %d: got %q, want %q", i, got, want)
+		}
+	}
+}
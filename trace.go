@@ -0,0 +1,119 @@
+package terminal
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxTracedSequence is the most bytes of a sequence written to opts.Trace,
+// so that inline images don't fill it with base64.
+const maxTracedSequence = 64
+
+// trace writes an event to opts.Trace for the character at the offset at in
+// the input, which was parsed in mode, if it finished an escape sequence or
+// was a newline, carriage return or backspace.
+func (p *parser) trace(mode int, at, charLen int) {
+	s := p.screen
+	if mode == MODE_NORMAL {
+		switch p.ansi[at] {
+		case '\n':
+			p.writeTrace(at, "C0", p.ansi[at:at+1], "newline, cursor to "+s.cursorPosition())
+		case '\r':
+			p.writeTrace(at, "C0", p.ansi[at:at+1], "carriage return, cursor to "+s.cursorPosition())
+		case '\b':
+			p.writeTrace(at, "C0", p.ansi[at:at+1], "backspace, cursor to "+s.cursorPosition())
+		}
+		return
+	}
+	if p.mode != MODE_NORMAL {
+		// The sequence continues
+		return
+	}
+	sequence := p.ansi[p.escapeStartedAt : at+charLen]
+	if n := len(s.sequences.unknown); n > p.tracedUnknown {
+		p.tracedUnknown = n
+		p.writeTrace(p.escapeStartedAt, "ESC", sequence, "unknown, escape character skipped and the rest rendered as text")
+		return
+	}
+
+	switch sequence[1] {
+	case '[':
+		final, _ := utf8.DecodeLastRune(sequence)
+		var action string
+		switch unicode.ToUpper(final) {
+		case 'M':
+			if classes := s.style.appendClasses(nil); len(classes) > 0 {
+				action = "style " + string(classes)
+			} else {
+				action = "reset style"
+			}
+		case 'A', 'B', 'C', 'D', 'G':
+			action = "move cursor to " + s.cursorPosition()
+		case 'J':
+			action = fmt.Sprintf("erase in display, %d lines left", len(s.screen))
+		case 'K':
+			action = "erase in line"
+		case 'H', 'L':
+			action = "set or reset mode, ignored"
+		default:
+			action = "ignored"
+		}
+		if s.opts.ConPTY {
+			switch final {
+			case 'H', 'f':
+				action = "move cursor to " + s.cursorPosition()
+			case 'X':
+				action = "erase characters"
+			}
+		}
+		p.writeTrace(p.escapeStartedAt, "CSI", sequence, action)
+	case ']':
+		action := "ignored"
+		if bytes.HasPrefix(sequence[2:], elementSequencePrefix) {
+			action = "element"
+		}
+		p.writeTrace(p.escapeStartedAt, "OSC", sequence, action)
+	case '_':
+		action := "ignored"
+		namespace, _, _ := bytes.Cut(sequence[2:], []byte(";"))
+		if string(namespace) == bkNamespace || slices.Contains(s.opts.APCNamespaces, string(namespace)) {
+			action = "line metadata " + string(namespace)
+		}
+		p.writeTrace(p.escapeStartedAt, "APC", sequence, action)
+	case '(', ')':
+		p.writeTrace(p.escapeStartedAt, "ESC", sequence, "character set, ignored")
+	case 'M':
+		p.writeTrace(p.escapeStartedAt, "ESC", sequence, "reverse index, cursor to "+s.cursorPosition())
+	case '7':
+		p.writeTrace(p.escapeStartedAt, "ESC", sequence, "save cursor")
+	case '8':
+		p.writeTrace(p.escapeStartedAt, "ESC", sequence, "restore cursor to "+s.cursorPosition())
+	}
+}
+
+// writeTrace writes an event to opts.Trace as a line of tab-separated fields:
+// its offset in the input, its kind, its sequence quoted as a Go string, the
+// parameters of a control sequence, and the action taken.
+func (p *parser) writeTrace(at int, kind string, sequence []byte, action string) {
+	quoted := fmt.Sprintf("%q", sequence)
+	if len(sequence) > maxTracedSequence {
+		quoted = fmt.Sprintf("%q... (%d bytes)", sequence[:maxTracedSequence], len(sequence))
+	}
+	params := ""
+	if kind == "CSI" {
+		// Between ESC [ and the final character
+		_, n := utf8.DecodeLastRune(sequence)
+		params = string(sequence[2 : len(sequence)-n])
+	}
+	// Errors are ignored, as the trace is only for debugging
+	_, _ = fmt.Fprintf(p.screen.opts.Trace, "%d\t%s\t%s\t%s\t%s\n", p.screen.parsed+at, kind, quoted, params, action)
+}
+
+// cursorPosition describes where the cursor is, counting lines and columns
+// from 1.
+func (s *Screen) cursorPosition() string {
+	return fmt.Sprintf("line %d, column %d", s.dropped+s.y+1, s.x+1)
+}
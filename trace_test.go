@@ -0,0 +1,61 @@
+package terminal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	input := "a\x1b[31;1mred\x1b[0m\x1b[5x\r\nb\x1b[2A\x1b]0;title\a\x1b]1339;url=https://example.com\a" +
+		"\x1b_bk;t=1\a\x1b7\x1b(B\x1b8\x1bM\x1b[?25l\x1b]1337;File"
+	want := strings.Join([]string{
+		"1\tCSI\t\"\\x1b[31;1m\"\t31;1\tstyle term-fg31 term-fg1",
+		"11\tCSI\t\"\\x1b[0m\"\t0\treset style",
+		"15\tESC\t\"\\x1b[5x\"\t\tunknown, escape character skipped and the rest rendered as text",
+		"19\tC0\t\"\\r\"\t\tcarriage return, cursor to line 1, column 1",
+		"20\tC0\t\"\\n\"\t\tnewline, cursor to line 2, column 1",
+		"22\tCSI\t\"\\x1b[2A\"\t2\tmove cursor to line 1, column 2",
+		"26\tOSC\t\"\\x1b]0;title\\a\"\t\tignored",
+		"36\tOSC\t\"\\x1b]1339;url=https://example.com\\a\"\t\telement",
+		"67\tAPC\t\"\\x1b_bk;t=1\\a\"\t\tline metadata bk",
+		"76\tESC\t\"\\x1b7\"\t\tsave cursor",
+		"78\tESC\t\"\\x1b(B\"\t\tcharacter set, ignored",
+		"81\tESC\t\"\\x1b8\"\t\trestore cursor to line 1, column 3",
+		"83\tESC\t\"\\x1bM\"\t\treverse index, cursor to line 1, column 3",
+		"85\tCSI\t\"\\x1b[?25l\"\t?25\tset or reset mode, ignored",
+		"91\tESC\t\"\\x1b]1337;File\"\t\tunterminated, ignored",
+	}, "\n") + "\n"
+
+	var trace bytes.Buffer
+	RenderWithOptions([]byte(input), Options{Trace: &trace})
+	if got := trace.String(); got != want {
+		t.Errorf("got trace:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestTraceLongSequence(t *testing.T) {
+	var trace bytes.Buffer
+	RenderWithOptions([]byte("\x1b]1337;File=inline=1:"+strings.Repeat("A", 1000)+"\a"), Options{Trace: &trace})
+	want := `0	OSC	"\x1b]1337;File=inline=1:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"... (1022 bytes)		element` + "\n"
+	if got := trace.String(); got != want {
+		t.Errorf("got trace %q, want %q", got, want)
+	}
+}
+
+func TestTraceOffsetsAcrossWrites(t *testing.T) {
+	var trace, output bytes.Buffer
+	s := NewStreamer(&output, Options{Trace: &trace})
+	s.Write([]byte("one\n\x1bZ"))
+	s.Write([]byte("two\n\x1bZ"))
+	s.Close()
+	want := strings.Join([]string{
+		"3\tC0\t\"\\n\"\t\tnewline, cursor to line 2, column 1",
+		"4\tESC\t\"\\x1bZ\"\t\tunknown, escape character skipped and the rest rendered as text",
+		"9\tC0\t\"\\n\"\t\tnewline, cursor to line 3, column 1",
+		"10\tESC\t\"\\x1bZ\"\t\tunknown, escape character skipped and the rest rendered as text",
+	}, "\n") + "\n"
+	if got := trace.String(); got != want {
+		t.Errorf("got trace:\n%s\nwant:\n%s", got, want)
+	}
+}
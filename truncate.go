@@ -0,0 +1,70 @@
+package terminal
+
+import "bytes"
+
+// TruncateANSI cuts each line of ANSI input that takes up more than columns
+// columns short, ending it with an ellipsis, e.g. for a preview of the first
+// line of an error. Cursor movement is applied first, as by SerializeANSI, so
+// the output is only text, styles and links. The whole style is written
+// wherever it changes, including for the ellipsis, and reset at the end of
+// each line, so every line can be shown on its own. Blanks at the end of a
+// line are left out. Links aren't split: one that doesn't fit is left out.
+// Widths are counted as by DisplayWidth, with tabs moving to the next
+// multiple of 8 columns. If columns is 0 or less, lines aren't truncated.
+func TruncateANSI(input []byte, columns int) []byte {
+	s := NewScreen(Options{})
+	s.Parse(input)
+	var buf bytes.Buffer
+	for i, line := range s.screen {
+		nodes := trimBlanks(line.nodes)
+		if cut, ok := ansiTruncation(nodes, columns); ok {
+			ellipsis := node{blob: '…', style: nodes[cut].style}
+			nodes = append(nodes[:cut:cut], ellipsis)
+		}
+		writeANSINodes(&buf, nodes)
+		if i < len(s.screen)-1 {
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes()
+}
+
+// trimBlanks returns the nodes without the blanks at the end.
+func trimBlanks(nodes []node) []node {
+	n := len(nodes)
+	for n > 0 && nodes[n-1].elem == nil && nodes[n-1].blob == ' ' {
+		n--
+	}
+	return nodes[:n]
+}
+
+// ansiTruncation returns how many nodes fit in columns columns, leaving one
+// for an ellipsis, and whether the nodes need truncating.
+func ansiTruncation(nodes []node, columns int) (int, bool) {
+	cut, width := -1, 0
+	for i, node := range nodes {
+		w := nodeWidth(node, width)
+		if width+w > columns-1 && cut < 0 {
+			cut = i
+		}
+		width += w
+		if width > columns {
+			return max(cut, 0), columns > 0
+		}
+	}
+	return 0, false
+}
+
+// nodeWidth returns the number of columns a node takes up when written as
+// ANSI at column, which only matters for tabs.
+func nodeWidth(n node, column int) int {
+	switch {
+	case n.elem == nil && n.blob == '\t':
+		return tabWidth - column%tabWidth
+	case n.elem == nil:
+		return runeWidth(n.blob)
+	case n.elem.elementType == ELEMENT_LINK:
+		return DisplayWidth(linkText(n.elem))
+	}
+	return 0
+}
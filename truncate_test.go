@@ -0,0 +1,36 @@
+package terminal
+
+import "testing"
+
+func TestTruncateANSI(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		columns  int
+		expected string
+	}{
+		{"short lines are kept", "\x1b[31mshort\x1b[0m", 10, "\x1b[0;31mshort\x1b[0m"},
+		{"exactly fits", "1234567890", 10, "1234567890"},
+		{"plain text", "this is too long", 10, "this is t…"},
+		{"the style is kept for the ellipsis", "\x1b[1;31merror: something failed", 10, "\x1b[0;31;1merror: so…\x1b[0m"},
+		{"style changes before the cut", "\x1b[32mok\x1b[0m then more text", 8, "\x1b[0;32mok\x1b[0m then…"},
+		{"trailing blanks don't count", "ten chars      ", 9, "ten chars"},
+		{"wide characters", "日本語のテキスト", 7, "日本語…"},
+		{"links aren't split", "see \x1b]1339;url=https://example.com;content=the docs\x07", 8, "see …"},
+		{"links that fit are kept", "see \x1b]1339;url=https://example.com;content=docs\x07 now", 12, "see \x1b]8;;https://example.com\x1b\\docs\x1b]8;;\x1b\\ now"},
+		{"cursor movement is applied", "50%\r100% downloaded", 8, "100% do…"},
+		{"each line is truncated", "first line\n\x1b[33msecond line\x1b[0m", 6, "first…\n\x1b[0;33msecon…\x1b[0m"},
+		{"tabs move to the next tab stop", "a\tb\tc", 10, "a\tb…"},
+		{"a tab that doesn't fit", "a\tbcdefgh", 9, "a\t…"},
+		{"one column", "long", 1, "…"},
+		{"no limit", "long", 0, "long"},
+	}
+	for _, tc := range testCases {
+		if got := string(TruncateANSI([]byte(tc.input), tc.columns)); got != tc.expected {
+			t.Errorf("%s: TruncateANSI(%q, %d) = %q, want %q", tc.name, tc.input, tc.columns, got, tc.expected)
+		}
+		if got := DisplayWidth(tc.expected); tc.columns > 0 && got > tc.columns {
+			t.Errorf("%s: DisplayWidth(%q) = %d, want no more than %d", tc.name, tc.expected, got, tc.columns)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package terminal
+
+import (
+	"bytes"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// HTMLToANSI converts HTML rendered by this package back into ANSI, e.g. to
+// restore an archived log for diffing or viewing in a terminal:
+//
+//   - Styles are recovered from the term-* classes, and the elements that
+//     SemanticElements uses.
+//   - Links become OSC 8 hyperlinks ended by BEL, which this package's parser
+//     skips over without losing the text after them.
+//   - Line metadata rendered as processing instructions, data-timestamp
+//     attributes or <time> elements, and APCs kept by UnknownAPCComments,
+//     become APC sequences again.
+//   - Lines collapsed by CollapseRepeatedLines are repeated.
+//
+// As with SerializeANSI, the ANSI is only text, styles, links and metadata,
+// with the style reset at the end of each line. Anything the renderer added,
+// such as group durations and ellipses, or that can't be recovered, such as
+// images, is left out.
+func HTMLToANSI(input []byte) []byte {
+	var w ansiWriter
+	z := html.NewTokenizer(bytes.NewReader(input))
+
+	type open struct {
+		name  string
+		style Style
+
+		// link is set for an <a> written as a hyperlink, and skip for an
+		// element whose text is left out
+		link bool
+		skip bool
+
+		// The text of a term-repeated span
+		repeated *strings.Builder
+	}
+	var elements []open
+	top := func() open {
+		if len(elements) == 0 {
+			return open{}
+		}
+		return elements[len(elements)-1]
+	}
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			// The end of the input
+			return w.finish()
+
+		case html.TextToken:
+			e := top()
+			switch {
+			case e.repeated != nil:
+				e.repeated.Write(z.Text())
+			case !e.skip:
+				w.text(string(z.Text()), e.style)
+			}
+
+		case html.CommentToken:
+			// The tokenizer reads processing instructions as comments
+			data := string(z.Text())
+			if top().skip {
+				continue
+			}
+			if strings.HasPrefix(data, "?") {
+				w.processingInstruction(strings.Trim(data, "?"))
+			} else if payload, ok := strings.CutPrefix(strings.TrimSpace(data), "apc: "); ok {
+				// An APC kept by UnknownAPCComments
+				if payload, err := url.PathUnescape(payload); err == nil {
+					w.write("\x1b_" + payload + "\x07")
+				}
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttrs := z.TagName()
+			attrs := map[string]string{}
+			for hasAttrs {
+				var key, value []byte
+				key, value, hasAttrs = z.TagAttr()
+				attrs[string(key)] = string(value)
+			}
+			parent := top()
+			e := open{name: string(name), style: parent.style, skip: parent.skip}
+			if !e.skip {
+				if t, ok := attrs["data-timestamp"]; ok {
+					w.apc(bkNamespace, map[string]string{"t": t})
+				}
+				switch e.name {
+				case "br":
+					w.newline()
+				case "time":
+					if t, err := time.Parse(time.RFC3339Nano, attrs["datetime"]); err == nil {
+						w.apc(bkNamespace, map[string]string{"t": strconv.FormatInt(t.UnixMilli(), 10)})
+					}
+				case "a":
+					if href, ok := attrs["href"]; ok {
+						w.write("\x1b]8;;" + href + "\a")
+						e.link = true
+					}
+				case "head", "style", "script", "title":
+					e.skip = true
+				case "strong", "b":
+					e.style.Bold = true
+				case "em", "i":
+					e.style.Italic = true
+				case "del", "s":
+					e.style.Strike = true
+				case "u":
+					e.style.Underline = true
+				}
+				for _, class := range strings.Fields(attrs["class"]) {
+					switch class {
+					case "term-group-duration", "term-ellipsis":
+						e.skip = true
+					case "term-repeated":
+						e.repeated = &strings.Builder{}
+					default:
+						applyClass(&e.style, class)
+					}
+				}
+			}
+			if !slices.Contains(voidElements, e.name) && tt != html.SelfClosingTagToken {
+				elements = append(elements, e)
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			// Close any elements left open inside this one
+			i := len(elements) - 1
+			for i >= 0 && elements[i].name != string(name) {
+				i--
+			}
+			if i < 0 {
+				continue
+			}
+			for j := len(elements) - 1; j >= i; j-- {
+				switch e := elements[j]; {
+				case e.link:
+					w.write("\x1b]8;;\a")
+				case e.repeated != nil:
+					w.repeat(e.repeated.String())
+				case e.name == "summary" && !e.skip:
+					w.newline()
+				}
+			}
+			elements = elements[:i]
+		}
+	}
+}
+
+// applyClass adds a term-* class of the renderer to the style.
+func applyClass(s *Style, class string) {
+	for _, c := range [...]struct {
+		prefix string
+		apply  func(n uint8)
+	}{
+		// The longer prefixes first, as term-fg is a prefix of them
+		{"term-fgx", func(n uint8) { s.Foreground, s.Foreground256 = n, true }},
+		{"term-bgx", func(n uint8) { s.Background, s.Background256 = n, true }},
+		{"term-fgi", func(n uint8) { s.Foreground, s.Foreground256 = n, false }},
+		{"term-bgi", func(n uint8) { s.Background, s.Background256 = n, false }},
+		{"term-bg", func(n uint8) { s.Background, s.Background256 = n, false }},
+		{"term-fg", func(n uint8) {
+			switch n {
+			case 1:
+				s.Bold = true
+			case 2:
+				s.Faint = true
+			case 3:
+				s.Italic = true
+			case 4:
+				s.Underline = true
+			case 5:
+				s.Blink = true
+			case 9:
+				s.Strike = true
+			default:
+				s.Foreground, s.Foreground256 = n, false
+			}
+		}},
+	} {
+		if rest, ok := strings.CutPrefix(class, c.prefix); ok {
+			if n, err := strconv.ParseUint(rest, 10, 8); err == nil {
+				c.apply(uint8(n))
+			}
+			return
+		}
+	}
+}
+
+// An ansiWriter writes the ANSI of HTMLToANSI.
+type ansiWriter struct {
+	buf bytes.Buffer
+
+	// where the current line starts in buf, and the style written last
+	lineStart int
+	style     Style
+}
+
+func (w *ansiWriter) write(s string) {
+	w.buf.WriteString(s)
+}
+
+// text writes text in the style, starting a new line at each newline.
+func (w *ansiWriter) text(text string, style Style) {
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			w.newline()
+		}
+		// Blank lines are rendered as &nbsp;
+		if line == "" || (line == "\u00a0" && w.buf.Len() == w.lineStart) {
+			continue
+		}
+		w.write(StyleTransition(w.style, style))
+		w.style = style
+		w.write(line)
+	}
+}
+
+// newline resets the style, and starts a new line.
+func (w *ansiWriter) newline() {
+	w.write(StyleTransition(w.style, Style{}))
+	w.style = Style{}
+	w.buf.WriteByte('\n')
+	w.lineStart = w.buf.Len()
+}
+
+// repeat writes the current line again to make up the number of times given
+// by the text of a term-repeated span, "(repeated N times)".
+func (w *ansiWriter) repeat(text string) {
+	m := repeatedRegexp.FindStringSubmatch(text)
+	if m == nil {
+		return
+	}
+	n, _ := strconv.Atoi(m[1])
+	// The renderer puts a space before the count
+	if b := w.buf.Bytes(); len(b) > w.lineStart && b[len(b)-1] == ' ' {
+		w.buf.Truncate(len(b) - 1)
+	}
+	line, style := bytes.Clone(w.buf.Bytes()[w.lineStart:]), w.style
+	for i := 1; i < n; i++ {
+		w.newline()
+		w.buf.Write(line)
+		w.style = style
+	}
+}
+
+var repeatedRegexp = regexp.MustCompile(`^\(repeated (\d+) times\)$`)
+
+// processingInstruction writes a processing instruction such as
+// bk t="1684881360000" as an APC sequence.
+func (w *ansiWriter) processingInstruction(pi string) {
+	namespace, attrs, _ := strings.Cut(pi, " ")
+	data := map[string]string{}
+	for _, m := range piAttrRegexp.FindAllStringSubmatch(attrs, -1) {
+		data[m[1]] = html.UnescapeString(m[2])
+	}
+	w.apc(namespace, data)
+}
+
+var piAttrRegexp = regexp.MustCompile(`([^\s=]+)="([^"]*)"`)
+
+// apc writes line metadata as an APC sequence, e.g. \x1b_bk;t=1684881360000\x07
+func (w *ansiWriter) apc(namespace string, data map[string]string) {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	w.write("\x1b_" + namespace)
+	for _, k := range keys {
+		w.write(";" + k + "=" + data[k])
+	}
+	w.write("\x07")
+}
+
+// finish resets the style at the end, and returns the ANSI.
+func (w *ansiWriter) finish() []byte {
+	w.write(StyleTransition(w.style, Style{}))
+	return w.buf.Bytes()
+}
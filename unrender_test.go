@@ -0,0 +1,59 @@
+package terminal
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestHTMLToANSI(t *testing.T) {
+	testCases := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{"plain text", "hello &amp; goodbye", "hello & goodbye"},
+		{"styles", `<span class="term-fg31 term-fg1">error</span>: <span class="term-fgx208 term-bgi104">orange</span>`, "\x1b[31;1merror\x1b[0m: \x1b[38;5;208;104morange\x1b[0m"},
+		{"nested styles", `<span class="term-fg32">a<span class="term-fg4">b</span></span>c`, "\x1b[32ma\x1b[4mb\x1b[0mc"},
+		{"styles end with each line", `<span class="term-fg33">one</span>` + "\n" + `<span class="term-fg33">two</span>`, "\x1b[33mone\x1b[0m\n\x1b[33mtwo\x1b[0m"},
+		{"semantic elements", `<strong>bold</strong> <em>italic</em> <del>gone</del>`, "\x1b[1mbold\x1b[0m \x1b[3mitalic\x1b[0m \x1b[9mgone\x1b[0m"},
+		{"links", `see <a href="https://example.com/?a=1&amp;b=2">the docs</a>`, "see \x1b]8;;https://example.com/?a=1&b=2\athe docs\x1b]8;;\a"},
+		{"blank lines", "one\n&nbsp;\ntwo", "one\n\ntwo"},
+		{"break elements", "one<br>two", "one\ntwo"},
+		{"processing instructions", `<?bk t="1684881360000"?>line`, "\x1b_bk;t=1684881360000\x07line"},
+		{"kept APCs", `<!-- apc: ci;job=1%2D%2D%3E -->line`, "\x1b_ci;job=1-->\x07line"},
+		{"timestamp attributes", `<span class="term-line" data-timestamp="1000">line</span>`, "\x1b_bk;t=1000\x07line"},
+		{"time elements", `<time datetime="2023-05-23T22:36:00.123Z"></time>line`, "\x1b_bk;t=1684881360123\x07line"},
+		{"groups", `<details class="term-group"><summary>Build<span class="term-group-duration">1m 2s</span></summary>step` + "\n" + `done</details>`, "Build\nstep\ndone"},
+		{"repeated lines", `<span class="term-fg31">retry</span> <span class="term-repeated">(repeated 3 times)</span>` + "\nend", "\x1b[31mretry\x1b[0m\n\x1b[31mretry\x1b[0m\n\x1b[31mretry\x1b[0m\nend"},
+		{"truncated lines", `<span class="term-line term-truncated" title="too long">too<span class="term-ellipsis">…</span></span>`, "too"},
+		{"previews", `<html><head><style>.term { color: red }</style></head><body><div class="term-container">text</div></body></html>`, "text"},
+		{"images are left out", `before<img src="a.png" alt="a">after`, "beforeafter"},
+	}
+	for _, tc := range testCases {
+		if got := string(HTMLToANSI([]byte(tc.html))); got != tc.expected {
+			t.Errorf("%s: HTMLToANSI(%q) = %q, want %q", tc.name, tc.html, got, tc.expected)
+		}
+	}
+}
+
+func TestHTMLToANSIRoundTrip(t *testing.T) {
+	input := []byte("\x1b_bk;t=1684881360000\x07\x1b_ci;job=1\x07\x1b[1;31merror:\x1b[0m \x1b[38;5;208mfailed\x1b[0m\n\n" +
+		"\x1b[4;32munderlined green\x1b[24m plain green\x1b[0m\n" +
+		"\x1b[7mreversed\x1b[0m & <text>\n" +
+		"retry\nretry\nretry\n\x1b[3;9;45mend")
+	for _, opts := range []Options{
+		{},
+		{TimestampFormat: TimestampDataAttribute},
+		{TimestampFormat: TimestampTimeElement},
+		{CollapseRepeatedLines: true},
+		{SemanticElements: true},
+		{BreakElements: true},
+		{UnknownAPCComments: true},
+		{LineNumbers: LineNumberGutter, Search: regexp.MustCompile("r")},
+	} {
+		html := RenderWithOptions(input, opts)
+		if got := RenderWithOptions(HTMLToANSI(html), opts); string(got) != string(html) {
+			t.Errorf("with %+v, rendering HTMLToANSI(%q) = %q, want the same HTML", opts, html, got)
+		}
+	}
+}
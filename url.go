@@ -2,11 +2,15 @@ package terminal
 
 import (
 	"net/url"
+	"strings"
 )
 
 const unsafeURLSubstitution = "#"
 
-func sanitizeURL(s string) string {
+// sanitizeURL returns s if it is safe to use in an href or src attribute, or
+// unsafeURLSubstitution if it isn't. If allowedSchemes is non-empty, only
+// relative URLs and URLs with one of those schemes are permitted.
+func sanitizeURL(s string, allowedSchemes []string) string {
 	url, err := url.Parse(s)
 	if err != nil {
 		return unsafeURLSubstitution
@@ -19,7 +23,7 @@ func sanitizeURL(s string) string {
 
 	// deny-list known-XSS-dangerous URL schemes for <a href=""> etc.
 	// An allow-list would be preferable, but we don't know what URL schemes are being legitimately
-	// used in the wild, so that would be a breaking change, and likely require configurability.
+	// used in the wild, so that would be a breaking change. Instead the allow-list is opt-in.
 	disallowedSchemes := []string{"javascript"}
 	for _, ds := range disallowedSchemes {
 		if url.Scheme == ds {
@@ -27,6 +31,15 @@ func sanitizeURL(s string) string {
 		}
 	}
 
-	// default allow
-	return url.String()
+	if len(allowedSchemes) == 0 {
+		// default allow
+		return url.String()
+	}
+
+	for _, as := range allowedSchemes {
+		if strings.EqualFold(url.Scheme, as) {
+			return url.String()
+		}
+	}
+	return unsafeURLSubstitution
 }
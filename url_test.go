@@ -31,7 +31,39 @@ func TestSanitizeURL(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(fmt.Sprintf("%q -> %q", tc.input, tc.want), func(t *testing.T) {
-			got := sanitizeURL(tc.input)
+			got := sanitizeURL(tc.input, nil)
+			if got != tc.want {
+				t.Errorf("wanted %q -> %q, got %q", tc.input, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestSanitizeURLWithAllowedSchemes(t *testing.T) {
+	allowedSchemes := []string{"https", "S3"}
+
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		// allowed schemes
+		{input: "https://example.org/", want: "https://example.org/"},
+		{input: "HTTPS://example.org/", want: "https://example.org/"},
+		{input: "s3://bucket/key.txt", want: "s3://bucket/key.txt"},
+
+		// relative URLs are still permitted
+		{input: "/hello.txt", want: "/hello.txt"},
+		{input: "hello.txt", want: "hello.txt"},
+
+		// everything else is not
+		{input: "http://example.org/", want: "#"},
+		{input: "artifact://hello.txt", want: "#"},
+		{input: "javascript:alert(1)", want: "#"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%q -> %q", tc.input, tc.want), func(t *testing.T) {
+			got := sanitizeURL(tc.input, allowedSchemes)
 			if got != tc.want {
 				t.Errorf("wanted %q -> %q, got %q", tc.input, tc.want, got)
 			}
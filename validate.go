@@ -0,0 +1,192 @@
+package terminal
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+)
+
+// voidElements are the elements that have no closing tag.
+var voidElements = []string{"area", "base", "br", "col", "embed", "hr", "img", "input", "link", "meta", "source", "track", "wbr"}
+
+// namedEntities are the character references Validate accepts by name.
+var namedEntities = []string{"amp", "lt", "gt", "quot", "apos", "nbsp"}
+
+// Validate checks that html, as rendered by this package, is a well-formed
+// fragment, for consumers that feed it into strict XML or AMP pipelines, and
+// for fuzzing: every element other than a void element such as <img> is
+// closed, in the reverse order it was opened; attribute values are quoted;
+// processing instructions and comments are terminated, and comments, such as
+// those of Options.UnknownAPCComments, don't contain --; and every <, > and &
+// in text or attribute values is escaped. Character references other than
+// &amp;, &lt;, &gt;, &quot;, &apos;, &nbsp; and numeric ones are rejected.
+// Any HTML from Options.InsertHTML is checked too. The error gives the byte
+// offset of the first problem found.
+func Validate(html []byte) error {
+	type open struct {
+		name   string
+		offset int
+	}
+	var elements []open
+
+	for i := 0; i < len(html); {
+		switch html[i] {
+		case '<':
+			switch {
+			case bytes.HasPrefix(html[i:], []byte("<?")):
+				end := bytes.Index(html[i:], []byte("?>"))
+				if end < 0 {
+					return fmt.Errorf("byte %d: unterminated processing instruction", i)
+				}
+				i += end + len("?>")
+			case bytes.HasPrefix(html[i:], []byte("<!--")):
+				end := bytes.Index(html[i+len("<!--"):], []byte("--"))
+				if end < 0 {
+					return fmt.Errorf("byte %d: unterminated comment", i)
+				}
+				i += len("<!--") + end
+				if !bytes.HasPrefix(html[i:], []byte("-->")) {
+					return fmt.Errorf("byte %d: -- inside a comment", i)
+				}
+				i += len("-->")
+			case bytes.HasPrefix(html[i:], []byte("</")):
+				name, n := tagName(html[i+2:])
+				if name == "" || i+2+n >= len(html) || html[i+2+n] != '>' {
+					return fmt.Errorf("byte %d: malformed closing tag", i)
+				}
+				if len(elements) == 0 {
+					return fmt.Errorf("byte %d: closing tag </%s> without an open element", i, name)
+				}
+				if last := elements[len(elements)-1]; last.name != name {
+					return fmt.Errorf("byte %d: closing tag </%s> inside <%s> opened at byte %d", i, name, last.name, last.offset)
+				}
+				elements = elements[:len(elements)-1]
+				i += 2 + n + 1
+			default:
+				name, n := tagName(html[i+1:])
+				if name == "" {
+					return fmt.Errorf("byte %d: unescaped <", i)
+				}
+				end, selfClosing, err := validateAttributes(html, i+1+n)
+				if err != nil {
+					return err
+				}
+				if !selfClosing && !slices.Contains(voidElements, name) {
+					elements = append(elements, open{name: name, offset: i})
+				}
+				i = end
+			}
+		case '>':
+			return fmt.Errorf("byte %d: unescaped >", i)
+		case '&':
+			n, err := validateReference(html, i)
+			if err != nil {
+				return err
+			}
+			i += n
+		default:
+			i++
+		}
+	}
+	if len(elements) > 0 {
+		last := elements[len(elements)-1]
+		return fmt.Errorf("byte %d: <%s> is never closed", last.offset, last.name)
+	}
+	return nil
+}
+
+// tagName returns the name of the element at the start of b, and its length,
+// or "" if there isn't one.
+func tagName(b []byte) (string, int) {
+	n := 0
+	for n < len(b) && (isASCIILetter(b[n]) || (n > 0 && (b[n] >= '0' && b[n] <= '9' || b[n] == '-'))) {
+		n++
+	}
+	return string(b[:n]), n
+}
+
+// validateAttributes checks the attributes of a tag from html[i] up to the
+// end of the tag, and returns the offset after it, and whether it ended with
+// />.
+func validateAttributes(html []byte, i int) (int, bool, error) {
+	for {
+		start := i
+		for i < len(html) && (html[i] == ' ' || html[i] == '\n' || html[i] == '\t') {
+			i++
+		}
+		switch {
+		case i >= len(html):
+			return i, false, fmt.Errorf("byte %d: unterminated tag", i)
+		case html[i] == '>':
+			return i + 1, false, nil
+		case bytes.HasPrefix(html[i:], []byte("/>")):
+			return i + 2, true, nil
+		case i == start:
+			return i, false, fmt.Errorf("byte %d: missing space before attribute", i)
+		}
+
+		nameStart := i
+		for i < len(html) && (isASCIILetter(html[i]) || html[i] == '-' || html[i] == ':' || html[i] >= '0' && html[i] <= '9') {
+			i++
+		}
+		if i == nameStart {
+			return i, false, fmt.Errorf("byte %d: malformed attribute", i)
+		}
+		if i >= len(html) || html[i] != '=' {
+			// An attribute without a value, e.g. <details open>
+			continue
+		}
+		i++
+		if i >= len(html) || (html[i] != '"' && html[i] != '\'') {
+			return i, false, fmt.Errorf("byte %d: unquoted attribute value", i)
+		}
+		quote := html[i]
+		i++
+		for i < len(html) && html[i] != quote {
+			switch html[i] {
+			case '<', '>':
+				return i, false, fmt.Errorf("byte %d: unescaped %c in attribute value", i, html[i])
+			case '&':
+				n, err := validateReference(html, i)
+				if err != nil {
+					return i, false, err
+				}
+				i += n
+			default:
+				i++
+			}
+		}
+		if i >= len(html) {
+			return i, false, fmt.Errorf("byte %d: unterminated attribute value", i)
+		}
+		i++
+	}
+}
+
+// validateReference checks the character reference at html[i], and returns
+// its length.
+func validateReference(html []byte, i int) (int, error) {
+	end := bytes.IndexByte(html[i:min(i+16, len(html))], ';')
+	if end < 0 {
+		return 0, fmt.Errorf("byte %d: unescaped &", i)
+	}
+	name := html[i+1 : i+end]
+	if len(name) > 1 && name[0] == '#' {
+		digits, hex := name[1:], false
+		if digits[0] == 'x' || digits[0] == 'X' {
+			digits, hex = digits[1:], true
+		}
+		if len(digits) > 0 && bytes.IndexFunc(digits, func(r rune) bool {
+			return !(r >= '0' && r <= '9' || hex && (r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F'))
+		}) < 0 {
+			return end + 1, nil
+		}
+	} else if slices.Contains(namedEntities, string(name)) {
+		return end + 1, nil
+	}
+	return 0, fmt.Errorf("byte %d: unknown character reference &%s;", i, name)
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,117 @@
+package terminal
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	valid := []string{
+		"",
+		"plain &amp; simple &lt;text&gt; &#39;&#x2F;&nbsp;",
+		`<span class="term-fg31">red <strong>bold</strong></span>`,
+		`<img alt="a &quot;b&quot;" src="data:image/gif;base64,AA=="><br><br/>`,
+		`<details class="term-group" open><summary>title</summary>body</details>`,
+		`<?bk t="123"?>line`,
+		`line<!-- apc: a %3C-%3E b -->`,
+		`<a href='single'>quoted</a>`,
+	}
+	for _, html := range valid {
+		if err := Validate([]byte(html)); err != nil {
+			t.Errorf("Validate(%q) = %v, want nil", html, err)
+		}
+	}
+
+	invalid := []struct {
+		html string
+		err  string
+	}{
+		{`<span>unclosed`, "byte 0: <span> is never closed"},
+		{`<span><em></span></em>`, "byte 10: closing tag </span> inside <em> opened at byte 6"},
+		{`text</span>`, "byte 4: closing tag </span> without an open element"},
+		{`a < b`, "byte 2: unescaped <"},
+		{`a > b`, "byte 2: unescaped >"},
+		{`fish & chips`, "byte 5: unescaped &"},
+		{`&copy;`, "byte 0: unknown character reference &copy;"},
+		{`&#xzz;`, "byte 0: unknown character reference &#xzz;"},
+		{`<img src=unquoted>`, "byte 9: unquoted attribute value"},
+		{`<img alt="<b>">`, "byte 10: unescaped < in attribute value"},
+		{`<img alt="a&b">`, "byte 11: unescaped &"},
+		{`<img alt="unterminated`, "byte 22: unterminated attribute value"},
+		{`<span class="a"id="b"></span>`, "byte 15: missing space before attribute"},
+		{`<span`, "byte 5: unterminated tag"},
+		{`</span x>`, "byte 0: malformed closing tag"},
+		{`<?bk t="1"`, "byte 0: unterminated processing instruction"},
+		{`<!-- a -`, "byte 0: unterminated comment"},
+		{`<!-- a -- b -->`, "byte 7: -- inside a comment"},
+	}
+	for _, c := range invalid {
+		err := Validate([]byte(c.html))
+		if err == nil || err.Error() != c.err {
+			t.Errorf("Validate(%q) = %v, want %q", c.html, err, c.err)
+		}
+	}
+}
+
+// validateOptions are options that between them produce most of the markup
+// the renderer can.
+var validateOptions = []Options{
+	{},
+	{
+		LineNumbers: LineNumberGutter, Search: regexp.MustCompile(`e`), Linkify: true,
+		BuildkiteGroups: true, GitHubActionsAnnotations: true, TimestampFormat: TimestampTimeElement,
+		ProgressFrames: 1, CollapseRepeatedLines: true,
+	},
+	{
+		Accessible: true, SemanticElements: true, BreakElements: true, ElapsedTime: true,
+		LineNumbers: LineNumberDataAttribute, LineClasses: DefaultLineClasses, GitLabSections: true,
+		TruncateColumns: 20, UnknownAPCComments: true,
+	},
+}
+
+func TestRenderedFixturesValidate(t *testing.T) {
+	for _, base := range TestFiles {
+		raw := loadFixture(t, base, "raw")
+		for i, opts := range validateOptions {
+			if err := Validate(RenderWithOptions(raw, opts)); err != nil {
+				t.Errorf("%s with options %d: %v", base, i, err)
+			}
+		}
+	}
+}
+
+func TestRenderValidates(t *testing.T) {
+	inputs := []string{
+		"\x1b_bk;a?>b=1;t=1\a metadata keys are escaped",
+		"\x1b]1339;url=http://example.com;content=<&>\a",
+		"\x1b]1337;File=name=PGI+;inline=1:AA==\a",
+		"::error file=<a>,line=1::<b>&\n",
+		"\x1b_unknown --> <b> &\a an APC kept as a comment",
+	}
+	for _, input := range inputs {
+		for i, opts := range validateOptions {
+			if err := Validate(RenderWithOptions([]byte(input), opts)); err != nil {
+				t.Errorf("%q with options %d: %v", input, i, err)
+			}
+		}
+	}
+}
+
+func FuzzRenderValidates(f *testing.F) {
+	for _, base := range TestFiles {
+		f.Add(loadFixture(f, base, "raw"))
+	}
+	for _, c := range rendererTestCases {
+		f.Add([]byte(c.input))
+	}
+	f.Add([]byte("\x1b]1339;url=https://example.com;content=<b>\a \x1b_bk;t=1\a--- group\n::error file=a.go::oops\n"))
+	f.Fuzz(func(t *testing.T, input []byte) {
+		for i, opts := range validateOptions {
+			html := RenderWithOptions(input, opts)
+			if err := Validate(html); err != nil {
+				t.Errorf("options %d: %v\ninput: %q\nhtml: %s", i, err, input, strings.ToValidUTF8(string(html), "?"))
+			}
+		}
+	})
+}
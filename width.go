@@ -0,0 +1,50 @@
+package terminal
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+// tabWidth is the distance between tab stops.
+const tabWidth = 8
+
+// DisplayWidth returns the number of columns the text takes up in a terminal,
+// ignoring its escape sequences, as for lining up columns of text that
+// contains ANSI styles. Wide characters, such as CJK characters and most
+// emoji, take up two columns, and combining and zero-width characters none.
+// Tabs move to the next multiple of 8 columns. For text of several lines, it
+// returns the width of the widest.
+func DisplayWidth(text string) int {
+	widest, column := 0, 0
+	for _, r := range string(Strip([]byte(text))) {
+		switch r {
+		case '\n':
+			widest, column = max(widest, column), 0
+		case '\t':
+			column += tabWidth - column%tabWidth
+		default:
+			column += runeWidth(r)
+		}
+	}
+	return max(widest, column)
+}
+
+// runeWidth returns the number of columns a printable character takes up.
+func runeWidth(r rune) int {
+	switch {
+	case r == utf8.RuneError:
+		return 1
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) || (r >= 0x1160 && r <= 0x11ff):
+		// Combining marks, format characters such as zero-width joiners, and
+		// Hangul medial vowels and final consonants, which join the character
+		// before
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
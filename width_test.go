@@ -0,0 +1,27 @@
+package terminal
+
+import "testing"
+
+func TestDisplayWidth(t *testing.T) {
+	testCases := []struct {
+		text  string
+		width int
+	}{
+		{"", 0},
+		{"hello", 5},
+		{"\x1b[1;31merror\x1b[0m", 5},
+		{"\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\", 4},
+		{"日本語", 6},
+		{"ｆｕｌｌ", 8},
+		{"✅ done", 7},
+		{"é", 1},
+		{"a\tb", 9},
+		{"short\nmuch longer\nmid", 11},
+		{"caf\xe9", 4},
+	}
+	for _, tc := range testCases {
+		if got := DisplayWidth(tc.text); got != tc.width {
+			t.Errorf("DisplayWidth(%q) = %d, want %d", tc.text, got, tc.width)
+		}
+	}
+}